@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,11 +20,15 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
+	"github.com/Takanpon2512/english-app/internal/config"
 	"github.com/Takanpon2512/english-app/internal/handler"
+	"github.com/Takanpon2512/english-app/internal/llm"
 	"github.com/Takanpon2512/english-app/internal/middleware"
 	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
 	"github.com/Takanpon2512/english-app/internal/repository"
 	"github.com/Takanpon2512/english-app/internal/service"
+	"github.com/Takanpon2512/english-app/internal/worker"
 )
 
 func main() {
@@ -61,14 +71,6 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// ヘルスチェックエンドポイント
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "ok",
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-		})
-	})
-
 	// 環境変数から秘密鍵を取得
 	secretKey := getEnvOrDefault("JWT_SECRET_KEY", "your-secret-key")
 
@@ -78,6 +80,8 @@ func main() {
 	userTagsRepo := repository.NewUserTagsRepository(db)
 	categoryMastersRepo := repository.NewCategoryMastersRepository(db)
 	questionTemplateMastersRepo := repository.NewQuestionTemplateMastersRepository(db)
+	questionReferenceAnswersRepo := repository.NewQuestionReferenceAnswersRepository(db)
+	gradingFlagsRepo := repository.NewGradingFlagsRepository(db)
 	projectQuestionsRepo := repository.NewProjectQuestionsRepository(db)
 	questionAnswersRepo := repository.NewQuestionAnswersRepository(db)
 	correctResultsRepo := repository.NewCorrectResultsRepository(db)
@@ -85,6 +89,111 @@ func main() {
 	weaknessCategoryAnalysisRepo := repository.NewWeaknessCategoryAnalysisRepository(db)
 	weaknessDetailedAnalysisRepo := repository.NewWeaknessDetailedAnalysisRepository(db)
 	weaknessLearningAdviceRepo := repository.NewWeaknessLearningAdviceRepository(db)
+	tutorThreadsRepo := repository.NewTutorThreadsRepository(db)
+
+	// LLM呼び出しの記録（学習者の入力を伏せたプロンプト・出力・解析結果・所要時間。保存期間を過ぎた記録は定期的に削除する）
+	// プロバイダごとに記録し、構造化出力の修正依頼やフォールバックも1回ずつ記録する
+	llmCallsRepo := repository.NewLLMCallsRepository(db)
+	llmAuditRetention := config.LoadLLMAuditRetention()
+	llmCallsService := service.NewLLMCallsService(llmCallsRepo, llmAuditRetention)
+	if llmAuditRetention > 0 {
+		stopRetention := llmCallsService.WatchRetention(time.Hour)
+		defer stopRetention()
+	} else {
+		log.Println("LLM呼び出しの記録は無効です")
+	}
+
+	// LLMクライアントの初期化（再試行・タイムアウト・サーキットブレーカーはプロバイダ・機能グループごとに設定）
+	// 複数のプロバイダを指定した場合は、失敗または出力が検証を通らなかったときに次のプロバイダで再実行する
+	// 設定に不備のあるプロバイダは使用せず、1つも使用できない場合はLLMを利用する機能を無効にして起動する（縮退運転）
+	llmPolicies := config.LoadLLMPolicies()
+	var (
+		providers      []llm.Provider
+		providerErrors []string
+	)
+	providerNames := config.LoadLLMProviders()
+	for _, name := range providerNames {
+		client, err := newLLMClient(name)
+		if err != nil {
+			log.Printf("Warning: LLMプロバイダ %s を使用できません: %v", name, err)
+			providerErrors = append(providerErrors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		client = llm.NewResilientClient(client, llmPolicies)
+		if llmAuditRetention > 0 {
+			client = llm.NewAuditedClient(client, llmCallsRepo, name)
+		}
+		providers = append(providers, llm.Provider{Name: name, Client: client})
+	}
+	llmProviders := llm.NewFallbackClient(providers)
+	llmFeature := middleware.FeatureConfig{Enabled: llmProviders.Available()}
+	if !llmFeature.Enabled {
+		llmFeature.Reason = "利用可能なLLMプロバイダがありません（" + strings.Join(providerErrors, ", ") + "）"
+		log.Printf("Warning: 採点・弱点分析・チューターを無効にして起動します: %s", llmFeature.Reason)
+	}
+	var llmClient llm.LLMClient = llmProviders
+
+	// LLM利用量の記録と利用上限の確認（キャッシュから返した応答は記録しない）
+	llmUsageRepo := repository.NewLLMUsageRepository(db)
+	usageMeter := llm.NewUsageMeter(llmUsageRepo, config.LoadLLMPrices(), config.LoadLLMQuota())
+
+	// LLM応答キャッシュの初期化
+	cacheConfig := config.LoadLLMCacheConfig()
+	switch cacheConfig.Store {
+	case "memory":
+		llmClient = llm.NewCachedClient(llmClient, llm.NewMemoryCacheStore(cacheConfig.MaxEntries), cacheConfig.Options)
+	case "mysql":
+		llmClient = llm.NewCachedClient(llmClient, repository.NewLLMResponseCacheRepository(db, cacheConfig.MaxEntries), cacheConfig.Options)
+	case "none":
+		log.Println("LLM応答キャッシュは無効です")
+	default:
+		log.Fatalf("未対応のLLM応答キャッシュの保存先です: %s", cacheConfig.Store)
+	}
+
+	// キャッシュから返した応答も記録し、利用上限で呼び出さなかったリクエストは記録しない
+	if llmAuditRetention > 0 {
+		llmClient = llm.NewCacheAuditedClient(llmClient, llmCallsRepo)
+	}
+	llmClient = llm.NewMeteredClient(llmClient, usageMeter)
+
+	// 機能ごとのモデル設定とルーティング規則（採点は問題のレベル・種類と解答の長さでモデルを選ぶ）
+	// LLM_CHEAP_MODEL・LLM_STRONG_MODELのデフォルトは優先するプロバイダに合わせる
+	var primaryProvider string
+	if len(providerNames) > 0 {
+		primaryProvider = providerNames[0]
+	}
+	llmRouter := config.LoadLLMRouter(primaryProvider)
+
+	// プロンプトの読み込み（PROMPTS_DIRを指定した場合はディレクトリのテンプレートも読み込み、定期的に再読み込みする）
+	promptsDir := os.Getenv("PROMPTS_DIR")
+	prompts, err := prompt.NewRegistry(promptsDir)
+	if err != nil {
+		log.Fatal("プロンプトの読み込みに失敗しました:", err)
+	}
+	if reloadInterval := getEnvDurationOrDefault("PROMPTS_RELOAD_INTERVAL", 30*time.Second); promptsDir != "" && reloadInterval > 0 {
+		stopWatch := prompts.Watch(reloadInterval)
+		defer stopWatch()
+	}
+
+	// SIGINT・SIGTERMを受け取ったら、処理中のリクエストとジョブを終えてから停止する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 採点用ワーカープールの初期化
+	gradingPool := worker.NewPool(
+		"grading",
+		getEnvIntOrDefault("GRADING_WORKERS", 4),
+		getEnvIntOrDefault("GRADING_QUEUE_SIZE", 100),
+	)
+	gradingPool.Start()
+
+	// 弱点分析用ワーカープールの初期化
+	analysisPool := worker.NewPool(
+		"weakness-analysis",
+		getEnvIntOrDefault("ANALYSIS_WORKERS", 2),
+		getEnvIntOrDefault("ANALYSIS_QUEUE_SIZE", 20),
+	)
+	analysisPool.Start()
 
 	// サービスの初期化
 	authService := service.NewAuthService(userRepo)
@@ -92,10 +201,61 @@ func main() {
 	userTagsService := service.NewUserTagsService(db, userTagsRepo)
 	categoryMastersService := service.NewCategoryMastersService(db, categoryMastersRepo)
 	questionTemplateMastersService := service.NewQuestionTemplateMastersService(db, questionTemplateMastersRepo)
+	questionReferenceAnswersService := service.NewQuestionReferenceAnswersService(questionReferenceAnswersRepo, questionTemplateMastersRepo)
 	projectQuestionsService := service.NewProjectQuestionsService(db, projectQuestionsRepo, questionTemplateMastersRepo)
 	questionAnswersService := service.NewQuestionAnswersService(db, questionAnswersRepo, projectQuestionsRepo, questionTemplateMastersRepo)
-	correctResultsService := service.NewCorrectResultsService(db, correctResultsRepo, questionTemplateMastersRepo, questionAnswersRepo, categoryMastersRepo)
-	weaknessAnalysisService := service.NewWeaknessAnalysisService(db, weaknessAnalysisRepo, correctResultsRepo, questionAnswersRepo, questionTemplateMastersRepo, categoryMastersRepo, weaknessCategoryAnalysisRepo, weaknessDetailedAnalysisRepo, weaknessLearningAdviceRepo)
+	consensusSamples, consensusReviewStdDev := config.LoadGradingConsensus()
+	consensus := service.ConsensusConfig{Samples: consensusSamples, ReviewStdDev: consensusReviewStdDev}
+	correctResultsService := service.NewCorrectResultsService(db, correctResultsRepo, questionTemplateMastersRepo, questionAnswersRepo, categoryMastersRepo, questionReferenceAnswersRepo, gradingFlagsRepo, llmClient, llmRouter, usageMeter, gradingPool, prompts, consensus, getEnvIntOrDefault("GRADING_BATCH_CONCURRENCY", 4))
+	tutorDailyQuestions, tutorThreadQuestions, tutorMaxQuestionChars := config.LoadTutorLimits()
+	tutorLimits := service.TutorLimits{DailyQuestions: tutorDailyQuestions, ThreadQuestions: tutorThreadQuestions, MaxQuestionRunes: tutorMaxQuestionChars}
+	tutorThreadsService := service.NewTutorThreadsService(tutorThreadsRepo, correctResultsRepo, questionAnswersRepo, questionTemplateMastersRepo, llmClient, llmRouter, usageMeter, prompts, tutorLimits)
+	questionGenerationService := service.NewQuestionGenerationService(questionTemplateMastersRepo, questionReferenceAnswersRepo, categoryMastersRepo, llmClient, llmRouter, usageMeter, prompts)
+	llmUsageService := service.NewLLMUsageService(llmUsageRepo)
+	weaknessAnalysisService := service.NewWeaknessAnalysisService(db, weaknessAnalysisRepo, correctResultsRepo, questionAnswersRepo, questionTemplateMastersRepo, categoryMastersRepo, weaknessCategoryAnalysisRepo, weaknessDetailedAnalysisRepo, weaknessLearningAdviceRepo, llmClient, llmRouter, usageMeter, analysisPool, prompts)
+
+	// 前回起動時に処理中のまま残った採点ジョブ・弱点分析を再開
+	// LLMを利用できない場合は処理中のまま残し、利用できる状態で起動したときに再開する
+	// キューの長さを超える件数が残っている場合はワーカーの空きを待って投入するため、サーバーの起動を待たせずに行う
+	if llmFeature.Enabled {
+		go func() {
+			if resumed, err := correctResultsService.ResumePendingGradings(ctx); err != nil {
+				log.Println("Warning: 未処理の採点ジョブの再開に失敗しました:", err)
+			} else if resumed > 0 {
+				log.Printf("未処理の採点ジョブを %d 件再開しました", resumed)
+			}
+		}()
+
+		go func() {
+			if resumed, err := weaknessAnalysisService.ResumePendingAnalyses(ctx); err != nil {
+				log.Println("Warning: 未処理の弱点分析の再開に失敗しました:", err)
+			} else if resumed > 0 {
+				log.Printf("未処理の弱点分析を %d 件再開しました", resumed)
+			}
+		}()
+	}
+
+	// ヘルスチェックエンドポイント（LLMを利用する機能が無効な場合は status を degraded とする）
+	r.GET("/health", func(c *gin.Context) {
+		status := "ok"
+		if !llmFeature.Enabled {
+			status = "degraded"
+		}
+		feature := gin.H{"enabled": llmFeature.Enabled}
+		if !llmFeature.Enabled {
+			feature["reason"] = llmFeature.Reason
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":    status,
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"features": gin.H{
+				"grading":             feature,
+				"weakness_analysis":   feature,
+				"tutor":               feature,
+				"question_generation": feature,
+			},
+		})
+	})
 
 	// ハンドラーの初期化
 	authHandler := handler.NewAuthHandler(authService, secretKey)
@@ -103,16 +263,41 @@ func main() {
 	userTagsHandler := handler.NewUserTagsHandler(userTagsService)
 	categoryMastersHandler := handler.NewCategoryMastersHandler(categoryMastersService)
 	questionTemplateMastersHandler := handler.NewQuestionMastersHandler(questionTemplateMastersService)
+	questionReferenceAnswersHandler := handler.NewQuestionReferenceAnswersHandler(questionReferenceAnswersService)
 	projectQuestionsHandler := handler.NewProjectQuestionsHandler(projectQuestionsService)
-	questionAnswersHandler := handler.NewQuestionAnswersHandler(questionAnswersService)
+	questionAnswersHandler := handler.NewQuestionAnswersHandler(questionAnswersService, correctResultsService)
 	correctResultsHandler := handler.NewCorrectResultsHandler(correctResultsService)
 	weaknessAnalysisHandler := handler.NewWeaknessAnalysisHandler(weaknessAnalysisService)
+	tutorThreadsHandler := handler.NewTutorThreadsHandler(tutorThreadsService)
+	questionGenerationHandler := handler.NewQuestionGenerationHandler(questionGenerationService)
+	llmUsageHandler := handler.NewLLMUsageHandler(llmUsageService)
+	llmProviderHandler := handler.NewLLMProviderHandler(llmProviders)
+	llmCallsHandler := handler.NewLLMCallsHandler(llmCallsService)
 
 	// 認証ミドルウェアの初期化
 	authMiddleware := middleware.NewAuthMiddleware(middleware.AuthConfig{
 		SecretKey: secretKey,
 	})
 
+	// 管理者ミドルウェアの初期化（ADMIN_EMAILSにカンマ区切りで管理者のメールアドレスを指定する）
+	adminMiddleware := middleware.NewAdminMiddleware(middleware.AdminConfig{
+		AdminEmails: strings.Split(os.Getenv("ADMIN_EMAILS"), ","),
+	})
+
+	// LLMを利用する機能のミドルウェア（無効な場合は 503 を返す）
+	gradingFeature := llmFeature
+	gradingFeature.Feature = "grading"
+	gradingMiddleware := middleware.NewFeatureMiddleware(gradingFeature)
+	analysisFeature := llmFeature
+	analysisFeature.Feature = "weakness_analysis"
+	analysisMiddleware := middleware.NewFeatureMiddleware(analysisFeature)
+	tutorFeature := llmFeature
+	tutorFeature.Feature = "tutor"
+	tutorMiddleware := middleware.NewFeatureMiddleware(tutorFeature)
+	questionGenerationFeature := llmFeature
+	questionGenerationFeature.Feature = "question_generation"
+	questionGenerationMiddleware := middleware.NewFeatureMiddleware(questionGenerationFeature)
+
 	// 認証不要のエンドポイント
 	auth := r.Group("/api/v1/auth")
 	{
@@ -156,23 +341,108 @@ func main() {
 		api.POST("/question-answers", questionAnswersHandler.CreateQuestionAnswers)
 		api.GET("/question-answers/:project_id", questionAnswersHandler.GetQuestionAnswersByProjectID)
 		api.PUT("/question-answers/finish/:project_id", questionAnswersHandler.UpdateQuestionAnswersFinish)
+		api.PUT("/question-answers/finish/:project_id/grade", gradingMiddleware, questionAnswersHandler.FinishAndGradeQuestionAnswers)
 		api.POST("/question-answers/question-to-answer/:project_id", questionAnswersHandler.GetProjectQuestionToAnswer)
 
-		api.POST("/correct-results", correctResultsHandler.CreateCorrectResult)
+		api.POST("/correct-results", gradingMiddleware, correctResultsHandler.CreateCorrectResult)
+		api.POST("/correct-results/stream", gradingMiddleware, correctResultsHandler.StreamCorrectResult)
+		api.GET("/correct-results/status/:id", correctResultsHandler.GetCorrectResultStatus)
 		api.POST("/correct-results/get", correctResultsHandler.GetCorrectResults)
 		api.POST("/correct-results/version-list", correctResultsHandler.GetCorrectResultsVersionList)
+		api.POST("/correct-results/diff", correctResultsHandler.CompareAnswers)
+		api.POST("/correct-results/regrade", gradingMiddleware, correctResultsHandler.RegradeCorrectResult)
+		api.GET("/correct-results/history/:id", correctResultsHandler.GetGradingHistory)
+
+		// 添削結果についてチューター（LLM）に質問する
+		api.POST("/tutor-threads/messages", tutorMiddleware, tutorThreadsHandler.PostTutorMessage)
+		api.GET("/tutor-threads/:correction_result_id", tutorThreadsHandler.GetTutorThread)
 
 		// 弱点分析テーブルを作成+LLMによる分析を行う
-		api.POST("/weakness-analysis/create-analysis", weaknessAnalysisHandler.CreateWeaknessAnalysis)
+		api.POST("/weakness-analysis/create-analysis", analysisMiddleware, weaknessAnalysisHandler.CreateWeaknessAnalysis)
 		api.GET("/weakness-analysis/all-summary/:project_id", weaknessAnalysisHandler.GetWeaknessAnalysisAllSummary)
 		api.GET("/weakness-analysis/status-summary/:analysis_id", weaknessAnalysisHandler.GetWeaknessAnalysisStatusSummary)
-		api.PUT("/weakness-analysis/update-analysis", weaknessAnalysisHandler.UpdateWeaknessAnalysis)
+		api.PUT("/weakness-analysis/update-analysis", analysisMiddleware, weaknessAnalysisHandler.UpdateWeaknessAnalysis)
+	}
+
+	// 管理者用のエンドポイント
+	admin := r.Group("/api/v1/admin")
+	admin.Use(authMiddleware, adminMiddleware)
+	{
+		admin.GET("/llm-usage", llmUsageHandler.GetLLMUsageSummary)
+		admin.GET("/llm-providers", llmProviderHandler.GetLLMProviderStats)
+		admin.GET("/llm-calls", llmCallsHandler.GetLLMCalls)
+
+		admin.POST("/reference-answers", questionReferenceAnswersHandler.CreateReferenceAnswer)
+		admin.GET("/reference-answers/:question_template_master_id", questionReferenceAnswersHandler.GetReferenceAnswers)
+		admin.PUT("/reference-answers/update", questionReferenceAnswersHandler.UpdateReferenceAnswer)
+		admin.PUT("/reference-answers/delete", questionReferenceAnswersHandler.DeleteReferenceAnswer)
+
+		// LLMで作成した質問テンプレートは承認待ちとして保存し、承認すると出題されるようになる
+		admin.POST("/question-masters/generate", questionGenerationMiddleware, questionGenerationHandler.GenerateQuestionTemplates)
+		admin.GET("/question-masters/drafts", questionGenerationHandler.GetDraftQuestionTemplates)
+		admin.PUT("/question-masters/approve", questionGenerationHandler.ApproveQuestionTemplate)
+		admin.PUT("/question-masters/reject", questionGenerationHandler.RejectQuestionTemplate)
+
+		admin.GET("/correct-results/needs-review", correctResultsHandler.GetReviewCorrectResults)
+		admin.PUT("/correct-results/needs-review/resolve", correctResultsHandler.ResolveReview)
+		admin.GET("/grading-flags", correctResultsHandler.GetGradingFlags)
 	}
 
 	// サーバーの起動
 	port := getEnvOrDefault("PORT", "8080")
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("サーバーの起動に失敗しました:", err)
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Println("停止シグナルを受け取りました。サーバーを停止します")
+	case runErr = <-serverErr:
+	}
+
+	// 新しいリクエストの受付を止めて処理中のリクエストを待ち、その後ワーカーを停止する
+	// 停止までに終わらなかった採点・弱点分析はPROCESSINGのまま残り、次回起動時に再開する
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), getEnvDurationOrDefault("SHUTDOWN_TIMEOUT", 30*time.Second))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Warning: サーバーの停止に失敗しました:", err)
+	}
+	gradingPool.Stop()
+	analysisPool.Stop()
+
+	if runErr != nil {
+		log.Fatal("サーバーの起動に失敗しました:", runErr)
+	}
+	log.Println("サーバーを停止しました")
+}
+
+// LLMプロバイダ名からLLMクライアントを作成する
+// fake を指定するとネットワーク・APIキーなしで動作するフェイククライアントを使用する
+func newLLMClient(provider string) (llm.LLMClient, error) {
+	switch provider {
+	case "fake":
+		log.Println("Warning: フェイクLLMクライアントを使用します")
+		return llm.NewFakeClient(), nil
+	case "claude":
+		apiKey := os.Getenv("CLAUDE_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("CLAUDE_API_KEY が設定されていません")
+		}
+		return llm.NewClaudeClient(apiKey, os.Getenv("CLAUDE_BASE_URL")), nil
+	case "openai":
+		// OpenAI互換API（Ollama・llama.cpp serverなどのセルフホストのモデル）
+		return llm.NewOpenAIClient(os.Getenv("OPENAI_BASE_URL"), os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_MODEL")), nil
+	default:
+		return nil, fmt.Errorf("未対応のLLMプロバイダです")
 	}
 }
 
@@ -183,3 +453,24 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// 環境変数を整数として取得、未設定または不正な場合はデフォルト値を返す
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+		log.Printf("Warning: 環境変数 %s の値が不正です: %s", key, value)
+	}
+	return defaultValue
+}
+
+// 環境変数を時間（例: 30s）として取得、未設定または不正な場合はデフォルト値を返す
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
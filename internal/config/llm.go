@@ -0,0 +1,242 @@
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+)
+
+// LoadLLMPolicies 機能グループ（採点・分析）ごとの再試行・タイムアウト・サーキットブレーカー設定を環境変数から読み込む
+// 環境変数は LLM_<GROUP>_TIMEOUT のようにグループ名を大文字にして指定する（未設定の項目はデフォルト値）
+func LoadLLMPolicies() map[string]llm.Policy {
+	policies := make(map[string]llm.Policy)
+	for _, group := range []string{llm.GroupGrading, llm.GroupAnalysis} {
+		policy := llm.DefaultPolicy(group)
+		prefix := "LLM_" + strings.ToUpper(group) + "_"
+
+		policy.Timeout = envDuration(prefix+"TIMEOUT", policy.Timeout)
+		policy.MaxRetries = envInt(prefix+"MAX_RETRIES", policy.MaxRetries)
+		policy.InitialBackoff = envDuration(prefix+"INITIAL_BACKOFF", policy.InitialBackoff)
+		policy.MaxBackoff = envDuration(prefix+"MAX_BACKOFF", policy.MaxBackoff)
+		policy.BreakerThreshold = envInt(prefix+"BREAKER_THRESHOLD", policy.BreakerThreshold)
+		policy.BreakerCooldown = envDuration(prefix+"BREAKER_COOLDOWN", policy.BreakerCooldown)
+
+		policies[group] = policy
+	}
+	return policies
+}
+
+// LLMCacheConfig LLM応答キャッシュの設定
+type LLMCacheConfig struct {
+	Store      string // 保存先（memory / mysql / none）
+	MaxEntries int    // 最大エントリ数
+	Options    llm.CacheOptions
+}
+
+// LoadLLMCacheConfig LLM応答キャッシュの設定を環境変数から読み込む
+func LoadLLMCacheConfig() LLMCacheConfig {
+	store := os.Getenv("LLM_CACHE_STORE")
+	if store == "" {
+		store = "memory"
+	}
+	return LLMCacheConfig{
+		Store:      store,
+		MaxEntries: envInt("LLM_CACHE_MAX_ENTRIES", 1000),
+		Options: llm.CacheOptions{
+			TTL:           envDuration("LLM_CACHE_TTL", 7*24*time.Hour),
+			MaxEntryBytes: envInt("LLM_CACHE_MAX_ENTRY_BYTES", 64*1024),
+		},
+	}
+}
+
+// LoadLLMQuota ユーザーごとのLLM利用上限（トークン数）を環境変数から読み込む（未設定の場合は無制限）
+func LoadLLMQuota() llm.Quota {
+	return llm.Quota{
+		DailyTokens:   envInt("LLM_QUOTA_DAILY_TOKENS", 0),
+		MonthlyTokens: envInt("LLM_QUOTA_MONTHLY_TOKENS", 0),
+	}
+}
+
+// LoadLLMAuditRetention LLM呼び出しの記録の保存期間を環境変数から読み込む（0以下の場合は記録しない）
+func LoadLLMAuditRetention() time.Duration {
+	return envDuration("LLM_AUDIT_RETENTION", 30*24*time.Hour)
+}
+
+// LoadLLMPrices モデルごとの料金表を読み込む
+// LLM_PRICES に {"<モデル名またはその前方一致>": {"input": 3, "output": 15}} の形式（100万トークンあたりのUSD）で指定するとデフォルトの料金表に追加・上書きする
+func LoadLLMPrices() map[string]llm.Price {
+	prices := make(map[string]llm.Price, len(llm.DefaultPrices))
+	for model, price := range llm.DefaultPrices {
+		prices[model] = price
+	}
+
+	value := os.Getenv("LLM_PRICES")
+	if value == "" {
+		return prices
+	}
+	var overrides map[string]llm.Price
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		log.Printf("Warning: 環境変数 LLM_PRICES の値が不正です。デフォルトの料金表を使用します: %v", err)
+		return prices
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+	return prices
+}
+
+// 機能ごとのデフォルトの最大出力トークン数
+var defaultMaxTokens = map[string]int{
+	llm.FeatureGrading:            5000,
+	llm.FeatureCategoryAnalysis:   6000,
+	llm.FeatureDetailedAnalysis:   8000,
+	llm.FeatureLearningAdvice:     8000,
+	llm.FeatureTutor:              2000,
+	llm.FeatureQuestionGeneration: 6000,
+}
+
+// LoadLLMProviders 使用するLLMプロバイダを優先順に読み込む
+// LLM_PROVIDERS（カンマ区切り）を指定した場合は先頭のプロバイダが失敗したときに次のプロバイダで再実行する
+// 未指定の場合は LLM_PROVIDER の1つのみを使用する
+func LoadLLMProviders() []string {
+	value := os.Getenv("LLM_PROVIDERS")
+	if value == "" {
+		value = envString("LLM_PROVIDER", "claude")
+	}
+
+	var providers []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(providers, name) {
+			providers = append(providers, name)
+		}
+	}
+	return providers
+}
+
+// LoadLLMRouter 機能ごとのモデル設定と採点のルーティング規則を環境変数から読み込む
+// 機能ごとの設定は LLM_<FEATURE>_MODEL / LLM_<FEATURE>_MAX_TOKENS / LLM_<FEATURE>_TEMPERATURE で指定する
+// LLM_CHEAP_MODEL / LLM_STRONG_MODEL のデフォルト（Claudeのモデル）は優先するプロバイダがClaudeの場合のみ使用し、
+// それ以外のプロバイダではモデルを指定した規則のみを適用する
+func LoadLLMRouter(primaryProvider string) *llm.ModelRouter {
+	features := make(map[string]llm.FeatureConfig, len(defaultMaxTokens))
+	for feature, maxTokens := range defaultMaxTokens {
+		prefix := "LLM_" + strings.ToUpper(feature) + "_"
+		features[feature] = llm.FeatureConfig{
+			Model:       os.Getenv(prefix + "MODEL"),
+			MaxTokens:   envInt(prefix+"MAX_TOKENS", maxTokens),
+			Temperature: envFloatPtr(prefix + "TEMPERATURE"),
+		}
+	}
+
+	rules := make(map[string][]llm.RoutingRule)
+	if os.Getenv("LLM_ROUTING_ENABLED") != "false" {
+		var defaultCheapModel, defaultStrongModel string
+		if primaryProvider == "claude" {
+			defaultCheapModel = string(anthropic.ModelClaude3_5HaikuLatest)
+			defaultStrongModel = string(anthropic.ModelClaudeSonnet4_5)
+		}
+		cheapModel := envString("LLM_CHEAP_MODEL", defaultCheapModel)
+		strongModel := envString("LLM_STRONG_MODEL", defaultStrongModel)
+		for _, rule := range []llm.RoutingRule{
+			// 上級の英作文は採点の難易度が高いため高性能なモデルを使用する
+			{Name: "advanced_essay", Levels: []string{"adv"}, QuestionTypes: []string{"essay"}, Model: strongModel},
+			// 初級の問題や短い解答は安価なモデルで十分に採点できる
+			{Name: "basic_level", Levels: []string{"basic"}, Model: cheapModel},
+			{Name: "short_answer", MaxAnswerLength: envInt("LLM_SHORT_ANSWER_CHARS", 80), Model: cheapModel},
+		} {
+			// モデルが決まらない規則は適用しない（機能の設定またはプロバイダのデフォルトを使用する）
+			if rule.Model != "" {
+				rules[llm.FeatureGrading] = append(rules[llm.FeatureGrading], rule)
+			}
+		}
+	}
+
+	return llm.NewModelRouter(features, rules)
+}
+
+// LoadGradingConsensus 合議採点の問題のレベルごとの採点回数と、要確認とする正答率の標準偏差を環境変数から読み込む
+// 採点回数は GRADING_CONSENSUS_SAMPLES_<LEVEL> のように問題のレベルを大文字にして指定する（未設定の場合は1回で合議採点を行わない）
+func LoadGradingConsensus() (samples map[string]int, reviewStdDev float64) {
+	samples = make(map[string]int)
+	for _, level := range []string{"basic", "inter", "adv"} {
+		samples[level] = envInt("GRADING_CONSENSUS_SAMPLES_"+strings.ToUpper(level), 1)
+	}
+	return samples, envFloat("GRADING_CONSENSUS_REVIEW_STDDEV", 10)
+}
+
+// LoadTutorLimits チューターへの1日あたり・スレッドあたりの質問数と、質問の最大文字数の上限を環境変数から読み込む（0以下の場合は無制限）
+func LoadTutorLimits() (dailyQuestions int, threadQuestions int, maxQuestionChars int) {
+	return envInt("TUTOR_DAILY_QUESTIONS", 30), envInt("TUTOR_THREAD_QUESTIONS", 20), envInt("TUTOR_MAX_QUESTION_CHARS", 500)
+}
+
+// envString 環境変数を取得する（未設定の場合はデフォルト値）
+func envString(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// envFloatPtr 環境変数を小数として取得する（未設定または不正な場合はnil）
+func envFloatPtr(key string) *float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: 環境変数 %s の値が不正です（%s）。プロバイダのデフォルト値を使用します", key, value)
+		return nil
+	}
+	return &f
+}
+
+// envFloat 環境変数を小数として取得する（未設定または不正な場合はデフォルト値）
+func envFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: 環境変数 %s の値が不正です（%s）。デフォルト値 %v を使用します", key, value, defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
+// envInt 環境変数を整数として取得する（未設定または不正な場合はデフォルト値）
+func envInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: 環境変数 %s の値が不正です（%s）。デフォルト値 %d を使用します", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// envDuration 環境変数を時間（例: 30s, 2m）として取得する（未設定または不正な場合はデフォルト値）
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: 環境変数 %s の値が不正です（%s）。デフォルト値 %v を使用します", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
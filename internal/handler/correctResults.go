@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -34,22 +35,107 @@ func (h *CorrectResultsHandler) CreateCorrectResult(c *gin.Context) {
 		return
 	}
 
-	resCreate, err := h.correctResultsService.CreateCorrectionResult(userID.(string), &reqCreate)
+	resCreate, err := h.correctResultsService.CreateCorrectionResult(c.Request.Context(), userID.(string), &reqCreate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLLMError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	// 採点はワーカーで非同期に行い、処理中の添削結果をすぐに返す
+	if err := h.correctResultsService.EnqueueGrading(userID.(string), resCreate.ID); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, resCreate)
+}
+
+// StreamCorrectResult 添削結果を作成して採点し、LLMが生成中のアドバイスをServer-Sent Eventsで返すハンドラー
+// イベントは created（作成した添削結果）→ advice（アドバイスの断片、0回以上）→ result（採点結果）または error の順に送る
+// LLMが出力を生成し直す場合は reset を送るため、それまでに受け取ったアドバイスを破棄する
+func (h *CorrectResultsHandler) StreamCorrectResult(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var reqCreate model.CreateCorrectionResultRequest
+	if err := c.ShouldBindJSON(&reqCreate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	resCreate, err := h.correctResultsService.CreateCorrectionResult(c.Request.Context(), userID.(string), &reqCreate)
+	if err != nil {
+		respondLLMError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	stream := &gradingEventStream{c: c}
+	stream.send("created", resCreate)
+
+	resGrade, err := h.correctResultsService.GradeCorrectionResultStream(c.Request.Context(), userID.(string), resCreate.ID, stream)
+	if err != nil {
+		// ステータスコードは送信済みのため、対応するHTTPステータスをイベントに含める
+		status, body := llmErrorBody(err, http.StatusInternalServerError)
+		body["status"] = status
+		stream.send("error", body)
+		return
+	}
+
+	stream.send("result", resGrade)
+}
+
+// gradingEventStream 採点中のLLMの出力をServer-Sent Eventsとして送る
+type gradingEventStream struct {
+	c *gin.Context
+}
+
+func (s *gradingEventStream) Reset() {
+	s.send("reset", gin.H{})
+}
+
+func (s *gradingEventStream) Advice(delta string) {
+	s.send("advice", gin.H{"delta": delta})
+}
+
+// send イベントを書き込み、すぐにクライアントに送る
+func (s *gradingEventStream) send(event string, data any) {
+	s.c.SSEvent(event, data)
+	s.c.Writer.Flush()
+}
+
+// GetCorrectResultStatus 添削結果の採点状況を取得するハンドラー
+func (h *CorrectResultsHandler) GetCorrectResultStatus(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
 		return
 	}
 
-	var reqGrand model.GrandCorrectResultRequest
-	reqGrand.ID = resCreate.ID
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "添削結果IDが必要です"})
+		return
+	}
 
-	resGrand, err := h.correctResultsService.GrandCorrectResult(userID.(string), &reqGrand)
+	response, err := h.correctResultsService.GetCorrectResultStatus(userID.(string), id)
 	if err != nil {
+		if errors.Is(err, service.ErrCorrectionResultNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, resGrand)
+	c.JSON(http.StatusOK, response)
 }
 
 // GetCorrectResults 添削結果を取得するハンドラー
@@ -98,4 +184,134 @@ func (h *CorrectResultsHandler) GetCorrectResultsVersionList(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, resVersionList)
-}
\ No newline at end of file
+}
+
+// CompareAnswers 同じ問題の2回の挑戦の解答を比較するハンドラー
+func (h *CorrectResultsHandler) CompareAnswers(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var reqCompare model.CompareAnswersRequest
+	if err := c.ShouldBindJSON(&reqCompare); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	resCompare, err := h.correctResultsService.CompareAnswers(userID.(string), &reqCompare)
+	if err != nil {
+		if errors.Is(err, service.ErrCorrectionResultNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resCompare)
+}
+
+// RegradeCorrectResult 添削結果の再採点を依頼するハンドラー
+func (h *CorrectResultsHandler) RegradeCorrectResult(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var reqRegrade model.RegradeCorrectionResultRequest
+	if err := c.ShouldBindJSON(&reqRegrade); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	resRegrade, err := h.correctResultsService.RegradeCorrectionResult(c.Request.Context(), userID.(string), &reqRegrade)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCorrectionResultNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrGradingInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			respondLLMError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// 再採点はワーカーで非同期に行い、処理中の添削結果をすぐに返す
+	c.JSON(http.StatusAccepted, resRegrade)
+}
+
+// GetGradingHistory 添削結果と同じ解答の採点履歴を取得するハンドラー
+func (h *CorrectResultsHandler) GetGradingHistory(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "添削結果IDが必要です"})
+		return
+	}
+
+	response, err := h.correctResultsService.GetGradingHistory(userID.(string), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCorrectionResultNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetReviewCorrectResults 人による確認が必要な添削結果を取得するハンドラー（管理者用）
+func (h *CorrectResultsHandler) GetReviewCorrectResults(c *gin.Context) {
+	response, err := h.correctResultsService.GetReviewCorrectionResults()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResolveReview 添削結果の要確認を解除するハンドラー（管理者用）
+func (h *CorrectResultsHandler) ResolveReview(c *gin.Context) {
+	var reqResolve model.ResolveReviewRequest
+	if err := c.ShouldBindJSON(&reqResolve); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	if err := h.correctResultsService.ResolveReview(&reqResolve); err != nil {
+		if errors.Is(err, service.ErrCorrectionResultNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": reqResolve.ID})
+}
+
+// GetGradingFlags 採点時に検出したプロンプトインジェクションの疑いと矛盾する採点結果を取得するハンドラー（管理者用）
+func (h *CorrectResultsHandler) GetGradingFlags(c *gin.Context) {
+	response, err := h.correctResultsService.GetGradingFlags()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
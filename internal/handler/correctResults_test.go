@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
+	"github.com/Takanpon2512/english-app/internal/repository"
+	"github.com/Takanpon2512/english-app/internal/service"
+)
+
+const (
+	testUserID             = "user-1"
+	testQuestionAnswerID   = "answer-1"
+	testQuestionTemplateID = "template-1"
+	testCorrectionResultID = "result-1"
+)
+
+// stubCorrectResultsRepository 作成・採点の保存を記録するCorrectResultsRepository（使用しないメソッドは未実装）
+type stubCorrectResultsRepository struct {
+	repository.CorrectResultsRepository
+
+	completed    *model.UpdateCorrectionResultRequest
+	rubricScores []model.CorrectionRubricScores
+}
+
+func (r *stubCorrectResultsRepository) CreateCorrectionResult(req *model.CreateCorrectionResultRequest) (*model.CreateCorrectionResultResponse, error) {
+	return &model.CreateCorrectionResultResponse{
+		ID:                       testCorrectionResultID,
+		QuestionAnswerID:         req.QuestionAnswerID,
+		QuestionTemplateMasterID: req.QuestionTemplateMasterID,
+		ProjectID:                req.ProjectID,
+		Status:                   req.Status,
+		ChallengeCount:           req.ChallengeCount,
+	}, nil
+}
+
+func (r *stubCorrectResultsRepository) GetCorrectionResultById(id string) (*model.CorrectionResults, error) {
+	return &model.CorrectionResults{
+		ID:                       id,
+		QuestionAnswerID:         testQuestionAnswerID,
+		QuestionTemplateMasterID: testQuestionTemplateID,
+		Status:                   "PROCESSING",
+		ChallengeCount:           1,
+		GradingVersion:           1,
+		IsAuthoritative:          true,
+		GradingMode:              model.GradingModeStandard,
+	}, nil
+}
+
+func (r *stubCorrectResultsRepository) SaveRubricScores(tx *gorm.DB, correctionResultID string, scores []model.CorrectionRubricScores) error {
+	r.rubricScores = scores
+	return nil
+}
+
+func (r *stubCorrectResultsRepository) SaveCorrectionErrors(tx *gorm.DB, correctionResultID string, correctionErrors []model.CorrectionErrors) error {
+	return nil
+}
+
+func (r *stubCorrectResultsRepository) CompleteCorrectionResult(tx *gorm.DB, req *model.UpdateCorrectionResultRequest) error {
+	r.completed = req
+	return nil
+}
+
+func (r *stubCorrectResultsRepository) UpdateCorrectionResult(req *model.UpdateCorrectionResultRequest) (*model.UpdateCorrectionResultResponse, error) {
+	return nil, errors.New("採点に失敗した添削結果の更新は想定していません")
+}
+
+type stubQuestionAnswersRepository struct {
+	repository.QuestionAnswersRepository
+}
+
+func (r *stubQuestionAnswersRepository) GetQuestionAnswerById(id string) (*model.QuestionAnswers, error) {
+	return &model.QuestionAnswers{
+		ID:                       id,
+		UserID:                   testUserID,
+		QuestionTemplateMasterID: testQuestionTemplateID,
+		UserAnswer:               "I go to see a movie with my friends yesterday.",
+		ChallengeCount:           1,
+	}, nil
+}
+
+type stubQuestionTemplateMastersRepository struct {
+	repository.QuestionTemplateMastersRepository
+}
+
+func (r *stubQuestionTemplateMastersRepository) GetQuestionTemplateMasterLLMById(id string) (*model.GetQuestionTemplateMastersLLMResponse, error) {
+	return &model.GetQuestionTemplateMastersLLMResponse{
+		ID:           id,
+		QuestionType: "translate",
+		English:      "Translate the following Japanese sentence to English: 昨日、友達と映画を見に行きました。",
+		Japanese:     "次の日本語の文を英語に翻訳してください：昨日、友達と映画を見に行きました。",
+		Level:        "inter",
+		Points:       10,
+	}, nil
+}
+
+type stubReferenceAnswersRepository struct {
+	repository.QuestionReferenceAnswersRepository
+}
+
+func (r *stubReferenceAnswersRepository) GetReferenceAnswers(questionTemplateMasterID string) ([]model.QuestionReferenceAnswers, error) {
+	return nil, nil
+}
+
+type stubGradingFlagsRepository struct {
+	repository.GradingFlagsRepository
+}
+
+func (r *stubGradingFlagsRepository) CreateGradingFlags(flags []model.GradingFlags) error {
+	return nil
+}
+
+type noQuota struct{}
+
+func (noQuota) CheckQuota(ctx context.Context, userID string) error { return nil }
+
+// noopConnector トランザクションの開始・確定のみを受け付けるデータベース接続（リポジトリはスタブのためSQLは実行しない）
+type noopConnector struct{}
+
+func (noopConnector) Connect(ctx context.Context) (driver.Conn, error) { return noopConn{}, nil }
+func (noopConnector) Driver() driver.Driver                            { return noopDriver{} }
+
+type noopDriver struct{}
+
+func (noopDriver) Open(name string) (driver.Conn, error) { return noopConn{}, nil }
+
+type noopConn struct{}
+
+func (noopConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("テスト用の接続ではSQLを実行できません")
+}
+func (noopConn) Close() error              { return nil }
+func (noopConn) Begin() (driver.Tx, error) { return noopConn{}, nil }
+func (noopConn) Commit() error             { return nil }
+func (noopConn) Rollback() error           { return nil }
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(noopConnector{}),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("テスト用のデータベース接続の作成に失敗しました: %v", err)
+	}
+	return db
+}
+
+// sseEvent Server-Sent Eventsの1つのイベント
+type sseEvent struct {
+	Name string
+	Data string
+}
+
+func parseSSE(body string) []sseEvent {
+	var events []sseEvent
+	for _, block := range strings.Split(body, "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				event.Data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			}
+		}
+		if event.Name != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestStreamCorrectResult(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	prompts, err := prompt.NewRegistry("")
+	if err != nil {
+		t.Fatalf("プロンプトの読み込みに失敗しました: %v", err)
+	}
+
+	// 1回目はスキーマを満たさない出力を返し、修正依頼の後にデフォルトの採点結果を返す
+	fake := llm.NewFakeClient()
+	fake.Script(llm.FeatureGrading, `{"advice": "修正前のアドバイス"}`)
+
+	repo := &stubCorrectResultsRepository{}
+	correctResultsService := service.NewCorrectResultsService(
+		newTestDB(t),
+		repo,
+		&stubQuestionTemplateMastersRepository{},
+		&stubQuestionAnswersRepository{},
+		nil,
+		&stubReferenceAnswersRepository{},
+		&stubGradingFlagsRepository{},
+		fake,
+		llm.NewModelRouter(nil, nil),
+		noQuota{},
+		nil,
+		prompts,
+		service.ConsensusConfig{},
+		1,
+	)
+	h := NewCorrectResultsHandler(correctResultsService)
+
+	router := gin.New()
+	router.POST("/correct-results/stream", func(c *gin.Context) {
+		c.Set("user_id", testUserID)
+	}, h.StreamCorrectResult)
+
+	body := `{"question_answer_id": "` + testQuestionAnswerID + `", "question_template_master_id": "` + testQuestionTemplateID + `", "project_id": "project-1"}`
+	req := httptest.NewRequest(http.MethodPost, "/correct-results/stream", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	events := parseSSE(rec.Body.String())
+	if len(events) == 0 {
+		t.Fatalf("イベントが送信されていません（ステータス: %d, 本文: %s）", rec.Code, rec.Body.String())
+	}
+	if events[0].Name != "created" {
+		t.Errorf("最初のイベント = %s, want created", events[0].Name)
+	}
+
+	// 修正依頼の前に通知したアドバイスはresetで破棄され、その後のアドバイスが採点結果のアドバイスになる
+	var (
+		advice strings.Builder
+		resets int
+	)
+	for _, event := range events {
+		switch event.Name {
+		case "reset":
+			resets++
+			advice.Reset()
+		case "advice":
+			var data struct {
+				Delta string `json:"delta"`
+			}
+			if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+				t.Fatalf("adviceイベントのデコードに失敗しました: %v", err)
+			}
+			advice.WriteString(data.Delta)
+		}
+	}
+	if resets != 1 {
+		t.Errorf("resetイベントの数 = %d, want 1", resets)
+	}
+
+	last := events[len(events)-1]
+	if last.Name != "result" {
+		t.Fatalf("最後のイベント = %s（%s）, want result", last.Name, last.Data)
+	}
+	var result model.GrandCorrectResultResponse
+	if err := json.Unmarshal([]byte(last.Data), &result); err != nil {
+		t.Fatalf("resultイベントのデコードに失敗しました: %v", err)
+	}
+
+	// resultイベントは保存した採点結果と一致する
+	saved := repo.completed
+	if saved == nil {
+		t.Fatal("採点結果が保存されていません")
+	}
+	if result.ID != saved.ID || result.GetPoints != saved.GetPoints || result.CorrectRate != saved.CorrectRate ||
+		result.ExampleCorrection != saved.ExampleCorrection || result.Advice != saved.Advice ||
+		result.LLMModel != saved.LLMModel || result.PromptVersion != saved.PromptVersion || result.Status != saved.Status {
+		t.Errorf("resultイベント = %+v, 保存した採点結果 = %+v", result, *saved)
+	}
+	if len(result.RubricScores) != len(repo.rubricScores) {
+		t.Errorf("評価観点の数 = %d, 保存した数 = %d", len(result.RubricScores), len(repo.rubricScores))
+	}
+	if got := advice.String(); got != saved.Advice {
+		t.Errorf("通知したアドバイス = %q, 保存したアドバイス = %q", got, saved.Advice)
+	}
+	if saved.Status != "COMPLETED" || saved.LLMModel != llm.FakeModel {
+		t.Errorf("保存した採点結果のステータス・モデル = %s, %s", saved.Status, saved.LLMModel)
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/service"
+)
+
+type LLMCallsHandler struct {
+	llmCallsService service.LLMCallsService
+}
+
+func NewLLMCallsHandler(llmCallsService service.LLMCallsService) *LLMCallsHandler {
+	return &LLMCallsHandler{
+		llmCallsService: llmCallsService,
+	}
+}
+
+// GetLLMCalls LLM呼び出しの記録を取得するハンドラー（管理者用）
+func (h *LLMCallsHandler) GetLLMCalls(c *gin.Context) {
+	var req model.GetLLMCallsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := h.llmCallsService.GetLLMCalls(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLLMCallQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
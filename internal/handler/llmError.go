@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+)
+
+// respondLLMError LLMの利用に関するエラーを対応するHTTPステータスで返す
+// LLMに関係しないエラーはdefaultStatusで返す
+func respondLLMError(c *gin.Context, err error, defaultStatus int) {
+	status, body := llmErrorBody(err, defaultStatus)
+	c.JSON(status, body)
+}
+
+// llmErrorBody LLMの利用に関するエラーのHTTPステータスとレスポンスボディ
+func llmErrorBody(err error, defaultStatus int) (int, gin.H) {
+	var quotaErr *llm.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return http.StatusTooManyRequests, gin.H{
+			"error":    quotaErr.Error(),
+			"code":     "LLM_QUOTA_EXCEEDED",
+			"period":   quotaErr.Period,
+			"limit":    quotaErr.Limit,
+			"used":     quotaErr.Used,
+			"reset_at": quotaErr.ResetAt,
+		}
+	}
+
+	// プロバイダが設定されていない・サーキットブレーカー作動中の場合は一時的に利用できないことを返す
+	if errors.Is(err, llm.ErrUnavailable) || errors.Is(err, llm.ErrCircuitOpen) {
+		return http.StatusServiceUnavailable, gin.H{
+			"error": err.Error(),
+			"code":  "LLM_UNAVAILABLE",
+		}
+	}
+
+	return defaultStatus, gin.H{"error": err.Error()}
+}
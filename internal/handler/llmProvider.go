@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+)
+
+type LLMProviderHandler struct {
+	providers *llm.FallbackClient
+}
+
+func NewLLMProviderHandler(providers *llm.FallbackClient) *LLMProviderHandler {
+	return &LLMProviderHandler{
+		providers: providers,
+	}
+}
+
+// GetLLMProviderStats LLMプロバイダごとの呼び出し回数・失敗回数を取得するハンドラー（管理者用）
+// 集計はプロセスの起動時からの値で、プロバイダは優先順に並ぶ
+func (h *LLMProviderHandler) GetLLMProviderStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"providers": h.providers.Stats(),
+	})
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/service"
+)
+
+type LLMUsageHandler struct {
+	llmUsageService service.LLMUsageService
+}
+
+func NewLLMUsageHandler(llmUsageService service.LLMUsageService) *LLMUsageHandler {
+	return &LLMUsageHandler{
+		llmUsageService: llmUsageService,
+	}
+}
+
+// GetLLMUsageSummary LLM利用量の集計を取得するハンドラー（管理者用）
+func (h *LLMUsageHandler) GetLLMUsageSummary(c *gin.Context) {
+	var req model.GetLLMUsageSummaryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := h.llmUsageService.GetLLMUsageSummary(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUsageQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -37,6 +38,11 @@ func (h *ProjectQuestionsHandler) CreateProjectQuestions(c *gin.Context) {
 	// プロジェクト質問作成
 	response, err := h.projectQuestionsService.CreateProjectQuestions(userID.(string), &req)
 	if err != nil {
+		// 存在しない・出題できない（承認待ち・却下した）質問テンプレートは404を返す
+		if errors.Is(err, service.ErrQuestionTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -11,11 +11,13 @@ import (
 
 type QuestionAnswersHandler struct {
 	questionAnswersService service.QuestionAnswersService
+	correctResultsService  service.CorrectResultsService
 }
 
-func NewQuestionAnswersHandler(questionAnswersService service.QuestionAnswersService) *QuestionAnswersHandler {
+func NewQuestionAnswersHandler(questionAnswersService service.QuestionAnswersService, correctResultsService service.CorrectResultsService) *QuestionAnswersHandler {
 	return &QuestionAnswersHandler{
 		questionAnswersService: questionAnswersService,
+		correctResultsService:  correctResultsService,
 	}
 }
 
@@ -83,6 +85,46 @@ func (h *QuestionAnswersHandler) UpdateQuestionAnswersFinish(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// FinishAndGradeQuestionAnswers プロジェクトIDに紐づく質問回答を完了にし、挑戦の解答をまとめて採点するハンドラー
+func (h *QuestionAnswersHandler) FinishAndGradeQuestionAnswers(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	// LLMの利用上限を超えている場合は、解答を完了にせずに返す（完了にすると再度の呼び出しで採点できなくなるため）
+	if err := h.correctResultsService.CheckGradingQuota(c.Request.Context(), userID.(string)); err != nil {
+		respondLLMError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	projectID := c.Param("project_id")
+	finished, err := h.questionAnswersService.UpdateQuestionAnswersFinish(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 解答は完了にしたうえで挑戦のすべての解答を採点する（採点を開始できない場合はエラーを返し、解答は個別に採点できる）
+	// 完了にする解答がない場合（採点に失敗した解答の再試行など）は、完了した最新の挑戦を採点する
+	challengeCount := 0
+	if len(finished.QuestionAnswers) > 0 {
+		challengeCount = finished.QuestionAnswers[0].ChallengeCount
+	}
+	grading, err := h.correctResultsService.GradeChallenge(c.Request.Context(), userID.(string), projectID, challengeCount)
+	if err != nil {
+		respondLLMError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.FinishAndGradeQuestionAnswersResponse{
+		QuestionAnswers: finished.QuestionAnswers,
+		Grading:         grading,
+	})
+}
+
 // GetProjectQuestionToAnswer プロジェクトに紐づく問題の中から1題ランダムに取得するハンドラー
 func (h *QuestionAnswersHandler) GetProjectQuestionToAnswer(c *gin.Context) {
 	// コンテキストからユーザーIDを取得
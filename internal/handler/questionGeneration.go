@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/service"
+)
+
+type QuestionGenerationHandler struct {
+	questionGenerationService service.QuestionGenerationService
+}
+
+func NewQuestionGenerationHandler(questionGenerationService service.QuestionGenerationService) *QuestionGenerationHandler {
+	return &QuestionGenerationHandler{
+		questionGenerationService: questionGenerationService,
+	}
+}
+
+// GenerateQuestionTemplates LLMで質問テンプレートを作成し、承認待ちとして保存するハンドラー（管理者用）
+func (h *QuestionGenerationHandler) GenerateQuestionTemplates(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var req model.GenerateQuestionTemplatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := h.questionGenerationService.GenerateQuestionTemplates(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondLLMError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetDraftQuestionTemplates 承認待ちの質問テンプレートの一覧を取得するハンドラー（管理者用）
+func (h *QuestionGenerationHandler) GetDraftQuestionTemplates(c *gin.Context) {
+	response, err := h.questionGenerationService.GetDraftQuestionTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ApproveQuestionTemplate 承認待ちの質問テンプレートを承認するハンドラー（管理者用）
+func (h *QuestionGenerationHandler) ApproveQuestionTemplate(c *gin.Context) {
+	h.reviewQuestionTemplate(c, h.questionGenerationService.ApproveQuestionTemplate)
+}
+
+// RejectQuestionTemplate 承認待ちの質問テンプレートを却下するハンドラー（管理者用）
+func (h *QuestionGenerationHandler) RejectQuestionTemplate(c *gin.Context) {
+	h.reviewQuestionTemplate(c, h.questionGenerationService.RejectQuestionTemplate)
+}
+
+func (h *QuestionGenerationHandler) reviewQuestionTemplate(c *gin.Context, review func(userID string, req *model.ReviewQuestionTemplateRequest) (*model.QuestionTemplateMastersSummary, error)) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var req model.ReviewQuestionTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := review(userID.(string), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrQuestionTemplateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrQuestionTemplateNotDraft):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
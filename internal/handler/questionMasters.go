@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -86,6 +87,11 @@ func (h *QuestionMastersHandler) GetQuestionMasterByID(c *gin.Context) {
 	response, err := h.questionMastersService.GetQuestionTemplateMasterByID(userID.(string), id)
 
 	if err != nil {
+		// 存在しない・出題できない（承認待ち・却下した）質問テンプレートは404を返す
+		if errors.Is(err, service.ErrQuestionTemplateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
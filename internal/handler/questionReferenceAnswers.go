@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/service"
+)
+
+type QuestionReferenceAnswersHandler struct {
+	questionReferenceAnswersService service.QuestionReferenceAnswersService
+}
+
+func NewQuestionReferenceAnswersHandler(questionReferenceAnswersService service.QuestionReferenceAnswersService) *QuestionReferenceAnswersHandler {
+	return &QuestionReferenceAnswersHandler{
+		questionReferenceAnswersService: questionReferenceAnswersService,
+	}
+}
+
+// CreateReferenceAnswer 質問テンプレートに解答例を追加するハンドラー（管理者用）
+func (h *QuestionReferenceAnswersHandler) CreateReferenceAnswer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var req model.CreateQuestionReferenceAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := h.questionReferenceAnswersService.CreateReferenceAnswer(userID.(string), &req)
+	if err != nil {
+		respondReferenceAnswerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetReferenceAnswers 質問テンプレートの解答例の一覧を取得するハンドラー（管理者用）
+func (h *QuestionReferenceAnswersHandler) GetReferenceAnswers(c *gin.Context) {
+	questionTemplateMasterID := c.Param("question_template_master_id")
+	if questionTemplateMasterID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "質問テンプレートIDが必要です"})
+		return
+	}
+
+	response, err := h.questionReferenceAnswersService.GetReferenceAnswers(questionTemplateMasterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateReferenceAnswer 解答例を更新するハンドラー（管理者用）
+func (h *QuestionReferenceAnswersHandler) UpdateReferenceAnswer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var req model.UpdateQuestionReferenceAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := h.questionReferenceAnswersService.UpdateReferenceAnswer(userID.(string), &req)
+	if err != nil {
+		respondReferenceAnswerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteReferenceAnswer 解答例を削除するハンドラー（管理者用）
+func (h *QuestionReferenceAnswersHandler) DeleteReferenceAnswer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var req model.DeleteQuestionReferenceAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := h.questionReferenceAnswersService.DeleteReferenceAnswer(userID.(string), &req)
+	if err != nil {
+		respondReferenceAnswerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondReferenceAnswerError 質問テンプレート・解答例が存在しない場合は404、それ以外は500を返す
+func respondReferenceAnswerError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrQuestionTemplateNotFound) || errors.Is(err, service.ErrReferenceAnswerNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
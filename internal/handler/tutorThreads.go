@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/service"
+)
+
+type TutorThreadsHandler struct {
+	tutorThreadsService service.TutorThreadsService
+}
+
+func NewTutorThreadsHandler(tutorThreadsService service.TutorThreadsService) *TutorThreadsHandler {
+	return &TutorThreadsHandler{
+		tutorThreadsService: tutorThreadsService,
+	}
+}
+
+// PostTutorMessage 添削結果についてチューターに質問し、回答を返すハンドラー
+func (h *TutorThreadsHandler) PostTutorMessage(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	var req model.PostTutorMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効なリクエストです"})
+		return
+	}
+
+	response, err := h.tutorThreadsService.PostTutorMessage(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTutorQuestion):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrCorrectionResultNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTutorNotReady):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTutorLimitExceeded):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "code": "TUTOR_LIMIT_EXCEEDED"})
+		default:
+			respondLLMError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTutorThread 添削結果に対するチューターとの対話の履歴を取得するハンドラー
+func (h *TutorThreadsHandler) GetTutorThread(c *gin.Context) {
+	// コンテキストからユーザーIDを取得
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
+
+	correctionResultID := c.Param("correction_result_id")
+	if correctionResultID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "添削結果IDが必要です"})
+		return
+	}
+
+	response, err := h.tutorThreadsService.GetTutorThread(userID.(string), correctionResultID)
+	if err != nil {
+		if errors.Is(err, service.ErrCorrectionResultNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -34,12 +34,13 @@ func (h *WeaknessAnalysisHandler) CreateWeaknessAnalysis(c *gin.Context) {
     return
   }
 
-  response, err := h.weaknessAnalysisService.CreateWeaknessAnalysis(userId.(string), &req)
+  // 分析はバックグラウンドで実行されるため、進捗はstatus-summaryで確認する
+  response, err := h.weaknessAnalysisService.CreateWeaknessAnalysis(c.Request.Context(), userId.(string), &req)
   if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    respondLLMError(c, err, http.StatusInternalServerError)
     return
   }
-  c.JSON(http.StatusCreated, response)
+  c.JSON(http.StatusAccepted, response)
 }
 
 // UpdateWeaknessAnalysis 学習弱点分析を再分析して更新するハンドラー
@@ -57,12 +58,13 @@ func (h *WeaknessAnalysisHandler) UpdateWeaknessAnalysis(c *gin.Context) {
     return
   }
 
-  response, err := h.weaknessAnalysisService.UpdateWeaknessAnalysis(userId.(string), &req)
+  // 再分析はバックグラウンドで実行されるため、受付時点の分析状況を返す
+  response, err := h.weaknessAnalysisService.UpdateWeaknessAnalysis(c.Request.Context(), userId.(string), &req)
   if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    respondLLMError(c, err, http.StatusInternalServerError)
     return
   }
-  c.JSON(http.StatusOK, response)
+  c.JSON(http.StatusAccepted, response)
 }
 
 // GetWeaknessAnalysisAllSummary 学習弱点分析の全ての結果を取得するハンドラー
@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// LLM呼び出しの出力の解析結果
+const (
+	CallOutcomeParsed          = "parsed"           // 構造化出力がスキーマを満たしていた
+	CallOutcomeInvalidJSON     = "invalid_json"     // 出力からJSONオブジェクトを取り出せなかった
+	CallOutcomeSchemaViolation = "schema_violation" // 出力がスキーマを満たしていなかった
+	CallOutcomeText            = "text"             // 構造化出力を指定していない呼び出し
+	CallOutcomeError           = "error"            // 呼び出し自体が失敗した
+)
+
+// CallRecord 1回のLLM呼び出しの監査記録
+type CallRecord struct {
+	Feature       string
+	UserID        string
+	SubjectID     string // 呼び出しに関連する添削結果・弱点分析のID
+	Model         string // 応答したモデル（失敗した場合はリクエストのモデル）
+	Provider      string
+	PromptVersion string
+	Attempt       int    // 構造化出力の修正依頼を含めた試行回数（1始まり）
+	Prompt        string // 学習者の入力を伏せたプロンプト
+	Response      string // 出力テキスト（加工しない）
+	Outcome       string // 出力の解析結果（CallOutcome* 定数）
+	Error         string // 呼び出しが失敗した場合のエラー
+	InputTokens   int
+	OutputTokens  int
+	Latency       time.Duration
+	Cached        bool
+}
+
+// CallStore LLM呼び出しの監査記録の保存先
+type CallStore interface {
+	RecordCall(ctx context.Context, record *CallRecord) error
+}
+
+// AuditedClient LLM呼び出しごとにプロンプト（学習者の入力を伏せたもの）・出力・解析結果・所要時間を記録するLLMClient
+// プロバイダごとにFallbackClientの内側でラップし、構造化出力の修正依頼やフォールバックも1回ずつ記録する
+// キャッシュから返した応答はプロバイダを呼び出さないため、キャッシュの外側でラップしたクライアント（cachedOnly）で記録する
+type AuditedClient struct {
+	next       LLMClient
+	store      CallStore
+	provider   string
+	cachedOnly bool
+}
+
+// NewAuditedClient 監査記録の保存先とプロバイダ名を指定してプロバイダのクライアントをラップする
+func NewAuditedClient(next LLMClient, store CallStore, provider string) *AuditedClient {
+	return &AuditedClient{next: next, store: store, provider: provider}
+}
+
+// NewCacheAuditedClient キャッシュから返した応答のみを記録するようにクライアントをラップする
+func NewCacheAuditedClient(next LLMClient, store CallStore) *AuditedClient {
+	return &AuditedClient{next: next, store: store, cachedOnly: true}
+}
+
+func (c *AuditedClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	start := time.Now()
+	res, err := c.next.Generate(ctx, req)
+	if c.cachedOnly && (err != nil || !res.Cached) {
+		return res, err
+	}
+
+	record := &CallRecord{
+		Feature:       req.Feature,
+		UserID:        req.UserID,
+		SubjectID:     req.SubjectID,
+		Model:         req.Model,
+		Provider:      c.provider,
+		PromptVersion: req.PromptVersion,
+		Attempt:       callAttempt(req),
+		Prompt:        RedactPrompt(req),
+		Latency:       time.Since(start),
+	}
+	if err != nil {
+		record.Outcome = CallOutcomeError
+		record.Error = err.Error()
+	} else {
+		record.Model = res.Model
+		if res.Provider != "" {
+			record.Provider = res.Provider
+		}
+		record.Response = res.Text
+		record.Outcome = callOutcome(req, res)
+		record.InputTokens = res.InputTokens
+		record.OutputTokens = res.OutputTokens
+		record.Cached = res.Cached
+	}
+
+	// 呼び出し元が切断しても記録は残し、記録の失敗でLLM呼び出しを失敗させない
+	if storeErr := c.store.RecordCall(context.WithoutCancel(ctx), record); storeErr != nil {
+		log.Printf("Warning: LLM呼び出しの記録に失敗しました（機能: %s）: %v", req.Feature, storeErr)
+	}
+	return res, err
+}
+
+// Unwrap ラップしているクライアントを返す
+func (c *AuditedClient) Unwrap() LLMClient {
+	return c.next
+}
+
+// callAttempt 構造化出力の修正依頼を含めた試行回数（会話履歴中のLLMの出力の数+1）
+// 構造化出力を指定していない呼び出し（チューターとの対話など）の会話履歴は修正依頼ではないため1とする
+func callAttempt(req *Request) int {
+	attempt := 1
+	if req.Output == nil {
+		return attempt
+	}
+	for _, m := range req.Messages {
+		if m.Role == RoleAssistant {
+			attempt++
+		}
+	}
+	return attempt
+}
+
+// callOutcome 出力の解析結果（構造化出力の場合はスキーマで検証する）
+func callOutcome(req *Request, res *Response) string {
+	if req.Output == nil || req.Output.Schema == nil {
+		return CallOutcomeText
+	}
+	jsonStr, err := ExtractJSONObject(res.Text)
+	if err != nil {
+		return CallOutcomeInvalidJSON
+	}
+	if len(req.Output.Schema.Validate([]byte(jsonStr))) > 0 {
+		return CallOutcomeSchemaViolation
+	}
+	return CallOutcomeParsed
+}
+
+// 監査記録で伏せる学習者の入力
+var (
+	// learnerInputPattern プロンプトで学習者の入力を囲むタグ（採点プロンプトの解答・再採点の理由、チューターへの質問）
+	// タグは行単位で置かれるため、プロンプトの説明文中のタグ名には一致させない
+	learnerInputPattern = regexp.MustCompile(`(?ms)^<(learner_answer|appeal_reason|learner_message)>$(.*?)^</(learner_answer|appeal_reason|learner_message)>$`)
+	// learnerJSONPattern 分析プロンプトのJSONに含まれる学習者の解答
+	learnerJSONPattern = regexp.MustCompile(`"user_answer"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	// emailPattern メールアドレス
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// RedactPrompt 監査記録に残すプロンプト（学習者の入力とメールアドレスを伏せ、会話履歴中のLLMの出力は文字数のみにする）
+// LLMの出力はそれぞれの呼び出しの記録に残るため、修正依頼の会話履歴では繰り返さない
+func RedactPrompt(req *Request) string {
+	var b strings.Builder
+	if req.System != "" {
+		b.WriteString("[system]\n")
+		b.WriteString(redactText(req.System))
+		b.WriteString("\n\n")
+	}
+	for i, m := range req.Messages {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", m.Role)
+		if m.Role == RoleAssistant {
+			fmt.Fprintf(&b, "（LLMの出力 %d文字）", utf8.RuneCountInString(m.Content))
+			continue
+		}
+		b.WriteString(redactText(m.Content))
+	}
+	return b.String()
+}
+
+// redactText テキスト中の学習者の入力とメールアドレスを伏せる
+func redactText(text string) string {
+	text = learnerInputPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := learnerInputPattern.FindStringSubmatch(match)
+		return fmt.Sprintf("<%s>（学習者の入力 %d文字）</%s>", groups[1], utf8.RuneCountInString(strings.TrimSpace(groups[2])), groups[3])
+	})
+	text = learnerJSONPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := learnerJSONPattern.FindStringSubmatch(match)
+		return fmt.Sprintf(`"user_answer": "（学習者の入力 %d文字）"`, utf8.RuneCountInString(groups[1]))
+	})
+	return emailPattern.ReplaceAllString(text, "（メールアドレス）")
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryCallStore 監査記録をメモリに保存するCallStore
+type memoryCallStore struct {
+	mu      sync.Mutex
+	records []CallRecord
+}
+
+func (s *memoryCallStore) RecordCall(ctx context.Context, record *CallRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, *record)
+	return nil
+}
+
+// testScoreRequest 0〜100の得点を返させる構造化出力のリクエスト
+func testScoreRequest() *Request {
+	req := NewUserRequest(FeatureGrading, "", 100, "<learner_answer>\nI go to school.\n</learner_answer>")
+	req.Output = &OutputSchema{
+		Name: "score",
+		Schema: ObjectSchema(map[string]*Schema{
+			"score": IntegerSchema("得点", 0, 100),
+		}),
+	}
+	return req
+}
+
+// 構造化出力の修正依頼はプロバイダの呼び出しごとに試行回数・解析結果・プロバイダ名を記録する
+func TestAuditedClientRecordsEachAttempt(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputs      []string
+		wantOutcomes []string
+	}{
+		{name: "JSONでない出力の後に成功", outputs: []string{"採点できませんでした", `{"score": 80}`}, wantOutcomes: []string{CallOutcomeInvalidJSON, CallOutcomeParsed}},
+		{name: "スキーマ違反の後に成功", outputs: []string{`{"score": 120}`, `{"score": 80}`}, wantOutcomes: []string{CallOutcomeSchemaViolation, CallOutcomeParsed}},
+		{name: "修正後も検証を通らない", outputs: []string{"x", "y", "z"}, wantOutcomes: []string{CallOutcomeInvalidJSON, CallOutcomeInvalidJSON, CallOutcomeInvalidJSON}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeClient()
+			fake.Script(FeatureGrading, tt.outputs...)
+			store := &memoryCallStore{}
+			client := NewFallbackClient([]Provider{{Name: "fake", Client: NewAuditedClient(fake, store, "fake")}})
+
+			var out struct {
+				Score int `json:"score"`
+			}
+			_, err := GenerateStructured(context.Background(), client, testScoreRequest(), &out, nil)
+			wantErr := tt.wantOutcomes[len(tt.wantOutcomes)-1] != CallOutcomeParsed
+			if (err != nil) != wantErr {
+				t.Fatalf("GenerateStructured のエラー = %v, want エラー %v", err, wantErr)
+			}
+
+			if len(store.records) != len(tt.wantOutcomes) {
+				t.Fatalf("記録の数 = %d, want %d", len(store.records), len(tt.wantOutcomes))
+			}
+			for i, record := range store.records {
+				if record.Attempt != i+1 || record.Outcome != tt.wantOutcomes[i] || record.Provider != "fake" {
+					t.Errorf("%d件目の記録 = 試行 %d, 解析結果 %s, プロバイダ %q; want %d, %s, fake", i+1, record.Attempt, record.Outcome, record.Provider, i+1, tt.wantOutcomes[i])
+				}
+				if record.Response != tt.outputs[i] {
+					t.Errorf("%d件目の出力 = %q, want %q", i+1, record.Response, tt.outputs[i])
+				}
+			}
+		})
+	}
+}
+
+// フォールバックした場合は呼び出したプロバイダごとに記録する
+func TestAuditedClientRecordsFallback(t *testing.T) {
+	failing := NewFakeClient()
+	failing.SetDefault(FeatureGrading, "採点できませんでした")
+	fake := NewFakeClient()
+	fake.Script(FeatureGrading, `{"score": 80}`)
+	store := &memoryCallStore{}
+	client := NewFallbackClient([]Provider{
+		{Name: "primary", Client: NewAuditedClient(failing, store, "primary")},
+		{Name: "secondary", Client: NewAuditedClient(fake, store, "secondary")},
+	})
+
+	var out struct {
+		Score int `json:"score"`
+	}
+	if _, err := GenerateStructured(context.Background(), client, testScoreRequest(), &out, nil); err != nil {
+		t.Fatalf("GenerateStructured: %v", err)
+	}
+
+	want := []struct {
+		provider string
+		attempt  int
+		outcome  string
+	}{
+		{"primary", 1, CallOutcomeInvalidJSON},
+		{"primary", 2, CallOutcomeInvalidJSON},
+		{"primary", 3, CallOutcomeInvalidJSON},
+		{"secondary", 1, CallOutcomeParsed},
+	}
+	if len(store.records) != len(want) {
+		t.Fatalf("記録の数 = %d, want %d", len(store.records), len(want))
+	}
+	for i, w := range want {
+		record := store.records[i]
+		if record.Provider != w.provider || record.Attempt != w.attempt || record.Outcome != w.outcome {
+			t.Errorf("%d件目の記録 = %s, 試行 %d, %s; want %s, %d, %s", i+1, record.Provider, record.Attempt, record.Outcome, w.provider, w.attempt, w.outcome)
+		}
+	}
+}
+
+// キャッシュの外側でラップしたクライアントはキャッシュから返した応答のみを記録する
+func TestCacheAuditedClient(t *testing.T) {
+	fake := NewFakeClient()
+	store := &memoryCallStore{}
+	client := NewCacheAuditedClient(NewCachedClient(fake, NewMemoryCacheStore(10), CacheOptions{TTL: time.Minute}), store)
+	req := NewUserRequest(FeatureTutor, "", 100, "went と go の違いを教えてください")
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Generate(context.Background(), req); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+	if len(store.records) != 1 || !store.records[0].Cached {
+		t.Errorf("記録 = %+v, want キャッシュから返した応答1件", store.records)
+	}
+}
@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// CacheEntry キャッシュに保存するLLMの応答
+type CacheEntry struct {
+	Feature       string
+	Model         string
+	Provider      string
+	PromptVersion string
+	Text          string
+	InputTokens   int
+	OutputTokens  int
+}
+
+// CacheStore LLM応答キャッシュの保存先
+type CacheStore interface {
+	// Get キーに対応する有効期限内のエントリを取得する（存在しない場合はnil）
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	// Set エントリを有効期限付きで保存する
+	Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error
+}
+
+// CacheOptions キャッシュの有効期限とサイズの上限
+type CacheOptions struct {
+	TTL           time.Duration // エントリの有効期限
+	MaxEntryBytes int           // 保存する応答テキストの最大サイズ（超える応答はキャッシュしない）
+}
+
+// CachedClient 同一のリクエストに対する応答をキャッシュから返すLLMClient
+// キーはモデル・プロンプトのバージョン・作成したプロンプト本文などのハッシュとする
+type CachedClient struct {
+	next    LLMClient
+	store   CacheStore
+	options CacheOptions
+}
+
+// NewCachedClient キャッシュの保存先を指定してクライアントをラップする
+func NewCachedClient(next LLMClient, store CacheStore, options CacheOptions) *CachedClient {
+	return &CachedClient{next: next, store: store, options: options}
+}
+
+func (c *CachedClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if req.NoCache {
+		return c.next.Generate(ctx, req)
+	}
+	key := CacheKey(req)
+
+	// キャッシュの障害でLLM呼び出しを失敗させないよう、エラーはログ出力のみとする
+	entry, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Printf("Warning: LLM応答キャッシュの取得に失敗しました（機能: %s）: %v", req.Feature, err)
+	}
+	if entry != nil {
+		return &Response{
+			Text:         entry.Text,
+			Model:        entry.Model,
+			Provider:     entry.Provider,
+			InputTokens:  entry.InputTokens,
+			OutputTokens: entry.OutputTokens,
+			Cached:       true,
+		}, nil
+	}
+
+	res, err := c.next.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cacheable(req, res) {
+		if err := c.store.Set(ctx, key, &CacheEntry{
+			Feature:       req.Feature,
+			Model:         res.Model,
+			Provider:      res.Provider,
+			PromptVersion: req.PromptVersion,
+			Text:          res.Text,
+			InputTokens:   res.InputTokens,
+			OutputTokens:  res.OutputTokens,
+		}, c.options.TTL); err != nil {
+			log.Printf("Warning: LLM応答キャッシュの保存に失敗しました（機能: %s）: %v", req.Feature, err)
+		}
+	}
+
+	return res, nil
+}
+
+// cacheable 応答をキャッシュに保存するかどうか
+// 構造化出力の場合は検証を通る応答のみ保存し、不正な応答が再利用されないようにする
+// （Request.Validateがある場合は値の制約も含めて検証し、ない場合はスキーマのみ検証する）
+func (c *CachedClient) cacheable(req *Request, res *Response) bool {
+	if c.options.TTL <= 0 {
+		return false
+	}
+	if c.options.MaxEntryBytes > 0 && len(res.Text) > c.options.MaxEntryBytes {
+		return false
+	}
+	if req.Validate != nil {
+		return res.Validated || len(req.Validate(res.Text)) == 0
+	}
+	if req.Output != nil && req.Output.Schema != nil {
+		jsonStr, err := ExtractJSONObject(res.Text)
+		if err != nil || len(req.Output.Schema.Validate([]byte(jsonStr))) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CacheKey リクエストの内容からキャッシュキー（SHA-256の16進文字列）を作成する
+func CacheKey(req *Request) string {
+	// 応答に影響する項目のみを含める（呼び出し元の機能やユーザーは含めない）
+	material := struct {
+		Model         string        `json:"model"`
+		PromptVersion string        `json:"prompt_version"`
+		MaxTokens     int           `json:"max_tokens"`
+		Temperature   *float64      `json:"temperature,omitempty"`
+		System        string        `json:"system"`
+		Messages      []Message     `json:"messages"`
+		Output        *OutputSchema `json:"output,omitempty"`
+	}{
+		Model:         req.Model,
+		PromptVersion: req.PromptVersion,
+		MaxTokens:     req.MaxTokens,
+		Temperature:   req.Temperature,
+		System:        req.System,
+		Messages:      req.Messages,
+		Output:        req.Output,
+	}
+
+	// json.Marshal はmapのキーをソートするため、同じ内容からは常に同じキーが得られる
+	data, _ := json.Marshal(material)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCacheStore プロセス内でLRU方式によりエントリ数を制限するキャッシュの保存先
+type MemoryCacheStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // 先頭ほど最近使用したエントリ
+}
+
+type memoryCacheItem struct {
+	key       string
+	entry     CacheEntry
+	expiresAt time.Time
+}
+
+// NewMemoryCacheStore 最大エントリ数を指定してメモリキャッシュを作成する（0以下の場合は無制限）
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (s *MemoryCacheStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	item := element.Value.(*memoryCacheItem)
+	if time.Now().After(item.expiresAt) {
+		s.remove(element)
+		return nil, nil
+	}
+
+	s.order.MoveToFront(element)
+	entry := item.entry
+	return &entry, nil
+}
+
+func (s *MemoryCacheStore) Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := &memoryCacheItem{key: key, entry: *entry, expiresAt: time.Now().Add(ttl)}
+	if element, ok := s.entries[key]; ok {
+		element.Value = item
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(item)
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// Len 保持しているエントリ数を返す（有効期限切れのエントリを含む）
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *MemoryCacheStore) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*memoryCacheItem).key)
+}
@@ -0,0 +1,278 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingCacheStore 常にエラーを返すキャッシュの保存先
+type failingCacheStore struct{}
+
+func (failingCacheStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	return nil, errors.New("キャッシュに接続できません")
+}
+
+func (failingCacheStore) Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error {
+	return errors.New("キャッシュに接続できません")
+}
+
+func testTutorRequest(prompt string) *Request {
+	return NewUserRequest(FeatureTutor, FakeModel, 100, prompt)
+}
+
+func TestCachedClientReturnsCachedResponse(t *testing.T) {
+	fake := NewFakeClient()
+	fake.Script(FeatureTutor, "1回目の応答", "2回目の応答")
+	client := NewCachedClient(fake, NewMemoryCacheStore(10), CacheOptions{TTL: time.Minute})
+	ctx := context.Background()
+
+	first, err := client.Generate(ctx, testTutorRequest("went と go の違いを教えてください"))
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	second, err := client.Generate(ctx, testTutorRequest("went と go の違いを教えてください"))
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if first.Cached || !second.Cached || second.Text != "1回目の応答" || second.Model != FakeModel ||
+		second.InputTokens != first.InputTokens || second.OutputTokens != first.OutputTokens {
+		t.Errorf("応答 = %+v, %+v; want 2回目はキャッシュから1回目の応答", first, second)
+	}
+
+	// プロンプトのバージョンが異なるリクエスト・キャッシュを使わないリクエストはLLMを呼び出す
+	versioned := testTutorRequest("went と go の違いを教えてください")
+	versioned.PromptVersion = "tutor@v2"
+	noCache := testTutorRequest("went と go の違いを教えてください")
+	noCache.NoCache = true
+	for _, req := range []*Request{versioned, noCache} {
+		res, err := client.Generate(ctx, req)
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		if res.Cached {
+			t.Errorf("キャッシュから返しています: %+v", req)
+		}
+	}
+	if calls := fake.Calls(); len(calls) != 3 {
+		t.Errorf("LLMの呼び出し回数 = %d, want 3", len(calls))
+	}
+}
+
+// 有効期限を過ぎたエントリは使わずにLLMを呼び出す
+func TestCachedClientTTL(t *testing.T) {
+	fake := NewFakeClient()
+	client := NewCachedClient(fake, NewMemoryCacheStore(10), CacheOptions{TTL: 20 * time.Millisecond})
+	req := testTutorRequest("went と go の違いを教えてください")
+
+	for _, wait := range []time.Duration{0, 0, 40 * time.Millisecond} {
+		time.Sleep(wait)
+		if _, err := client.Generate(context.Background(), req); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+	if calls := fake.Calls(); len(calls) != 2 {
+		t.Errorf("LLMの呼び出し回数 = %d, want 2（有効期限内の1回はキャッシュから返す）", len(calls))
+	}
+}
+
+// キャッシュに保存しない応答（有効期限なし・サイズ超過・呼び出しの失敗）
+func TestCachedClientSkipsUncacheableResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		options CacheOptions
+		text    string
+		err     error
+	}{
+		{name: "有効期限が0", options: CacheOptions{}, text: "応答"},
+		{name: "最大サイズを超える応答", options: CacheOptions{TTL: time.Minute, MaxEntryBytes: 8}, text: strings.Repeat("a", 9)},
+		{name: "呼び出しの失敗", options: CacheOptions{TTL: time.Minute}, err: errors.New("接続できません")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			next := clientFunc(func(ctx context.Context, req *Request) (*Response, error) {
+				calls++
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &Response{Text: tt.text}, nil
+			})
+			store := NewMemoryCacheStore(10)
+			client := NewCachedClient(next, store, tt.options)
+
+			for i := 0; i < 2; i++ {
+				if _, err := client.Generate(context.Background(), testTutorRequest("質問")); !errors.Is(err, tt.err) {
+					t.Fatalf("エラー = %v, want %v", err, tt.err)
+				}
+			}
+			if calls != 2 || store.Len() != 0 {
+				t.Errorf("呼び出し回数 = %d, キャッシュのエントリ数 = %d; want 2, 0", calls, store.Len())
+			}
+		})
+	}
+
+	// 最大サイズ以下の応答は保存する
+	store := NewMemoryCacheStore(10)
+	client := NewCachedClient(NewFakeClient(), store, CacheOptions{TTL: time.Minute, MaxEntryBytes: 4096})
+	if _, err := client.Generate(context.Background(), testTutorRequest("質問")); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if store.Len() != 1 {
+		t.Errorf("キャッシュのエントリ数 = %d, want 1", store.Len())
+	}
+}
+
+// 構造化出力は検証を通る応答のみ保存し、不正な応答は修正後の応答も含めて再利用しない
+func TestCachedClientSkipsUnvalidatedResponses(t *testing.T) {
+	valid := `{"score": 80, "comment": "よくできています", "tags": ["時制"]}`
+
+	tests := []struct {
+		name      string
+		text      string
+		validated bool
+		validate  func(text string) []string
+		want      bool // キャッシュに保存するか
+	}{
+		{name: "スキーマを満たす応答", text: valid, want: true},
+		{name: "JSONがない応答", text: "採点結果はありません"},
+		{name: "スキーマを満たさない応答", text: `{"score": 150, "comment": "よくできています", "tags": []}`},
+		{
+			name:     "値の制約を満たさない応答",
+			text:     valid,
+			validate: func(text string) []string { return []string{"合計点が一致しません"} },
+		},
+		{
+			name:     "値の制約を満たす応答",
+			text:     valid,
+			validate: func(text string) []string { return nil },
+			want:     true,
+		},
+		{
+			name:      "検証済みの応答",
+			text:      valid,
+			validated: true,
+			validate:  func(text string) []string { t.Error("検証済みの応答を再度検証しています"); return nil },
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := clientFunc(func(ctx context.Context, req *Request) (*Response, error) {
+				return &Response{Text: tt.text, Validated: tt.validated}, nil
+			})
+			store := NewMemoryCacheStore(10)
+			client := NewCachedClient(next, store, CacheOptions{TTL: time.Minute})
+			req := testStructuredRequest()
+			req.Validate = tt.validate
+
+			if _, err := client.Generate(context.Background(), req); err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if got := store.Len() == 1; got != tt.want {
+				t.Errorf("キャッシュに保存したか = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// キャッシュの障害ではLLM呼び出しを失敗させない
+func TestCachedClientIgnoresStoreErrors(t *testing.T) {
+	fake := NewFakeClient()
+	client := NewCachedClient(fake, failingCacheStore{}, CacheOptions{TTL: time.Minute})
+
+	res, err := client.Generate(context.Background(), testTutorRequest("質問"))
+	if err != nil || res.Cached {
+		t.Errorf("応答 = %+v, エラー = %v", res, err)
+	}
+}
+
+// キャッシュキーは応答に影響する項目のみから作成する
+func TestCacheKey(t *testing.T) {
+	base := testTutorRequest("質問")
+	key := CacheKey(base)
+
+	sameKey := *base
+	sameKey.UserID = "user-1"
+	sameKey.SubjectID = "result-1"
+	sameKey.NoCache = true
+	if CacheKey(&sameKey) != key {
+		t.Error("ユーザー・関連するID・NoCacheでキャッシュキーが変わります")
+	}
+
+	temperature := 0.2
+	for name, modify := range map[string]func(req *Request){
+		"モデル":         func(req *Request) { req.Model = "other-model" },
+		"プロンプトのバージョン": func(req *Request) { req.PromptVersion = "tutor@v2" },
+		"最大出力トークン数":   func(req *Request) { req.MaxTokens = 200 },
+		"温度":          func(req *Request) { req.Temperature = &temperature },
+		"システムプロンプト":   func(req *Request) { req.System = "あなたは英語の先生です" },
+		"会話履歴":        func(req *Request) { req.Messages = []Message{{Role: RoleUser, Content: "別の質問"}} },
+		"構造化出力":       func(req *Request) { req.Output = testOutputSchema() },
+	} {
+		req := *base
+		modify(&req)
+		if CacheKey(&req) == key {
+			t.Errorf("%sが異なるリクエストのキャッシュキーが同じです", name)
+		}
+	}
+}
+
+func TestMemoryCacheStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCacheStore(2)
+
+	store.Set(ctx, "a", &CacheEntry{Text: "a"}, time.Minute)
+	store.Set(ctx, "b", &CacheEntry{Text: "b"}, time.Minute)
+	// aを使用したため、最も使われていないbを削除する
+	if entry, _ := store.Get(ctx, "a"); entry == nil || entry.Text != "a" {
+		t.Fatalf("Get(a) = %+v", entry)
+	}
+	store.Set(ctx, "c", &CacheEntry{Text: "c"}, time.Minute)
+
+	if store.Len() != 2 {
+		t.Errorf("エントリ数 = %d, want 2", store.Len())
+	}
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if entry, _ := store.Get(ctx, key); (entry != nil) != want {
+			t.Errorf("Get(%s) = %+v, want 保持しているか %v", key, entry, want)
+		}
+	}
+
+	// 既存のキーは上書きし、エントリ数は増やさない
+	store.Set(ctx, "a", &CacheEntry{Text: "a2"}, time.Minute)
+	if entry, _ := store.Get(ctx, "a"); store.Len() != 2 || entry == nil || entry.Text != "a2" {
+		t.Errorf("上書き後のエントリ = %+v, エントリ数 = %d", entry, store.Len())
+	}
+}
+
+func TestMemoryCacheStoreExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCacheStore(0)
+
+	store.Set(ctx, "short", &CacheEntry{Text: "short"}, 10*time.Millisecond)
+	store.Set(ctx, "long", &CacheEntry{Text: "long"}, time.Minute)
+	time.Sleep(20 * time.Millisecond)
+
+	if entry, _ := store.Get(ctx, "short"); entry != nil {
+		t.Errorf("有効期限切れのエントリを返しています: %+v", entry)
+	}
+	if entry, _ := store.Get(ctx, "long"); entry == nil {
+		t.Error("有効期限内のエントリを返していません")
+	}
+	// 有効期限切れのエントリは取得時に削除する
+	if store.Len() != 1 {
+		t.Errorf("エントリ数 = %d, want 1", store.Len())
+	}
+
+	// 返したエントリを変更しても保持しているエントリは変わらない
+	entry, _ := store.Get(ctx, "long")
+	entry.Text = "changed"
+	if entry, _ := store.Get(ctx, "long"); entry.Text != "long" {
+		t.Errorf("保持しているエントリ = %+v", entry)
+	}
+}
@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// DefaultClaudeModel モデル未指定時に使用するClaudeのモデル
+const DefaultClaudeModel = string(anthropic.ModelClaude3_7Sonnet20250219)
+
+// claudeClient Anthropic Messages APIを利用するLLMClient実装
+type claudeClient struct {
+	client anthropic.Client
+}
+
+// NewClaudeClient APIキーを指定してClaudeクライアントを作成する
+// baseURLを指定した場合はそのエンドポイントに接続する（ローカルのモックサーバーなど）
+// 再試行はResilientClientで行うため、SDKの自動再試行は無効にする
+func NewClaudeClient(apiKey string, baseURL string) LLMClient {
+	opts := []option.RequestOption{
+		option.WithAPIKey(apiKey),
+		option.WithMaxRetries(0),
+	}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	return &claudeClient{
+		client: anthropic.NewClient(opts...),
+	}
+}
+
+func (c *claudeClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	model := req.Model
+	if model == "" {
+		model = DefaultClaudeModel
+	}
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: int64(req.MaxTokens),
+		Messages:  toClaudeMessages(req.Messages),
+	}
+	if req.System != "" {
+		params.System = []anthropic.TextBlockParam{{Text: req.System}}
+	}
+	if req.Temperature != nil {
+		params.Temperature = anthropic.Float(*req.Temperature)
+	}
+	if req.Output != nil {
+		// 構造化出力はツール呼び出しを強制し、ツールの入力としてJSONを受け取る
+		tool := toClaudeTool(req.Output)
+		params.Tools = []anthropic.ToolUnionParam{{OfTool: &tool}}
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(req.Output.Name)
+	}
+
+	var msg *anthropic.Message
+	if req.Stream != nil {
+		streamed, err := c.generateStreaming(ctx, params, req.Stream)
+		if err != nil {
+			return nil, err
+		}
+		msg = streamed
+	} else {
+		created, err := c.client.Messages.New(ctx, params)
+		if err != nil {
+			return nil, toClaudeError(ctx, err)
+		}
+		msg = created
+	}
+
+	// Contentのテキストブロックを結合（ツール呼び出しがあればその入力JSONを出力とする）
+	var output, toolOutput string
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "text":
+			output += block.Text
+		case "tool_use":
+			if req.Output != nil && block.Name == req.Output.Name {
+				toolOutput = string(block.Input)
+			}
+		}
+	}
+	if toolOutput != "" {
+		output = toolOutput
+	}
+
+	return &Response{
+		Text:         output,
+		Model:        string(msg.Model),
+		InputTokens:  int(msg.Usage.InputTokens),
+		OutputTokens: int(msg.Usage.OutputTokens),
+	}, nil
+}
+
+// generateStreaming ストリーミングでメッセージを生成し、テキストとツール入力のJSONの断片を通知する
+func (c *claudeClient) generateStreaming(ctx context.Context, params anthropic.MessageNewParams, observer StreamObserver) (*anthropic.Message, error) {
+	stream := c.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	observer.Begin()
+	msg := &anthropic.Message{}
+	for stream.Next() {
+		event := stream.Current()
+		if err := msg.Accumulate(event); err != nil {
+			return nil, &APIError{Provider: "Claude", Err: err}
+		}
+
+		delta, ok := event.AsAny().(anthropic.ContentBlockDeltaEvent)
+		if !ok {
+			continue
+		}
+		switch d := delta.Delta.AsAny().(type) {
+		case anthropic.TextDelta:
+			observer.Delta(d.Text)
+		case anthropic.InputJSONDelta:
+			observer.Delta(d.PartialJSON)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, toClaudeError(ctx, err)
+	}
+	return msg, nil
+}
+
+// toClaudeMessages 共通メッセージをAnthropic SDKのメッセージに変換する
+func toClaudeMessages(messages []Message) []anthropic.MessageParam {
+	params := make([]anthropic.MessageParam, 0, len(messages))
+	for _, m := range messages {
+		switch m.Role {
+		case RoleAssistant:
+			params = append(params, anthropic.NewAssistantMessage(anthropic.NewTextBlock(m.Content)))
+		default:
+			params = append(params, anthropic.NewUserMessage(anthropic.NewTextBlock(m.Content)))
+		}
+	}
+	return params
+}
+
+// toClaudeTool 構造化出力のスキーマをツール定義に変換する
+func toClaudeTool(output *OutputSchema) anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name:        output.Name,
+		Description: anthropic.String(output.Description),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: output.Schema.Properties,
+			Required:   output.Schema.Required,
+		},
+	}
+}
+
+// toClaudeError SDKのエラーをAPIErrorに変換する（コンテキストの終了はそのまま返す）
+func toClaudeError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		var retryAfter time.Duration
+		if apiErr.Response != nil {
+			retryAfter = parseRetryAfter(apiErr.Response.Header)
+		}
+		return &APIError{Provider: "Claude", StatusCode: apiErr.StatusCode, RetryAfter: retryAfter, Err: err}
+	}
+	return &APIError{Provider: "Claude", Err: err}
+}
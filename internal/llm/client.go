@@ -0,0 +1,83 @@
+package llm
+
+import "context"
+
+// LLM呼び出しの用途（機能）を表す識別子
+const (
+	FeatureGrading            = "grading"
+	FeatureCategoryAnalysis   = "category_analysis"
+	FeatureDetailedAnalysis   = "detailed_analysis"
+	FeatureLearningAdvice     = "learning_advice"
+	FeatureTutor              = "tutor"
+	FeatureQuestionGeneration = "question_generation"
+)
+
+// Role メッセージの発話者
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message 会話中の1メッセージ
+type Message struct {
+	Role    Role
+	Content string
+}
+
+// Request プロバイダに依存しないLLM呼び出しリクエスト
+type Request struct {
+	Feature       string         // 呼び出し元の機能（FeatureGrading など）
+	UserID        string         // 呼び出し元のユーザー（利用量の記録・上限の確認に使用する）
+	SubjectID     string         // 呼び出しに関連する添削結果・弱点分析のID（呼び出しの記録に使用する）
+	PromptVersion string         // 使用したプロンプトのバージョン（キャッシュキーに含める）
+	Model         string         // 使用するモデル名
+	MaxTokens     int            // 最大出力トークン数
+	Temperature   *float64       // 未指定の場合はプロバイダのデフォルト
+	System        string         // システムプロンプト
+	Messages      []Message      // 会話履歴（最後はユーザーメッセージ）
+	Output        *OutputSchema  // 構造化出力のスキーマ（指定時はプロバイダの機能で出力形式を強制する）
+	Stream        StreamObserver // 指定時はストリーミングに対応したプロバイダが生成中の出力を通知する
+	NoCache       bool           // キャッシュを使わずにLLMを呼び出す（再採点など、同じプロンプトで新しい応答が必要な場合）
+
+	// 構造化出力の検証（スキーマと値の制約の違反内容を返す）。GenerateStructuredが設定し、
+	// FallbackClientはプロバイダごとにこの検証で修正依頼とフォールバックを行う
+	Validate func(text string) []string
+}
+
+// Response LLMの応答
+type Response struct {
+	Text         string // 出力テキスト
+	Model        string // 実際に使用されたモデル名
+	Provider     string // 応答したプロバイダ名（フォールバックチェーンを使用した場合のみ）
+	InputTokens  int    // 入力トークン数
+	OutputTokens int    // 出力トークン数
+	Cached       bool   // キャッシュから返した応答かどうか
+	Validated    bool   // Request.Validateによる検証を通った応答かどうか（FallbackClientが検証した場合のみ）
+}
+
+// StreamObserver ストリーミングで生成中の出力を受け取る
+// 再試行・フォールバック・修正依頼で同じリクエストを再度生成する場合は、生成のたびにBeginが呼ばれる
+// ストリーミングに対応していないプロバイダ・キャッシュから返した応答では呼ばれない
+type StreamObserver interface {
+	Begin()            // 生成の開始（それまでに受け取った出力は破棄する）
+	Delta(text string) // 出力の断片（構造化出力の場合はJSONの断片）
+}
+
+// LLMClient LLMプロバイダの共通インターフェース
+type LLMClient interface {
+	Generate(ctx context.Context, req *Request) (*Response, error)
+}
+
+// NewUserRequest 単一のユーザープロンプトからリクエストを作成する
+func NewUserRequest(feature, model string, maxTokens int, prompt string) *Request {
+	return &Request{
+		Feature:   feature,
+		Model:     model,
+		MaxTokens: maxTokens,
+		Messages: []Message{
+			{Role: RoleUser, Content: prompt},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrCircuitOpen サーキットブレーカーが開いているため呼び出しを行わなかった
+var ErrCircuitOpen = errors.New("LLMプロバイダが一時的に利用できません（サーキットブレーカー作動中）")
+
+// ErrUnavailable LLMを利用できない（利用可能なプロバイダが設定されていない）
+var ErrUnavailable = errors.New("LLMを利用する機能は現在利用できません")
+
+// UnavailableError LLMを利用できない理由
+type UnavailableError struct {
+	Reason string
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrUnavailable, e.Reason)
+}
+
+// Is errors.Is(err, ErrUnavailable) で判定できるようにする
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// APIError LLMプロバイダのAPI呼び出しの失敗
+type APIError struct {
+	Provider   string        // プロバイダ名
+	StatusCode int           // HTTPステータスコード（通信エラーの場合は0）
+	RetryAfter time.Duration // Retry-Afterヘッダで指定された待機時間（指定がない場合は0）
+	Err        error         // 元のエラー
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s APIとの通信に失敗しました: %v", e.Provider, e.Err)
+	}
+	return fmt.Sprintf("%s APIがエラーを返しました（ステータス: %d）: %v", e.Provider, e.StatusCode, e.Err)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable 再試行で回復する可能性のあるエラーかどうか（通信エラー・429・5xx）
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == 0 ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter Retry-Afterヘッダ（秒数）を待機時間に変換する
+func parseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeModel フェイククライアントが応答に設定するモデル名
+const FakeModel = "fake-model"
+
+// fakeStreamChunkRunes ストリーミング時に1回で通知する文字数
+const fakeStreamChunkRunes = 8
+
+// fakeDefaultResponses 機能ごとのデフォルト応答（ネットワークやAPIキーなしで各フローを動かすためのもの）
+var fakeDefaultResponses = map[string]string{
+	FeatureGrading: `{
+  "rubric": {
+    "grammar": {"score": 70, "rationale": "時制の誤りが1か所あります。"},
+    "vocabulary": {"score": 80, "rationale": "適切な語を選べています。"},
+    "task_fulfilment": {"score": 90, "rationale": "問題の内容を過不足なく伝えています。"},
+    "naturalness": {"score": 70, "rationale": "やや直訳的な表現があります。"},
+    "spelling": {"score": 100, "rationale": "綴りの誤りはありません。"}
+  },
+  "errors": [],
+  "example_correction": "I went to see a movie with my friends yesterday.",
+  "advice": "全体的によく書けています。時制と冠詞の使い方を見直しましょう。"
+}`,
+	FeatureCategoryAnalysis: `{
+  "is_weakness": false,
+  "is_strength": true,
+  "issues": ["冠詞の使い分けに迷いが見られます"],
+  "strengths": ["基本的な語順は正しく使えています"],
+  "examples": ["I went to the park."]
+}`,
+	FeatureDetailedAnalysis: `{
+  "grammar": {"score": 70, "description": "基本的な文法は身についています。", "examples": ["I have been studying English for three years."]},
+  "vocabulary": {"score": 65, "description": "日常的な語彙は十分です。", "examples": ["favorite", "vacation"]},
+  "expression": {"score": 60, "description": "表現の幅を広げましょう。", "examples": ["I would love to travel around the world."]},
+  "structure": {"score": 75, "description": "文章の構成は明確です。", "examples": ["First, ... Second, ..."]}
+}`,
+	FeatureLearningAdvice: `{
+  "learning_advice": "時制の一致を意識して短い英作文を毎日書きましょう。",
+  "recommended_actions": ["毎日1題の英作文", "時制の復習", "音読練習"],
+  "next_goals": ["冠詞のミスを減らす", "接続詞を使った長文を書く", "中級レベルの問題に挑戦する"],
+  "study_plan": "1週目は文法の復習、2週目は語彙の拡充、3週目以降は英作文の実践を行いましょう。",
+  "motivational_message": "着実に上達しています。この調子で続けましょう！"
+}`,
+	FeatureQuestionGeneration: `{
+  "questions": [
+    {"english": "Translate the following Japanese sentence to English: 私は毎朝コーヒーを飲みます。", "japanese": "次の日本語の文を英語に翻訳してください：私は毎朝コーヒーを飲みます。", "reference_answer": "I drink coffee every morning.", "register": "casual", "variety": "US", "points": 8, "estimated_time": 10},
+    {"english": "Translate the following Japanese sentence to English: 駅までの道を教えていただけますか。", "japanese": "次の日本語の文を英語に翻訳してください：駅までの道を教えていただけますか。", "reference_answer": "Could you tell me the way to the station?", "register": "formal", "variety": "US", "points": 8, "estimated_time": 10},
+    {"english": "Translate the following Japanese sentence to English: 週末は家族と過ごしました。", "japanese": "次の日本語の文を英語に翻訳してください：週末は家族と過ごしました。", "reference_answer": "I spent the weekend with my family.", "register": "casual", "variety": "US", "points": 8, "estimated_time": 10}
+  ]
+}`,
+	FeatureTutor: "ここでは「映画を見に行った」という過去の出来事なので、go ではなく went を使います。yesterday のような過去を表す語があるときは動詞を過去形にしましょう。",
+}
+
+// FakeClient 台本（スクリプト）どおりのJSONを返す決定的なLLMClient実装
+// ローカル開発やテストでネットワーク・APIキーなしに採点・分析フローを実行するために使用する
+type FakeClient struct {
+	mu       sync.Mutex
+	scripts  map[string][]string
+	defaults map[string]string
+	calls    []Request
+}
+
+// NewFakeClient 各機能のデフォルト応答を持つフェイククライアントを作成する
+func NewFakeClient() *FakeClient {
+	defaults := make(map[string]string, len(fakeDefaultResponses))
+	for feature, response := range fakeDefaultResponses {
+		defaults[feature] = response
+	}
+	return &FakeClient{
+		scripts:  make(map[string][]string),
+		defaults: defaults,
+	}
+}
+
+// Script 指定した機能に対して、呼び出し順に返す応答を追加する
+// 台本を使い切った後はデフォルト応答を返す
+func (f *FakeClient) Script(feature string, responses ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scripts[feature] = append(f.scripts[feature], responses...)
+}
+
+// SetDefault 指定した機能のデフォルト応答を差し替える
+func (f *FakeClient) SetDefault(feature string, response string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.defaults[feature] = response
+}
+
+// Calls これまでに受け付けたリクエストを返す
+func (f *FakeClient) Calls() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := make([]Request, len(f.calls))
+	copy(calls, f.calls)
+	return calls
+}
+
+func (f *FakeClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, *req)
+
+	var text string
+	if queue := f.scripts[req.Feature]; len(queue) > 0 {
+		text = queue[0]
+		f.scripts[req.Feature] = queue[1:]
+	} else if response, ok := f.defaults[req.Feature]; ok {
+		text = response
+	} else {
+		return nil, fmt.Errorf("フェイククライアントに機能 %q の応答が登録されていません", req.Feature)
+	}
+
+	inputTokens := 0
+	for _, m := range req.Messages {
+		inputTokens += len(m.Content) / 4
+	}
+
+	// ストリーミングの場合は応答を一定の文字数ずつ通知する
+	if req.Stream != nil {
+		req.Stream.Begin()
+		runes := []rune(text)
+		for i := 0; i < len(runes); i += fakeStreamChunkRunes {
+			req.Stream.Delta(string(runes[i:min(i+fakeStreamChunkRunes, len(runes))]))
+		}
+	}
+
+	return &Response{
+		Text:         text,
+		Model:        FakeModel,
+		InputTokens:  inputTokens,
+		OutputTokens: len(text) / 4,
+	}, nil
+}
@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Provider フォールバックチェーンを構成する名前付きのLLMClient
+type Provider struct {
+	Name   string
+	Client LLMClient
+}
+
+// ProviderStats プロバイダごとの呼び出し結果の集計
+type ProviderStats struct {
+	Name               string     `json:"name"`
+	Requests           int64      `json:"requests"`            // 呼び出し回数
+	Successes          int64      `json:"successes"`           // 応答を返した回数
+	Failures           int64      `json:"failures"`            // エラーで失敗した回数
+	ValidationFailures int64      `json:"validation_failures"` // 出力が修正後も検証を通らなかった回数
+	LastError          string     `json:"last_error,omitempty"`
+	LastFailureAt      *time.Time `json:"last_failure_at,omitempty"`
+
+	// 機能グループごとのサーキットブレーカーの状態（プロバイダがResilientClientの場合のみ）
+	Breakers map[string]string `json:"breakers,omitempty"`
+}
+
+// breakerReporter サーキットブレーカーの状態を返すLLMClient（ResilientClient）
+type breakerReporter interface {
+	BreakerState(group string) string
+}
+
+// wrapper 別のLLMClientをラップするLLMClient（AuditedClient）
+type wrapper interface {
+	Unwrap() LLMClient
+}
+
+// findBreakerReporter ラップを外してサーキットブレーカーの状態を返すクライアントを探す
+func findBreakerReporter(client LLMClient) (breakerReporter, bool) {
+	for {
+		if reporter, ok := client.(breakerReporter); ok {
+			return reporter, true
+		}
+		w, ok := client.(wrapper)
+		if !ok {
+			return nil, false
+		}
+		client = w.Unwrap()
+	}
+}
+
+// ErrAllProvidersFailed フォールバックチェーンのすべてのプロバイダが失敗した
+var ErrAllProvidersFailed = errors.New("すべてのLLMプロバイダで呼び出しに失敗しました")
+
+// FallbackClient 設定順にプロバイダを呼び出し、障害で失敗した場合は次のプロバイダに同じリクエストを送るLLMClient
+// 構造化出力の場合は各プロバイダで呼び出し元の検証（Request.Validate）と修正依頼を行い、修正後も検証を通らない出力も失敗として扱う
+type FallbackClient struct {
+	providers []Provider
+
+	mu    sync.Mutex
+	stats map[string]*ProviderStats
+}
+
+// NewFallbackClient 優先順にプロバイダを指定してフォールバックチェーンを作成する
+func NewFallbackClient(providers []Provider) *FallbackClient {
+	c := &FallbackClient{
+		providers: providers,
+		stats:     make(map[string]*ProviderStats, len(providers)),
+	}
+	for _, p := range providers {
+		c.stats[p.Name] = &ProviderStats{Name: p.Name}
+	}
+	return c
+}
+
+func (c *FallbackClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if !c.Available() {
+		return nil, &UnavailableError{Reason: "利用可能なLLMプロバイダが設定されていません"}
+	}
+
+	var (
+		errs                      []error
+		inputTokens, outputTokens int
+	)
+	for i, p := range c.providers {
+		if i > 0 {
+			log.Printf("LLMプロバイダ %s にフォールバックします（機能: %s）: %v", p.Name, req.Feature, errs[len(errs)-1])
+		}
+
+		res, err := c.generate(ctx, p, req)
+		if res != nil {
+			// 検証に失敗した出力の生成にもトークンを消費しているため合算する
+			inputTokens += res.InputTokens
+			outputTokens += res.OutputTokens
+		}
+		if err == nil {
+			c.recordSuccess(p.Name)
+			res.Provider = p.Name
+			res.InputTokens = inputTokens
+			res.OutputTokens = outputTokens
+			return res, nil
+		}
+
+		// 呼び出し元のコンテキストが終了した場合はフォールバックしない
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		c.recordFailure(p.Name, err)
+		// リクエスト自体の誤り（4xxなど）は次のプロバイダでも失敗するためフォールバックしない
+		if !shouldFallBack(err) {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+
+	return nil, fmt.Errorf("%w（機能: %s）: %w", ErrAllProvidersFailed, req.Feature, errors.Join(errs...))
+}
+
+// shouldFallBack 次のプロバイダで回復する可能性のあるエラーかどうか
+// （再試行しても回復しなかった障害・サーキットブレーカーの作動・修正後も検証を通らない出力）
+func shouldFallBack(err error) bool {
+	return isRetryable(err) || errors.Is(err, ErrCircuitOpen) || IsValidationError(err)
+}
+
+// Available 利用可能なプロバイダが1つ以上あるかどうか
+func (c *FallbackClient) Available() bool {
+	return len(c.providers) > 0
+}
+
+// generate 1つのプロバイダを呼び出す
+// 構造化出力の場合は呼び出し元の検証（スキーマと値の制約）を行い、不正な出力はプロバイダ内で修正を依頼する
+// 修正後も検証を通らない場合は、消費したトークン数を持つ応答とValidationErrorを返す
+func (c *FallbackClient) generate(ctx context.Context, p Provider, req *Request) (*Response, error) {
+	if req.Validate == nil {
+		return p.Client.Generate(ctx, req)
+	}
+
+	// プロバイダごとに呼び出し元の会話履歴から修正依頼を始める
+	providerReq := *req
+	providerReq.Messages = append([]Message(nil), req.Messages...)
+	counter := &tokenCounter{next: p.Client}
+	res, err := generateValidated(ctx, counter, &providerReq)
+	if err != nil {
+		return counter.total(), err
+	}
+	total := counter.total()
+	res.InputTokens = total.InputTokens
+	res.OutputTokens = total.OutputTokens
+	res.Validated = true
+	return res, nil
+}
+
+// Stats プロバイダごとの呼び出し結果の集計を設定順に返す
+func (c *FallbackClient) Stats() []ProviderStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]ProviderStats, 0, len(c.providers))
+	for _, p := range c.providers {
+		s := *c.stats[p.Name]
+		if reporter, ok := findBreakerReporter(p.Client); ok {
+			s.Breakers = map[string]string{
+				GroupGrading:  reporter.BreakerState(GroupGrading),
+				GroupAnalysis: reporter.BreakerState(GroupAnalysis),
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// recordSuccess 応答を返した呼び出しを記録する
+func (c *FallbackClient) recordSuccess(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats[name]
+	stats.Requests++
+	stats.Successes++
+}
+
+// recordFailure 失敗した呼び出しを記録する（検証エラーとそれ以外のエラーを分けて数える）
+func (c *FallbackClient) recordFailure(name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	stats := c.stats[name]
+	stats.Requests++
+	if IsValidationError(err) {
+		stats.ValidationFailures++
+	} else {
+		stats.Failures++
+	}
+	stats.LastError = err.Error()
+	stats.LastFailureAt = &now
+}
+
+// tokenCounter 修正依頼を含む複数回の呼び出しで消費したトークン数を合算するLLMClient
+type tokenCounter struct {
+	next LLMClient
+
+	inputTokens  int
+	outputTokens int
+}
+
+func (t *tokenCounter) Generate(ctx context.Context, req *Request) (*Response, error) {
+	res, err := t.next.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	t.inputTokens += res.InputTokens
+	t.outputTokens += res.OutputTokens
+	return res, nil
+}
+
+// total 合算したトークン数を持つ応答を返す
+func (t *tokenCounter) total() *Response {
+	return &Response{InputTokens: t.inputTokens, OutputTokens: t.outputTokens}
+}
@@ -0,0 +1,182 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// failingClient 常に指定したエラーを返し、呼び出し回数を数えるLLMClient
+type failingClient struct {
+	err   error
+	calls int
+}
+
+func (c *failingClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	c.calls++
+	return nil, c.err
+}
+
+func TestFallbackClientFallsThrough(t *testing.T) {
+	retryable := &APIError{Provider: "primary", StatusCode: http.StatusServiceUnavailable, Err: errors.New("overloaded")}
+
+	tests := []struct {
+		name         string
+		err          error
+		wantFallback bool
+	}{
+		{name: "再試行の対象となるエラー", err: fmt.Errorf("LLM呼び出しが3回失敗しました: %w", retryable), wantFallback: true},
+		{name: "通信エラー", err: &APIError{Provider: "primary", Err: errors.New("connection refused")}, wantFallback: true},
+		{name: "タイムアウト", err: fmt.Errorf("LLM呼び出しがタイムアウトしました: %w", context.DeadlineExceeded), wantFallback: true},
+		{name: "サーキットブレーカーの作動", err: fmt.Errorf("%w（機能グループ: grading）", ErrCircuitOpen), wantFallback: true},
+		{name: "リクエストの誤り", err: &APIError{Provider: "primary", StatusCode: http.StatusBadRequest, Err: errors.New("invalid request")}},
+		{name: "応答を解釈できない", err: errors.New("応答にテキストがありません")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &failingClient{err: tt.err}
+			secondary := NewFakeClient()
+			client := NewFallbackClient([]Provider{{Name: "primary", Client: primary}, {Name: "secondary", Client: secondary}})
+
+			res, err := client.Generate(context.Background(), NewUserRequest(FeatureTutor, FakeModel, 100, "質問"))
+			if tt.wantFallback {
+				if err != nil || res.Provider != "secondary" {
+					t.Fatalf("Generate = %+v, %v; want secondary の応答", res, err)
+				}
+			} else {
+				if !errors.Is(err, tt.err) || errors.Is(err, ErrAllProvidersFailed) {
+					t.Fatalf("エラー = %v, want %v", err, tt.err)
+				}
+				if len(secondary.Calls()) != 0 {
+					t.Errorf("リクエストの誤りで次のプロバイダを呼び出しています")
+				}
+			}
+
+			stats := client.Stats()
+			if primary.calls != 1 || stats[0].Requests != 1 || stats[0].Failures != 1 || stats[0].LastError != tt.err.Error() || stats[0].LastFailureAt == nil {
+				t.Errorf("primary の呼び出し回数 = %d, 集計 = %+v", primary.calls, stats[0])
+			}
+			if wantSuccesses := map[bool]int64{true: 1, false: 0}[tt.wantFallback]; stats[1].Successes != wantSuccesses {
+				t.Errorf("secondary の集計 = %+v, want 成功 %d回", stats[1], wantSuccesses)
+			}
+		})
+	}
+}
+
+func TestFallbackClientAllProvidersFailed(t *testing.T) {
+	primaryErr := fmt.Errorf("%w（機能グループ: analysis）", ErrCircuitOpen)
+	secondaryErr := &APIError{Provider: "secondary", StatusCode: http.StatusTooManyRequests, Err: errors.New("rate limited")}
+	client := NewFallbackClient([]Provider{
+		{Name: "primary", Client: &failingClient{err: primaryErr}},
+		{Name: "secondary", Client: &failingClient{err: secondaryErr}},
+	})
+
+	_, err := client.Generate(context.Background(), NewUserRequest(FeatureDetailedAnalysis, FakeModel, 100, "分析してください"))
+	if !errors.Is(err, ErrAllProvidersFailed) || !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, secondaryErr) {
+		t.Errorf("エラー = %v, want すべてのプロバイダのエラーを含む ErrAllProvidersFailed", err)
+	}
+}
+
+// 呼び出し元のコンテキストが終了した場合はフォールバックせず、失敗として数えない
+func TestFallbackClientStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	primary := clientFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		cancel()
+		return nil, &APIError{Provider: "primary", Err: context.Canceled}
+	})
+	secondary := NewFakeClient()
+	client := NewFallbackClient([]Provider{{Name: "primary", Client: primary}, {Name: "secondary", Client: secondary}})
+
+	if _, err := client.Generate(ctx, NewUserRequest(FeatureTutor, FakeModel, 100, "質問")); !errors.Is(err, context.Canceled) {
+		t.Errorf("エラー = %v, want context.Canceled", err)
+	}
+	if len(secondary.Calls()) != 0 || client.Stats()[0].Failures != 0 {
+		t.Errorf("中断後に次のプロバイダを呼び出したか失敗として数えています: %+v", client.Stats())
+	}
+}
+
+func TestFallbackClientWithoutProviders(t *testing.T) {
+	client := NewFallbackClient(nil)
+	if client.Available() {
+		t.Error("プロバイダがないのに利用可能です")
+	}
+	if _, err := client.Generate(context.Background(), NewUserRequest(FeatureTutor, FakeModel, 100, "質問")); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("エラー = %v, want ErrUnavailable", err)
+	}
+}
+
+// 構造化出力はプロバイダごとに呼び出し元の会話履歴から修正を依頼し、修正後も検証を通らない場合は次のプロバイダで再実行する
+func TestFallbackClientRepairsPerProvider(t *testing.T) {
+	invalid := `{"score": 150, "comment": "よくできています", "tags": []}`
+	valid := `{"score": 80, "comment": "よくできています", "tags": []}`
+	primary := NewFakeClient()
+	primary.SetDefault(testFeature, invalid)
+	secondary := NewFakeClient()
+	secondary.Script(testFeature, "採点結果はありません", valid)
+	client := NewFallbackClient([]Provider{{Name: "primary", Client: primary}, {Name: "secondary", Client: secondary}})
+
+	var out testOutput
+	res, err := GenerateStructured(context.Background(), client, testStructuredRequest(), &out, nil)
+	if err != nil {
+		t.Fatalf("構造化出力の生成に失敗しました: %v", err)
+	}
+	if res.Provider != "secondary" || !res.Validated || out.Score != 80 {
+		t.Errorf("応答 = %+v, 出力 = %+v; want secondary の検証済みの応答", res, out)
+	}
+
+	primaryCalls, secondaryCalls := primary.Calls(), secondary.Calls()
+	if len(primaryCalls) != DefaultMaxRepairs+1 || len(secondaryCalls) != 2 {
+		t.Fatalf("呼び出し回数 = primary %d, secondary %d; want %d, 2", len(primaryCalls), len(secondaryCalls), DefaultMaxRepairs+1)
+	}
+	// 次のプロバイダには前のプロバイダへの修正依頼を含めない
+	if len(secondaryCalls[0].Messages) != 1 || len(secondaryCalls[1].Messages) != 3 {
+		t.Errorf("secondary の会話履歴の数 = %d, %d; want 1, 3", len(secondaryCalls[0].Messages), len(secondaryCalls[1].Messages))
+	}
+
+	// 検証に失敗した出力の生成で消費したトークン数も合算する
+	wantInput, wantOutput := 0, 0
+	for _, calls := range [][]Request{primaryCalls, secondaryCalls} {
+		for _, call := range calls {
+			for _, m := range call.Messages {
+				wantInput += len(m.Content) / 4
+			}
+		}
+	}
+	for i := 0; i < DefaultMaxRepairs+1; i++ {
+		wantOutput += len(invalid) / 4
+	}
+	wantOutput += len("採点結果はありません")/4 + len(valid)/4
+	if res.InputTokens != wantInput || res.OutputTokens != wantOutput {
+		t.Errorf("トークン数 = %d, %d; want %d, %d", res.InputTokens, res.OutputTokens, wantInput, wantOutput)
+	}
+
+	stats := client.Stats()
+	if stats[0].ValidationFailures != 1 || stats[0].Failures != 0 || stats[1].Successes != 1 {
+		t.Errorf("集計 = %+v, want primary の検証失敗1回・secondary の成功1回", stats)
+	}
+}
+
+// サーキットブレーカーの状態はラップしたクライアントの内側のResilientClientから取得する
+func TestFallbackClientStatsReportsBreakers(t *testing.T) {
+	policies := map[string]Policy{GroupGrading: {BreakerThreshold: 1, BreakerCooldown: time.Minute}}
+	resilient := NewResilientClient(&failingClient{err: &APIError{Provider: "primary", StatusCode: http.StatusInternalServerError, Err: errors.New("error")}}, policies)
+	client := NewFallbackClient([]Provider{
+		{Name: "primary", Client: NewAuditedClient(resilient, &memoryCallStore{}, "primary")},
+		{Name: "secondary", Client: NewFakeClient()},
+	})
+
+	if _, err := client.Generate(context.Background(), NewUserRequest(FeatureGrading, FakeModel, 100, "採点してください")); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	stats := client.Stats()
+	if stats[0].Breakers[GroupGrading] != breakerOpen || stats[0].Breakers[GroupAnalysis] != breakerClosed {
+		t.Errorf("primary のサーキットブレーカーの状態 = %v", stats[0].Breakers)
+	}
+	if stats[1].Breakers != nil {
+		t.Errorf("secondary のサーキットブレーカーの状態 = %v, want なし", stats[1].Breakers)
+	}
+}
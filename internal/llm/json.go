@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNoJSONObject 出力テキストにJSONオブジェクトが含まれていない
+var ErrNoJSONObject = errors.New("LLMの出力にJSONオブジェクトが見つかりません")
+
+// ExtractJSONObject LLMの出力テキストから最初の完全なJSONオブジェクトを抽出する
+// コードブロック( ```json ... ``` )や前後の説明文が含まれていても取り除いて抽出する
+// 文字列リテラル内の括弧は無視する
+func ExtractJSONObject(input string) (string, error) {
+	trimmed := strings.TrimSpace(input)
+
+	inString := false
+	escape := false
+	depth := 0
+	start := -1
+	for i, r := range trimmed {
+		if inString {
+			if escape {
+				escape = false
+				continue
+			}
+			if r == '\\' {
+				escape = true
+				continue
+			}
+			if r == '"' {
+				inString = false
+			}
+			continue
+		}
+		if r == '"' && depth > 0 {
+			inString = true
+			continue
+		}
+		switch r {
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+				if depth == 0 {
+					return trimmed[start : i+1], nil
+				}
+			}
+		}
+	}
+
+	return "", ErrNoJSONObject
+}
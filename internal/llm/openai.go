@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultOpenAIBaseURL 接続先未指定時に使用するOpenAI互換APIのベースURL（ローカルのOllama）
+const DefaultOpenAIBaseURL = "http://localhost:11434/v1"
+
+// openAIClient OpenAI互換の /v1/chat/completions を利用するLLMClient実装
+// Ollama・llama.cpp server・vLLMなどのセルフホストのモデルサーバーに接続する
+type openAIClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+}
+
+// NewOpenAIClient 接続先のベースURL（例: http://localhost:11434/v1）とモデル名を指定してクライアントを作成する
+// モデル名を指定した場合は機能ごとの設定に関わらずそのモデルを使用する（セルフホストのサーバーは提供するモデルが限られるため）
+// APIキーが不要なサーバーの場合はapiKeyを空にする
+func NewOpenAIClient(baseURL string, apiKey string, model string) LLMClient {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &openAIClient{
+		httpClient: &http.Client{},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+	}
+}
+
+// openAIChatRequest /chat/completions のリクエスト
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIChatMessage   `json:"messages"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIResponseFormat 構造化出力の指定（JSON Schemaに従った出力を要求する）
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// openAIChatResponse /chat/completions のレスポンス
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAIErrorResponse エラー時のレスポンス
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *openAIClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	model := c.model
+	if model == "" {
+		model = req.Model
+	}
+	if model == "" {
+		return nil, fmt.Errorf("OpenAI互換APIで使用するモデルが指定されていません（機能: %s）", req.Feature)
+	}
+
+	body := openAIChatRequest{
+		Model:       model,
+		Messages:    toOpenAIMessages(req),
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+	if req.Output != nil {
+		body.ResponseFormat = &openAIResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &openAIJSONSchema{
+				Name:        req.Output.Name,
+				Description: req.Output.Description,
+				Schema:      req.Output.Schema,
+			},
+		}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI互換APIのリクエストの作成に失敗しました: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI互換APIのリクエストの作成に失敗しました: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	httpRes, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &APIError{Provider: "OpenAI互換", Err: err}
+	}
+	defer httpRes.Body.Close()
+
+	resBody, err := io.ReadAll(httpRes.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &APIError{Provider: "OpenAI互換", Err: err}
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		return nil, &APIError{
+			Provider:   "OpenAI互換",
+			StatusCode: httpRes.StatusCode,
+			RetryAfter: parseRetryAfter(httpRes.Header),
+			Err:        fmt.Errorf("%s", openAIErrorMessage(resBody)),
+		}
+	}
+
+	var chatRes openAIChatResponse
+	if err := json.Unmarshal(resBody, &chatRes); err != nil {
+		return nil, &APIError{Provider: "OpenAI互換", StatusCode: httpRes.StatusCode, Err: fmt.Errorf("レスポンスを解析できません: %w", err)}
+	}
+	if len(chatRes.Choices) == 0 {
+		return nil, &APIError{Provider: "OpenAI互換", StatusCode: httpRes.StatusCode, Err: fmt.Errorf("レスポンスに出力が含まれていません")}
+	}
+
+	resModel := chatRes.Model
+	if resModel == "" {
+		resModel = model
+	}
+	return &Response{
+		Text:         chatRes.Choices[0].Message.Content,
+		Model:        resModel,
+		InputTokens:  chatRes.Usage.PromptTokens,
+		OutputTokens: chatRes.Usage.CompletionTokens,
+	}, nil
+}
+
+// toOpenAIMessages システムプロンプトと会話履歴をOpenAI形式のメッセージに変換する
+func toOpenAIMessages(req *Request) []openAIChatMessage {
+	messages := make([]openAIChatMessage, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, openAIChatMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		role := string(RoleUser)
+		if m.Role == RoleAssistant {
+			role = string(RoleAssistant)
+		}
+		messages = append(messages, openAIChatMessage{Role: role, Content: m.Content})
+	}
+	return messages
+}
+
+// openAIErrorMessage エラーレスポンスからメッセージを取り出す（形式が異なる場合は本文をそのまま返す）
+func openAIErrorMessage(body []byte) string {
+	var errRes openAIErrorResponse
+	if err := json.Unmarshal(body, &errRes); err == nil && errRes.Error.Message != "" {
+		return errRes.Error.Message
+	}
+	message := strings.TrimSpace(string(body))
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	return message
+}
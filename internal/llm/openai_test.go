@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpenAIClientGenerate(t *testing.T) {
+	temperature := 0.2
+	output := &OutputSchema{
+		Name:        "grading_result",
+		Description: "採点結果",
+		Schema: ObjectSchema(map[string]*Schema{
+			"advice": StringSchema("アドバイス"),
+		}),
+	}
+
+	tests := []struct {
+		name        string
+		clientModel string // NewOpenAIClientで指定するモデル
+		reqModel    string // リクエストのモデル
+		resModel    string // サーバーが返すモデル
+		output      *OutputSchema
+		wantModel   string // サーバーに送るモデル
+		wantResult  string // 応答のモデル
+	}{
+		{name: "クライアントのモデルを優先する", clientModel: "qwen2.5:7b", reqModel: "claude-sonnet-4-5", resModel: "qwen2.5:7b", wantModel: "qwen2.5:7b", wantResult: "qwen2.5:7b"},
+		{name: "クライアントのモデルが未指定の場合はリクエストのモデル", reqModel: "llama3.1:8b", resModel: "llama3.1:8b-instruct", wantModel: "llama3.1:8b", wantResult: "llama3.1:8b-instruct"},
+		{name: "応答にモデルがない場合は送ったモデル", clientModel: "qwen2.5:7b", wantModel: "qwen2.5:7b", wantResult: "qwen2.5:7b"},
+		{name: "構造化出力", clientModel: "qwen2.5:7b", output: output, wantModel: "qwen2.5:7b", wantResult: "qwen2.5:7b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got openAIChatRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+					t.Errorf("リクエスト = %s %s, want POST /v1/chat/completions", r.Method, r.URL.Path)
+				}
+				if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+					t.Errorf("Authorization = %q", auth)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("リクエストのデコードに失敗しました: %v", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]any{
+					"model": tt.resModel,
+					"choices": []map[string]any{
+						{"message": map[string]any{"role": "assistant", "content": `{"advice": "よく書けています。"}`}},
+					},
+					"usage": map[string]any{"prompt_tokens": 120, "completion_tokens": 30},
+				})
+			}))
+			defer server.Close()
+
+			// ベースURLの末尾の「/」は取り除く
+			client := NewOpenAIClient(server.URL+"/v1/", "test-key", tt.clientModel)
+			req := &Request{
+				Feature:     FeatureGrading,
+				Model:       tt.reqModel,
+				MaxTokens:   500,
+				Temperature: &temperature,
+				System:      "あなたは英語の先生です。",
+				Messages: []Message{
+					{Role: RoleUser, Content: "採点してください"},
+					{Role: RoleAssistant, Content: "{}"},
+					{Role: RoleUser, Content: "修正してください"},
+				},
+				Output: tt.output,
+			}
+			res, err := client.Generate(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+
+			if got.Model != tt.wantModel {
+				t.Errorf("送ったモデル = %q, want %q", got.Model, tt.wantModel)
+			}
+			if got.MaxTokens != 500 || got.Temperature == nil || *got.Temperature != temperature {
+				t.Errorf("max_tokens・temperature = %d, %v", got.MaxTokens, got.Temperature)
+			}
+			wantRoles := []string{"system", "user", "assistant", "user"}
+			if len(got.Messages) != len(wantRoles) {
+				t.Fatalf("メッセージ = %+v", got.Messages)
+			}
+			for i, role := range wantRoles {
+				if got.Messages[i].Role != role {
+					t.Errorf("メッセージ[%d]のロール = %q, want %q", i, got.Messages[i].Role, role)
+				}
+			}
+
+			if tt.output == nil {
+				if got.ResponseFormat != nil {
+					t.Errorf("response_format = %+v, want なし", got.ResponseFormat)
+				}
+			} else {
+				format := got.ResponseFormat
+				if format == nil || format.Type != "json_schema" || format.JSONSchema == nil {
+					t.Fatalf("response_format = %+v, want json_schema", format)
+				}
+				if format.JSONSchema.Name != tt.output.Name || format.JSONSchema.Description != tt.output.Description {
+					t.Errorf("json_schemaの名前・説明 = %q, %q", format.JSONSchema.Name, format.JSONSchema.Description)
+				}
+				if schema := format.JSONSchema.Schema; schema == nil || schema.Type != "object" || schema.Properties["advice"] == nil {
+					t.Errorf("json_schemaのスキーマ = %+v", schema)
+				}
+			}
+
+			if res.Text != `{"advice": "よく書けています。"}` {
+				t.Errorf("Text = %q", res.Text)
+			}
+			if res.Model != tt.wantResult {
+				t.Errorf("Model = %q, want %q", res.Model, tt.wantResult)
+			}
+			if res.InputTokens != 120 || res.OutputTokens != 30 {
+				t.Errorf("トークン数 = %d, %d; want 120, 30", res.InputTokens, res.OutputTokens)
+			}
+		})
+	}
+}
+
+func TestOpenAIClientModelRequired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("モデルが未指定の場合はAPIを呼び出さない")
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIClient(server.URL, "", "").Generate(context.Background(), NewUserRequest(FeatureGrading, "", 100, "採点してください"))
+	if err == nil {
+		t.Fatal("モデルが未指定の場合はエラーを返す")
+	}
+}
+
+func TestOpenAIClientErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		header         map[string]string
+		body           string
+		wantRetryable  bool
+		wantRetryAfter time.Duration
+		wantMessage    string
+	}{
+		{name: "429", status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "3"}, body: `{"error": {"message": "rate limited"}}`, wantRetryable: true, wantRetryAfter: 3 * time.Second, wantMessage: "rate limited"},
+		{name: "500", status: http.StatusInternalServerError, body: `{"error": {"message": "internal error"}}`, wantRetryable: true, wantMessage: "internal error"},
+		{name: "503（JSON以外の本文）", status: http.StatusServiceUnavailable, body: "model is loading", wantRetryable: true, wantMessage: "model is loading"},
+		{name: "400", status: http.StatusBadRequest, body: `{"error": {"message": "invalid schema"}}`, wantRetryable: false, wantMessage: "invalid schema"},
+		{name: "401", status: http.StatusUnauthorized, body: `{"error": {"message": "invalid api key"}}`, wantRetryable: false, wantMessage: "invalid api key"},
+		{name: "404", status: http.StatusNotFound, body: `{"error": {"message": "model not found"}}`, wantRetryable: false, wantMessage: "model not found"},
+		{name: "200で解析できない本文", status: http.StatusOK, body: "not json", wantRetryable: false},
+		{name: "200で出力がない", status: http.StatusOK, body: `{"choices": []}`, wantRetryable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.header {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewOpenAIClient(server.URL, "", "qwen2.5:7b").Generate(context.Background(), NewUserRequest(FeatureGrading, "", 100, "採点してください"))
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("エラー = %v, want APIError", err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+			if apiErr.Retryable() != tt.wantRetryable || isRetryable(err) != tt.wantRetryable {
+				t.Errorf("Retryable = %v, want %v", apiErr.Retryable(), tt.wantRetryable)
+			}
+			if apiErr.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("RetryAfter = %v, want %v", apiErr.RetryAfter, tt.wantRetryAfter)
+			}
+			if tt.wantMessage != "" && apiErr.Err.Error() != tt.wantMessage {
+				t.Errorf("メッセージ = %q, want %q", apiErr.Err.Error(), tt.wantMessage)
+			}
+		})
+	}
+
+	// 接続できない場合は通信エラーとして再試行の対象にする
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	_, err := NewOpenAIClient(server.URL, "", "qwen2.5:7b").Generate(context.Background(), NewUserRequest(FeatureGrading, "", 100, "採点してください"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 0 || !apiErr.Retryable() {
+		t.Errorf("接続できない場合のエラー = %v, want 再試行の対象の通信エラー", err)
+	}
+}
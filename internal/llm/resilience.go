@@ -0,0 +1,296 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// 再試行・タイムアウト・サーキットブレーカーの設定単位となる機能グループ
+const (
+	GroupGrading  = "grading"
+	GroupAnalysis = "analysis"
+)
+
+// FeatureGroup 機能が属する機能グループを返す
+// 学習者が応答を待つチューターとの対話は採点と同じグループとする
+func FeatureGroup(feature string) string {
+	if feature == FeatureGrading || feature == FeatureTutor {
+		return GroupGrading
+	}
+	return GroupAnalysis
+}
+
+// Policy LLM呼び出しの再試行・タイムアウト・サーキットブレーカーの設定
+type Policy struct {
+	Timeout          time.Duration // 1回の呼び出しのタイムアウト
+	MaxRetries       int           // 最大再試行回数（初回の呼び出しを含まない）
+	InitialBackoff   time.Duration // 初回の再試行までの待機時間
+	MaxBackoff       time.Duration // 再試行までの待機時間の上限
+	BreakerThreshold int           // サーキットブレーカーを開く連続失敗回数
+	BreakerCooldown  time.Duration // サーキットブレーカーを開いてから試行を再開するまでの時間
+}
+
+// DefaultPolicy 機能グループごとのデフォルト設定を返す
+// 採点はユーザーが結果を待つため短め、分析は長い出力を生成するため長めのタイムアウトとする
+func DefaultPolicy(group string) Policy {
+	policy := Policy{
+		Timeout:          60 * time.Second,
+		MaxRetries:       3,
+		InitialBackoff:   time.Second,
+		MaxBackoff:       20 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+	if group == GroupAnalysis {
+		policy.Timeout = 180 * time.Second
+		policy.MaxRetries = 2
+	}
+	return policy
+}
+
+// backoff 再試行回数に応じた待機時間（指数バックオフ + ジッター）を返す
+func (p Policy) backoff(retry int) time.Duration {
+	wait := p.InitialBackoff << retry
+	if wait <= 0 || wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	// 待機時間の半分を固定、残り半分をランダムにして同時再試行の集中を避ける
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// ResilientClient 機能グループごとのポリシーで再試行・タイムアウト・サーキットブレーカーを適用するLLMClient
+type ResilientClient struct {
+	next     LLMClient
+	policies map[string]Policy
+	breakers map[string]*circuitBreaker
+}
+
+// NewResilientClient 機能グループごとのポリシーを指定してクライアントをラップする
+// ポリシーが指定されていない機能グループにはDefaultPolicyを適用する
+func NewResilientClient(next LLMClient, policies map[string]Policy) *ResilientClient {
+	c := &ResilientClient{
+		next:     next,
+		policies: make(map[string]Policy),
+		breakers: make(map[string]*circuitBreaker),
+	}
+	for _, group := range []string{GroupGrading, GroupAnalysis} {
+		policy, ok := policies[group]
+		if !ok {
+			policy = DefaultPolicy(group)
+		}
+		c.policies[group] = policy
+		c.breakers[group] = newCircuitBreaker(policy.BreakerThreshold, policy.BreakerCooldown)
+	}
+	return c
+}
+
+func (c *ResilientClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	group := FeatureGroup(req.Feature)
+	policy := c.policies[group]
+	breaker := c.breakers[group]
+
+	var lastErr error
+	for attempt := 0; attempt <= policy.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := policy.backoff(attempt - 1)
+			var apiErr *APIError
+			if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > wait {
+				wait = apiErr.RetryAfter
+			}
+			log.Printf("LLM呼び出しを再試行します（機能: %s, %d回目, %v後）: %v", req.Feature, attempt, wait, lastErr)
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := breaker.allow(); err != nil {
+			return nil, fmt.Errorf("%w（機能グループ: %s）", err, group)
+		}
+
+		res, err := c.generateWithTimeout(ctx, req, policy.Timeout)
+		if err == nil {
+			breaker.success()
+			return res, nil
+		}
+
+		// 呼び出し元のコンテキストが終了した場合は再試行しない
+		if ctx.Err() != nil {
+			breaker.release()
+			return nil, err
+		}
+		if !isRetryable(err) {
+			// リクエスト自体の誤り（4xxなど）はプロバイダの障害ではないため失敗として数えない
+			breaker.release()
+			return nil, err
+		}
+
+		lastErr = err
+		if opened := breaker.failure(); opened {
+			return nil, fmt.Errorf("%w（機能グループ: %s）: %w", ErrCircuitOpen, group, lastErr)
+		}
+	}
+
+	return nil, fmt.Errorf("LLM呼び出しが%d回失敗しました（機能: %s）: %w", policy.MaxRetries+1, req.Feature, lastErr)
+}
+
+// BreakerState 機能グループのサーキットブレーカーの状態（closed / open / half_open）を返す
+func (c *ResilientClient) BreakerState(group string) string {
+	breaker, ok := c.breakers[group]
+	if !ok {
+		return ""
+	}
+	return breaker.currentState()
+}
+
+// generateWithTimeout 1回分のタイムアウトを設定して呼び出す
+func (c *ResilientClient) generateWithTimeout(ctx context.Context, req *Request, timeout time.Duration) (*Response, error) {
+	if timeout <= 0 {
+		return c.next.Generate(ctx, req)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := c.next.Generate(attemptCtx, req)
+	if err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("LLM呼び出しがタイムアウトしました（%v）: %w", timeout, context.DeadlineExceeded)
+	}
+	return res, err
+}
+
+// isRetryable 再試行の対象となるエラーかどうか（1回分のタイムアウト・通信エラー・429・5xx）
+func isRetryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	return false
+}
+
+// sleepContext コンテキストが終了するまでの範囲で指定時間待機する
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// サーキットブレーカーの状態
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half_open"
+)
+
+// circuitBreaker 連続失敗回数が閾値を超えたら一定時間呼び出しを遮断する
+// 遮断時間の経過後は1件だけ試行を許可し（half_open）、成功すれば復旧、失敗すれば再び遮断する
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     breakerClosed,
+	}
+}
+
+// allow 呼び出しを許可するかどうかを判定する
+func (b *circuitBreaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// success 呼び出しの成功を記録する
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != breakerClosed {
+		log.Println("LLMプロバイダへの呼び出しが回復しました（サーキットブレーカー解除）")
+	}
+	b.state = breakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure 呼び出しの失敗を記録し、遮断状態になったかどうかを返す
+func (b *circuitBreaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.failures++
+	if b.threshold > 0 && (b.state == breakerHalfOpen || b.failures >= b.threshold) {
+		if b.state != breakerOpen {
+			log.Printf("LLMプロバイダへの呼び出しが%d回連続で失敗したため、%v遮断します", b.failures, b.cooldown)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// release 成功・失敗のどちらにも数えない結果を記録する（試行中の枠だけを解放する）
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// currentState 現在の状態を返す
+func (b *circuitBreaker) currentState() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return breakerHalfOpen
+	}
+	return b.state
+}
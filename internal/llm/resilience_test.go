@@ -0,0 +1,284 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer 呼び出し順に指定したステータスを返すAnthropic Messages APIのサーバー（使い切った後は最後のステータスを返す）
+// delaysを指定した場合は、その呼び出しの応答を指定時間遅らせる
+type scriptedServer struct {
+	*httptest.Server
+	statuses []int
+	delays   []time.Duration
+	calls    atomic.Int32
+	done     chan struct{} // テストの終了時に遅らせている応答を打ち切る
+}
+
+func newScriptedServer(t *testing.T, statuses []int, delays []time.Duration) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{statuses: statuses, delays: delays, done: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(s.calls.Add(1)) - 1
+		if call < len(s.delays) && s.delays[call] > 0 {
+			select {
+			case <-time.After(s.delays[call]):
+			case <-r.Context().Done():
+				return
+			case <-s.done:
+				return
+			}
+		}
+
+		status := s.statuses[min(call, len(s.statuses)-1)]
+		if status != http.StatusOK {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]any{
+				"type":  "error",
+				"error": map[string]any{"type": "api_error", "message": http.StatusText(status)},
+			})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":          "msg_test",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       DefaultClaudeModel,
+			"content":     []map[string]any{{"type": "text", "text": "ok"}},
+			"stop_reason": "end_turn",
+			"usage":       map[string]any{"input_tokens": 10, "output_tokens": 2},
+		})
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { close(s.done) })
+	return s
+}
+
+// testPolicy テスト用に待機時間を短くしたポリシー
+func testPolicy() Policy {
+	return Policy{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       4 * time.Millisecond,
+		BreakerThreshold: 0,
+	}
+}
+
+func newTestResilientClient(server *scriptedServer, policy Policy) *ResilientClient {
+	return NewResilientClient(NewClaudeClient("test-key", server.URL), map[string]Policy{
+		GroupGrading:  policy,
+		GroupAnalysis: policy,
+	})
+}
+
+func TestResilientClientRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		delays     []time.Duration
+		timeout    time.Duration
+		wantCalls  int
+		wantErr    bool
+		wantStatus int  // 返すエラーのHTTPステータス
+		wantDead   bool // 返すエラーがタイムアウトかどうか
+	}{
+		{name: "成功", statuses: []int{200}, wantCalls: 1},
+		{name: "429の後に成功", statuses: []int{429, 200}, wantCalls: 2},
+		{name: "5xxの後に成功", statuses: []int{500, 503, 200}, wantCalls: 3},
+		{name: "再試行の上限まで5xx", statuses: []int{502}, wantCalls: 3, wantErr: true, wantStatus: 502},
+		{name: "400は再試行しない", statuses: []int{400, 200}, wantCalls: 1, wantErr: true, wantStatus: 400},
+		{name: "401は再試行しない", statuses: []int{401, 200}, wantCalls: 1, wantErr: true, wantStatus: 401},
+		{name: "404は再試行しない", statuses: []int{404, 200}, wantCalls: 1, wantErr: true, wantStatus: 404},
+		{name: "1回分のタイムアウトの後に成功", statuses: []int{200}, delays: []time.Duration{time.Second}, timeout: 50 * time.Millisecond, wantCalls: 2},
+		{name: "再試行の上限までタイムアウト", statuses: []int{200}, delays: []time.Duration{time.Second, time.Second, time.Second}, timeout: 50 * time.Millisecond, wantCalls: 3, wantErr: true, wantDead: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newScriptedServer(t, tt.statuses, tt.delays)
+			policy := testPolicy()
+			if tt.timeout > 0 {
+				policy.Timeout = tt.timeout
+			}
+			client := newTestResilientClient(server, policy)
+
+			res, err := client.Generate(context.Background(), NewUserRequest(FeatureGrading, "", 100, "採点してください"))
+			if got := int(server.calls.Load()); got != tt.wantCalls {
+				t.Errorf("呼び出し回数 = %d, want %d", got, tt.wantCalls)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Generate: %v", err)
+				}
+				if res.Text != "ok" {
+					t.Errorf("Text = %q", res.Text)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("エラーを返す")
+			}
+			if tt.wantStatus != 0 {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+					t.Errorf("エラー = %v, want ステータス %d", err, tt.wantStatus)
+				}
+			}
+			if tt.wantDead && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("エラー = %v, want タイムアウト", err)
+			}
+		})
+	}
+}
+
+// 呼び出し元のコンテキストが終了した場合は再試行しない
+func TestResilientClientCallerCanceled(t *testing.T) {
+	server := newScriptedServer(t, []int{200}, []time.Duration{time.Second})
+	client := newTestResilientClient(server, testPolicy())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.Generate(ctx, NewUserRequest(FeatureGrading, "", 100, "採点してください"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("エラー = %v, want 呼び出し元のタイムアウト", err)
+	}
+	if got := server.calls.Load(); got != 1 {
+		t.Errorf("呼び出し回数 = %d, want 1", got)
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	policy := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		retry int
+		max   time.Duration // ジッターを含まない待機時間
+	}{
+		{retry: 0, max: 100 * time.Millisecond},
+		{retry: 1, max: 200 * time.Millisecond},
+		{retry: 2, max: 400 * time.Millisecond},
+		{retry: 3, max: 800 * time.Millisecond},
+		{retry: 4, max: time.Second},  // 上限で打ち切る
+		{retry: 70, max: time.Second}, // シフトで桁あふれしても上限
+	}
+
+	for _, tt := range tests {
+		// 待機時間は上限の半分から上限までの範囲でばらつく
+		seen := make(map[time.Duration]struct{})
+		for i := 0; i < 100; i++ {
+			wait := policy.backoff(tt.retry)
+			if wait < tt.max/2 || wait > tt.max {
+				t.Fatalf("backoff(%d) = %v, want %v〜%v", tt.retry, wait, tt.max/2, tt.max)
+			}
+			seen[wait] = struct{}{}
+		}
+		if len(seen) < 2 {
+			t.Errorf("backoff(%d) の待機時間がばらついていません", tt.retry)
+		}
+	}
+
+	if wait := (Policy{}).backoff(0); wait != 0 {
+		t.Errorf("待機時間の設定がない場合の backoff = %v, want 0", wait)
+	}
+}
+
+func TestResilientClientCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		probe     int    // 遮断時間の経過後の試行でサーバーが返すステータス
+		wantState string // 試行後のサーキットブレーカーの状態
+	}{
+		{name: "試行が成功したら復旧する", probe: 200, wantState: breakerClosed},
+		{name: "試行が失敗したら再び遮断する", probe: 500, wantState: breakerOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newScriptedServer(t, []int{500, 500, tt.probe}, nil)
+			policy := testPolicy()
+			policy.MaxRetries = 0
+			policy.BreakerThreshold = 2
+			policy.BreakerCooldown = 50 * time.Millisecond
+			client := newTestResilientClient(server, policy)
+			req := NewUserRequest(FeatureGrading, "", 100, "採点してください")
+
+			// 連続失敗が閾値に達したら遮断する
+			if _, err := client.Generate(context.Background(), req); err == nil || errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("1回目のエラー = %v, want 遮断前の失敗", err)
+			}
+			if _, err := client.Generate(context.Background(), req); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("2回目のエラー = %v, want ErrCircuitOpen", err)
+			}
+			if state := client.BreakerState(GroupGrading); state != breakerOpen {
+				t.Errorf("状態 = %s, want open", state)
+			}
+
+			// 遮断中はAPIを呼び出さずに失敗する（別の機能グループには影響しない）
+			if _, err := client.Generate(context.Background(), req); !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("遮断中のエラー = %v, want ErrCircuitOpen", err)
+			}
+			if got := server.calls.Load(); got != 2 {
+				t.Errorf("遮断中の呼び出し回数 = %d, want 2", got)
+			}
+			if state := client.BreakerState(GroupAnalysis); state != breakerClosed {
+				t.Errorf("分析の状態 = %s, want closed", state)
+			}
+
+			// 遮断時間の経過後は1件だけ試行する
+			time.Sleep(policy.BreakerCooldown + 10*time.Millisecond)
+			if state := client.BreakerState(GroupGrading); state != breakerHalfOpen {
+				t.Errorf("遮断時間の経過後の状態 = %s, want half_open", state)
+			}
+			_, err := client.Generate(context.Background(), req)
+			if tt.probe == 200 && err != nil {
+				t.Errorf("試行のエラー = %v", err)
+			}
+			if tt.probe != 200 && !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("試行のエラー = %v, want ErrCircuitOpen", err)
+			}
+			if got := server.calls.Load(); got != 3 {
+				t.Errorf("試行後の呼び出し回数 = %d, want 3", got)
+			}
+			if state := client.BreakerState(GroupGrading); state != tt.wantState {
+				t.Errorf("試行後の状態 = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+// 半開状態では試行中の呼び出しが終わるまで他の呼び出しを遮断する
+func TestCircuitBreakerHalfOpenSingleProbe(t *testing.T) {
+	breaker := newCircuitBreaker(1, 10*time.Millisecond)
+	breaker.failure()
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("遮断中の allow = %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("遮断時間の経過後の allow = %v, want nil", err)
+	}
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("試行中の allow = %v, want ErrCircuitOpen", err)
+	}
+
+	// 4xxなど成功・失敗に数えない結果の場合は、次の呼び出しで試行し直す
+	breaker.release()
+	if err := breaker.allow(); err != nil {
+		t.Errorf("試行の枠を解放した後の allow = %v, want nil", err)
+	}
+	breaker.success()
+	if state := breaker.currentState(); state != breakerClosed {
+		t.Errorf("状態 = %s, want closed", state)
+	}
+}
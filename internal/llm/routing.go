@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"log"
+	"strings"
+)
+
+// FeatureConfig 機能ごとのモデル設定
+type FeatureConfig struct {
+	Model       string   // 使用するモデル（空の場合はプロバイダのデフォルト）
+	MaxTokens   int      // 最大出力トークン数
+	Temperature *float64 // 未指定の場合はプロバイダのデフォルト
+}
+
+// RouteInput モデルの選択に使用するリクエストの特徴
+type RouteInput struct {
+	Level        string // 問題のレベル（basic / inter / adv）
+	QuestionType string // 問題の種類（essay / translate / fill）
+	AnswerLength int    // 解答の文字数
+}
+
+// RoutingRule 条件に一致したリクエストに使用するモデルの規則
+// 指定した条件はすべて満たす必要がある（未指定の条件は判定しない）
+type RoutingRule struct {
+	Name            string   // 規則の名前（ログ出力用）
+	Levels          []string // 問題のレベルのいずれかに一致する
+	QuestionTypes   []string // 問題の種類のいずれかに一致する
+	MaxAnswerLength int      // 解答の文字数がこの値以下（0は判定しない）
+	MinAnswerLength int      // 解答の文字数がこの値以上（0は判定しない）
+	Model           string   // 使用するモデル
+	MaxTokens       int      // 最大出力トークン数（0の場合は機能の設定を使用する）
+}
+
+// matches リクエストの特徴が規則の条件をすべて満たすかどうか
+func (r *RoutingRule) matches(input RouteInput) bool {
+	if len(r.Levels) > 0 && !containsString(r.Levels, input.Level) {
+		return false
+	}
+	if len(r.QuestionTypes) > 0 && !containsString(r.QuestionTypes, input.QuestionType) {
+		return false
+	}
+	if r.MaxAnswerLength > 0 && input.AnswerLength > r.MaxAnswerLength {
+		return false
+	}
+	if r.MinAnswerLength > 0 && input.AnswerLength < r.MinAnswerLength {
+		return false
+	}
+	return true
+}
+
+// ModelRouter 機能ごとの設定とルーティング規則から、リクエストに使用するモデルを選ぶ
+type ModelRouter struct {
+	features map[string]FeatureConfig
+	rules    map[string][]RoutingRule
+}
+
+// NewModelRouter 機能ごとの設定とルーティング規則を指定して作成する
+// 規則は機能ごとに先頭から評価し、最初に一致した規則のモデルを使用する（一致しなければ機能の設定を使用する）
+func NewModelRouter(features map[string]FeatureConfig, rules map[string][]RoutingRule) *ModelRouter {
+	return &ModelRouter{features: features, rules: rules}
+}
+
+// Config 機能の設定を返す
+func (r *ModelRouter) Config(feature string) FeatureConfig {
+	return r.features[feature]
+}
+
+// NewRequest 機能の設定とルーティング規則を適用した単一プロンプトのリクエストを作成する
+func (r *ModelRouter) NewRequest(feature string, input RouteInput, prompt string) *Request {
+	config := r.features[feature]
+	req := NewUserRequest(feature, config.Model, config.MaxTokens, prompt)
+	req.Temperature = config.Temperature
+
+	for _, rule := range r.rules[feature] {
+		if !rule.matches(input) {
+			continue
+		}
+		req.Model = rule.Model
+		if rule.MaxTokens > 0 {
+			req.MaxTokens = rule.MaxTokens
+		}
+		log.Printf("LLMのモデルを選択しました（機能: %s, 規則: %s, モデル: %s）", feature, rule.Name, rule.Model)
+		break
+	}
+	return req
+}
+
+// JoinUnique 使用したモデルやプロバイダの名前を、重複と空文字を除いて出現順にカンマ区切りで連結する
+func JoinUnique(names []string) string {
+	seen := make(map[string]struct{}, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok || name == "" {
+			continue
+		}
+		seen[name] = struct{}{}
+		unique = append(unique, name)
+	}
+	return strings.Join(unique, ",")
+}
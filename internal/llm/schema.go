@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Schema LLMの構造化出力を定義・検証するためのJSON Schema（使用するキーワードのみのサブセット）
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+}
+
+// OutputSchema LLM呼び出しで期待する構造化出力の定義
+type OutputSchema struct {
+	Name        string  // 出力の名前（ツール名として使用するため英数字とアンダースコアのみ）
+	Description string  // 出力の説明
+	Schema      *Schema // 出力JSONのスキーマ（object型）
+}
+
+// ObjectSchema 全プロパティを必須とするobject型のスキーマを作成する
+func ObjectSchema(properties map[string]*Schema) *Schema {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// IntegerSchema 値の範囲を持つinteger型のスキーマを作成する
+func IntegerSchema(description string, min, max int) *Schema {
+	minimum, maximum := float64(min), float64(max)
+	return &Schema{Type: "integer", Description: description, Minimum: &minimum, Maximum: &maximum}
+}
+
+// StringSchema 空文字を許可しないstring型のスキーマを作成する
+func StringSchema(description string) *Schema {
+	minLength := 1
+	return &Schema{Type: "string", Description: description, MinLength: &minLength}
+}
+
+// EnumSchema 列挙値のいずれかをとるstring型のスキーマを作成する
+func EnumSchema(description string, values ...string) *Schema {
+	return &Schema{Type: "string", Description: description, Enum: values}
+}
+
+// BooleanSchema boolean型のスキーマを作成する
+func BooleanSchema(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+// ArraySchema 要素のスキーマを指定してarray型のスキーマを作成する
+func ArraySchema(description string, items *Schema) *Schema {
+	return &Schema{Type: "array", Description: description, Items: items}
+}
+
+// StringArraySchema 文字列配列のスキーマを作成する
+func StringArraySchema(description string) *Schema {
+	return ArraySchema(description, &Schema{Type: "string"})
+}
+
+// Validate JSONテキストをスキーマで検証し、違反内容の一覧を返す（違反がなければ空）
+func (s *Schema) Validate(data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("有効なJSONではありません: %v", err)}
+	}
+
+	var violations []string
+	s.validateValue("$", value, &violations)
+	return violations
+}
+
+func (s *Schema) validateValue(path string, value any, violations *[]string) {
+	addf := func(format string, args ...any) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if value == nil {
+		addf("nullは許可されていません（%s型が必要です）", s.Type)
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			addf("object型である必要があります")
+			return
+		}
+		for _, name := range s.Required {
+			if _, exists := object[name]; !exists {
+				addf("必須プロパティ %q がありません", name)
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if child, exists := object[name]; exists {
+				s.Properties[name].validateValue(path+"."+name, child, violations)
+			}
+		}
+
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			addf("array型である必要があります")
+			return
+		}
+		if s.MinItems != nil && len(array) < *s.MinItems {
+			addf("要素数は%d以上である必要があります（実際: %d）", *s.MinItems, len(array))
+		}
+		if s.MaxItems != nil && len(array) > *s.MaxItems {
+			addf("要素数は%d以下である必要があります（実際: %d）", *s.MaxItems, len(array))
+		}
+		if s.Items != nil {
+			for i, item := range array {
+				s.Items.validateValue(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			addf("string型である必要があります")
+			return
+		}
+		if s.MinLength != nil && len([]rune(str)) < *s.MinLength {
+			addf("%d文字以上である必要があります", *s.MinLength)
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, str) {
+			addf("%v のいずれかである必要があります（実際: %q）", s.Enum, str)
+		}
+
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			addf("%s型である必要があります", s.Type)
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				addf("整数である必要があります（実際: %s）", number)
+				return
+			}
+		}
+		f, err := number.Float64()
+		if err != nil {
+			addf("数値として解釈できません（実際: %s）", number)
+			return
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			addf("%v以上である必要があります（実際: %s）", *s.Minimum, number)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			addf("%v以下である必要があります（実際: %s）", *s.Maximum, number)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			addf("boolean型である必要があります")
+		}
+	}
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
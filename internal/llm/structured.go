@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DefaultMaxRepairs 構造化出力が不正だった場合に修正を依頼する最大回数
+const DefaultMaxRepairs = 2
+
+// ValidationError 修正の再試行を行っても構造化出力がスキーマや値の制約を満たさなかった
+type ValidationError struct {
+	Feature  string   // 呼び出し元の機能
+	Attempts int      // LLMを呼び出した回数
+	Errors   []string // 最後の出力の検証エラー
+	Output   string   // 最後の出力テキスト
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("LLMの出力が検証に失敗しました（機能: %s, 試行回数: %d）: %s",
+		e.Feature, e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// IsValidationError エラーが構造化出力の検証エラーかどうかを判定する
+func IsValidationError(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr)
+}
+
+// GenerateStructured スキーマ付きでLLMを呼び出し、検証済みの出力をoutにデコードする
+//
+// req.Output のスキーマで出力を検証したうえでoutにデコードし、checkが指定されていれば
+// スキーマで表現できない値の制約（合計値や項目間の整合性など）を検証する。
+// 検証に失敗した場合は、エラー内容をLLMに伝えて最大DefaultMaxRepairs回まで修正を依頼し、
+// それでも失敗した場合は *ValidationError を返す。
+//
+// 検証はRequest.Validateとしてクライアントにも渡すため、FallbackClientを含むクライアントでは
+// プロバイダごとに検証と修正依頼を行い、修正後も検証を通らない場合は次のプロバイダで再実行する。
+func GenerateStructured(ctx context.Context, client LLMClient, req *Request, out any, check func() []string) (*Response, error) {
+	if req.Output == nil || req.Output.Schema == nil {
+		return nil, fmt.Errorf("構造化出力のスキーマが指定されていません（機能: %s）", req.Feature)
+	}
+
+	// 修正依頼で会話履歴を追加するため、呼び出し元のリクエストは変更しない
+	attemptReq := *req
+	attemptReq.Messages = append([]Message(nil), req.Messages...)
+	attemptReq.Validate = func(text string) []string {
+		return decodeStructured(text, req.Output.Schema, out, check)
+	}
+	return generateValidated(ctx, client, &attemptReq)
+}
+
+// generateValidated req.Validate で出力を検証し、不正な場合は修正を依頼する（reqの会話履歴に修正依頼を追加する）
+// クライアント（FallbackClient）が検証済みの応答を返した場合は、そのまま返す
+func generateValidated(ctx context.Context, client LLMClient, req *Request) (*Response, error) {
+	var (
+		res        *Response
+		violations []string
+	)
+	for attempt := 1; attempt <= DefaultMaxRepairs+1; attempt++ {
+		var err error
+		res, err = client.Generate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if res.Validated {
+			return res, nil
+		}
+
+		violations = req.Validate(res.Text)
+		if len(violations) == 0 {
+			return res, nil
+		}
+
+		req.Messages = append(req.Messages,
+			Message{Role: RoleAssistant, Content: res.Text},
+			Message{Role: RoleUser, Content: repairPrompt(violations)},
+		)
+	}
+
+	return nil, &ValidationError{
+		Feature:  req.Feature,
+		Attempts: DefaultMaxRepairs + 1,
+		Errors:   violations,
+		Output:   res.Text,
+	}
+}
+
+// decodeStructured 出力テキストを検証してoutにデコードし、違反内容を返す
+func decodeStructured(text string, schema *Schema, out any, check func() []string) []string {
+	jsonStr, err := ExtractJSONObject(text)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	if violations := schema.Validate([]byte(jsonStr)); len(violations) > 0 {
+		return violations
+	}
+
+	// 前回の試行の値が残らないようにゼロ値に戻してからデコードする
+	target := reflect.ValueOf(out).Elem()
+	target.Set(reflect.Zero(target.Type()))
+	if err := json.Unmarshal([]byte(jsonStr), out); err != nil {
+		return []string{fmt.Sprintf("出力をデコードできません: %v", err)}
+	}
+
+	if check != nil {
+		return check()
+	}
+	return nil
+}
+
+// repairPrompt 検証エラーを伝えて出力の修正を依頼するプロンプト
+func repairPrompt(violations []string) string {
+	var b strings.Builder
+	b.WriteString("直前の出力は次の理由で要件を満たしていません。\n")
+	for _, v := range violations {
+		b.WriteString("- ")
+		b.WriteString(v)
+		b.WriteString("\n")
+	}
+	b.WriteString("指摘を修正し、指定されたJSON形式のオブジェクトのみを出力し直してください。")
+	return b.String()
+}
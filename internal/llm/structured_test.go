@@ -0,0 +1,275 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFeature = "test"
+
+// testOutput テスト用の構造化出力
+type testOutput struct {
+	Score   int      `json:"score"`
+	Comment string   `json:"comment"`
+	Tags    []string `json:"tags"`
+}
+
+func testOutputSchema() *OutputSchema {
+	return &OutputSchema{
+		Name: "test_output",
+		Schema: ObjectSchema(map[string]*Schema{
+			"score":   IntegerSchema("得点", 0, 100),
+			"comment": StringSchema("コメント"),
+			"tags":    StringArraySchema("タグ"),
+		}),
+	}
+}
+
+func testStructuredRequest() *Request {
+	req := NewUserRequest(testFeature, FakeModel, 100, "採点してください")
+	req.Output = testOutputSchema()
+	return req
+}
+
+// clientFunc 関数をLLMClientとして使う
+type clientFunc func(ctx context.Context, req *Request) (*Response, error)
+
+func (f clientFunc) Generate(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+// 不正なJSON・スキーマ違反の出力は違反内容を伝えて修正を依頼し、修正後の出力をデコードする
+func TestGenerateStructuredRepairsOutput(t *testing.T) {
+	fake := NewFakeClient()
+	fake.Script(testFeature,
+		"採点結果はありません",
+		`{"score": 150, "comment": "よくできています", "tags": []}`,
+		`{"score": 80, "comment": "よくできています", "tags": ["時制"]}`,
+	)
+	req := testStructuredRequest()
+
+	var out testOutput
+	res, err := GenerateStructured(context.Background(), fake, req, &out, nil)
+	if err != nil {
+		t.Fatalf("構造化出力の生成に失敗しました: %v", err)
+	}
+	want := testOutput{Score: 80, Comment: "よくできています", Tags: []string{"時制"}}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("出力 = %+v, want %+v", out, want)
+	}
+	if !strings.Contains(res.Text, `"score": 80`) {
+		t.Errorf("応答のテキスト = %q", res.Text)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("LLMの呼び出し回数 = %d, want 3", len(calls))
+	}
+	// 修正依頼では、直前の出力と違反内容を会話履歴に追加する
+	last := calls[2].Messages
+	if len(last) != 5 {
+		t.Fatalf("3回目の呼び出しの会話履歴の数 = %d, want 5", len(last))
+	}
+	if last[3].Role != RoleAssistant || last[3].Content != `{"score": 150, "comment": "よくできています", "tags": []}` {
+		t.Errorf("会話履歴の直前の出力 = %+v", last[3])
+	}
+	if last[4].Role != RoleUser || !strings.Contains(last[4].Content, "$.score: 100以下である必要があります（実際: 150）") {
+		t.Errorf("修正依頼 = %q", last[4].Content)
+	}
+	if !strings.Contains(last[2].Content, "JSON") {
+		t.Errorf("JSONがない出力の修正依頼 = %q", last[2].Content)
+	}
+	// 呼び出し元のリクエストは変更しない
+	if len(req.Messages) != 1 || req.Validate != nil {
+		t.Errorf("呼び出し元のリクエストが変更されています: %+v", req)
+	}
+}
+
+// 値の制約（check）の違反も修正を依頼する
+func TestGenerateStructuredRepairsCheckViolations(t *testing.T) {
+	fake := NewFakeClient()
+	fake.Script(testFeature,
+		`{"score": 80, "comment": "よくできています", "tags": []}`,
+		`{"score": 80, "comment": "よくできています", "tags": ["時制"]}`,
+	)
+
+	var out testOutput
+	check := func() []string {
+		if len(out.Tags) == 0 {
+			return []string{"tags: 1つ以上のタグが必要です"}
+		}
+		return nil
+	}
+	if _, err := GenerateStructured(context.Background(), fake, testStructuredRequest(), &out, check); err != nil {
+		t.Fatalf("構造化出力の生成に失敗しました: %v", err)
+	}
+	if !reflect.DeepEqual(out.Tags, []string{"時制"}) {
+		t.Errorf("タグ = %v, want [時制]", out.Tags)
+	}
+	calls := fake.Calls()
+	if len(calls) != 2 || !strings.Contains(calls[1].Messages[2].Content, "1つ以上のタグが必要です") {
+		t.Errorf("値の制約の違反で修正を依頼していません: %+v", calls)
+	}
+}
+
+// 修正を依頼しても出力が検証を通らない場合は、最後の出力と違反内容をValidationErrorで返す
+func TestGenerateStructuredExhaustsRepairs(t *testing.T) {
+	fake := NewFakeClient()
+	fake.SetDefault(testFeature, `{"score": "80", "comment": ""}`)
+
+	var out testOutput
+	_, err := GenerateStructured(context.Background(), fake, testStructuredRequest(), &out, nil)
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !IsValidationError(err) {
+		t.Fatalf("エラー = %v, want *ValidationError", err)
+	}
+	if calls := fake.Calls(); len(calls) != DefaultMaxRepairs+1 {
+		t.Errorf("LLMの呼び出し回数 = %d, want %d", len(calls), DefaultMaxRepairs+1)
+	}
+	want := []string{
+		`$: 必須プロパティ "tags" がありません`,
+		"$.comment: 1文字以上である必要があります",
+		"$.score: integer型である必要があります",
+	}
+	if validationErr.Feature != testFeature || validationErr.Attempts != DefaultMaxRepairs+1 ||
+		!reflect.DeepEqual(validationErr.Errors, want) || validationErr.Output != `{"score": "80", "comment": ""}` {
+		t.Errorf("検証エラー = %+v, want 違反 %q", validationErr, want)
+	}
+}
+
+// クライアントのエラーは修正を依頼せずにそのまま返す
+func TestGenerateStructuredReturnsClientError(t *testing.T) {
+	calls := 0
+	clientErr := errors.New("接続できません")
+	client := clientFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		calls++
+		return nil, clientErr
+	})
+
+	var out testOutput
+	if _, err := GenerateStructured(context.Background(), client, testStructuredRequest(), &out, nil); !errors.Is(err, clientErr) {
+		t.Errorf("エラー = %v, want %v", err, clientErr)
+	}
+	if calls != 1 {
+		t.Errorf("LLMの呼び出し回数 = %d, want 1", calls)
+	}
+}
+
+// クライアント（FallbackClient）が検証済みの応答を返した場合は、再度修正を依頼しない
+func TestGenerateStructuredAcceptsValidatedResponse(t *testing.T) {
+	calls := 0
+	client := clientFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		calls++
+		text := `{"score": 90, "comment": "検証済み", "tags": []}`
+		if violations := req.Validate(text); len(violations) > 0 {
+			t.Fatalf("検証エラー: %v", violations)
+		}
+		return &Response{Text: text, Validated: true}, nil
+	})
+
+	var out testOutput
+	if _, err := GenerateStructured(context.Background(), client, testStructuredRequest(), &out, nil); err != nil {
+		t.Fatalf("構造化出力の生成に失敗しました: %v", err)
+	}
+	if calls != 1 || out.Score != 90 {
+		t.Errorf("呼び出し回数 = %d, 出力 = %+v", calls, out)
+	}
+}
+
+func TestGenerateStructuredRequiresSchema(t *testing.T) {
+	req := NewUserRequest(testFeature, FakeModel, 100, "採点してください")
+
+	var out testOutput
+	if _, err := GenerateStructured(context.Background(), NewFakeClient(), req, &out, nil); err == nil || IsValidationError(err) {
+		t.Errorf("スキーマがないリクエストのエラー = %v", err)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	minItems, maxItems := 1, 2
+	schema := ObjectSchema(map[string]*Schema{
+		"score":  IntegerSchema("得点", 0, 100),
+		"rate":   {Type: "number"},
+		"level":  EnumSchema("レベル", "beginner", "advanced"),
+		"passed": BooleanSchema("合格"),
+		"items": {
+			Type:     "array",
+			MinItems: &minItems,
+			MaxItems: &maxItems,
+			Items:    ObjectSchema(map[string]*Schema{"text": StringSchema("本文")}),
+		},
+	})
+	valid := `{"score": 80, "rate": 0.5, "level": "beginner", "passed": true, "items": [{"text": "a"}]}`
+
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{name: "違反なし", json: valid},
+		{name: "有効なJSONではない", json: `{"score": 80,`, want: []string{"有効なJSONではありません: unexpected EOF"}},
+		{name: "object型ではない", json: `[]`, want: []string{"$: object型である必要があります"}},
+		{
+			name: "必須プロパティがない",
+			json: `{"score": 80, "rate": 0.5, "passed": true}`,
+			want: []string{`$: 必須プロパティ "items" がありません`, `$: 必須プロパティ "level" がありません`},
+		},
+		{
+			name: "null",
+			json: strings.Replace(valid, `"passed": true`, `"passed": null`, 1),
+			want: []string{"$.passed: nullは許可されていません（boolean型が必要です）"},
+		},
+		{
+			name: "型の違い",
+			json: `{"score": "80", "rate": "0.5", "level": 1, "passed": "true", "items": {}}`,
+			want: []string{
+				"$.items: array型である必要があります",
+				"$.level: string型である必要があります",
+				"$.passed: boolean型である必要があります",
+				"$.rate: number型である必要があります",
+				"$.score: integer型である必要があります",
+			},
+		},
+		{
+			name: "整数ではない",
+			json: strings.Replace(valid, `"score": 80`, `"score": 80.5`, 1),
+			want: []string{"$.score: 整数である必要があります（実際: 80.5）"},
+		},
+		{
+			name: "範囲外の値",
+			json: strings.Replace(valid, `"score": 80`, `"score": -1`, 1),
+			want: []string{"$.score: 0以上である必要があります（実際: -1）"},
+		},
+		{
+			name: "列挙値以外",
+			json: strings.Replace(valid, `"beginner"`, `"expert"`, 1),
+			want: []string{`$.level: [beginner advanced] のいずれかである必要があります（実際: "expert"）`},
+		},
+		{
+			name: "要素数が少ない",
+			json: strings.Replace(valid, `[{"text": "a"}]`, `[]`, 1),
+			want: []string{"$.items: 要素数は1以上である必要があります（実際: 0）"},
+		},
+		{
+			name: "要素数が多く、要素が違反している",
+			json: strings.Replace(valid, `[{"text": "a"}]`, `[{"text": "a"}, {"text": ""}, {}]`, 1),
+			want: []string{
+				"$.items: 要素数は2以下である必要があります（実際: 3）",
+				"$.items[1].text: 1文字以上である必要があります",
+				`$.items[2]: 必須プロパティ "text" がありません`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schema.Validate([]byte(tt.json)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%s) = %q, want %q", tt.json, got, tt.want)
+			}
+		})
+	}
+}
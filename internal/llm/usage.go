@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ErrQuotaExceeded ユーザーのLLM利用量が上限に達している
+var ErrQuotaExceeded = errors.New("LLMの利用上限に達しました")
+
+// QuotaExceededError 期間ごとの利用上限を超えた
+type QuotaExceededError struct {
+	Period  string    // 上限の期間（daily / monthly）
+	Limit   int       // 上限トークン数
+	Used    int       // 期間内の利用トークン数
+	ResetAt time.Time // 上限がリセットされる日時
+}
+
+func (e *QuotaExceededError) Error() string {
+	period := "1日"
+	if e.Period == QuotaPeriodMonthly {
+		period = "1か月"
+	}
+	return fmt.Sprintf("%sあたりのLLMの利用上限（%dトークン）に達しました（利用量: %dトークン、%sにリセットされます）",
+		period, e.Limit, e.Used, e.ResetAt.Format("2006-01-02 15:04"))
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// 利用上限の期間
+const (
+	QuotaPeriodDaily   = "daily"
+	QuotaPeriodMonthly = "monthly"
+)
+
+// Quota ユーザーごとのLLM利用上限（入力・出力トークンの合計。0は無制限）
+type Quota struct {
+	DailyTokens   int
+	MonthlyTokens int
+}
+
+// Price モデルごとの料金（100万トークンあたりのUSD）
+type Price struct {
+	InputPerMTok  float64 `json:"input"`
+	OutputPerMTok float64 `json:"output"`
+}
+
+// DefaultPrices 主なモデルの料金
+var DefaultPrices = map[string]Price{
+	"claude-3-7-sonnet": {InputPerMTok: 3, OutputPerMTok: 15},
+	"claude-sonnet-4":   {InputPerMTok: 3, OutputPerMTok: 15},
+	"claude-3-5-haiku":  {InputPerMTok: 0.8, OutputPerMTok: 4},
+	"claude-opus-4":     {InputPerMTok: 15, OutputPerMTok: 75},
+	FakeModel:           {},
+}
+
+// UsageRecord 1回のLLM呼び出しの利用量
+type UsageRecord struct {
+	UserID       string
+	Feature      string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+}
+
+// UsageStore LLM利用量の保存先
+type UsageStore interface {
+	// RecordUsage 利用量を記録する
+	RecordUsage(ctx context.Context, record *UsageRecord) error
+	// SumUserTokens 指定日時以降のユーザーの利用トークン数（入力・出力の合計）を返す
+	SumUserTokens(ctx context.Context, userID string, since time.Time) (int, error)
+}
+
+// QuotaChecker ユーザーがLLMを利用できるかどうかを確認する
+type QuotaChecker interface {
+	CheckQuota(ctx context.Context, userID string) error
+}
+
+// UsageMeter LLM利用量の記録・料金の見積もり・利用上限の確認を行う
+type UsageMeter struct {
+	store  UsageStore
+	prices map[string]Price
+	quota  Quota
+}
+
+// NewUsageMeter 料金表と利用上限を指定して作成する
+func NewUsageMeter(store UsageStore, prices map[string]Price, quota Quota) *UsageMeter {
+	return &UsageMeter{store: store, prices: prices, quota: quota}
+}
+
+// CheckQuota ユーザーの当日・当月の利用量が上限を超えていないか確認する
+func (m *UsageMeter) CheckQuota(ctx context.Context, userID string) error {
+	if userID == "" {
+		return nil
+	}
+
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	checks := []struct {
+		period  string
+		limit   int
+		since   time.Time
+		resetAt time.Time
+	}{
+		{QuotaPeriodDaily, m.quota.DailyTokens, dayStart, dayStart.AddDate(0, 0, 1)},
+		{QuotaPeriodMonthly, m.quota.MonthlyTokens, monthStart, monthStart.AddDate(0, 1, 0)},
+	}
+	for _, check := range checks {
+		if check.limit <= 0 {
+			continue
+		}
+		used, err := m.store.SumUserTokens(ctx, userID, check.since)
+		if err != nil {
+			return fmt.Errorf("LLM利用量の取得に失敗しました: %w", err)
+		}
+		if used >= check.limit {
+			return &QuotaExceededError{Period: check.period, Limit: check.limit, Used: used, ResetAt: check.resetAt}
+		}
+	}
+	return nil
+}
+
+// EstimateCost 料金表から利用料金（USD）を見積もる（料金表にないモデルは0）
+func (m *UsageMeter) EstimateCost(model string, inputTokens, outputTokens int) float64 {
+	price, ok := m.lookupPrice(model)
+	if !ok {
+		return 0
+	}
+	return (float64(inputTokens)*price.InputPerMTok + float64(outputTokens)*price.OutputPerMTok) / 1_000_000
+}
+
+// lookupPrice モデル名に一致する料金を返す（完全一致がなければ最長の前方一致）
+func (m *UsageMeter) lookupPrice(model string) (Price, bool) {
+	if price, ok := m.prices[model]; ok {
+		return price, true
+	}
+	var (
+		matched Price
+		longest int
+	)
+	for prefix, price := range m.prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > longest {
+			matched, longest = price, len(prefix)
+		}
+	}
+	return matched, longest > 0
+}
+
+// Record 応答の利用量を記録する
+func (m *UsageMeter) Record(ctx context.Context, req *Request, res *Response) error {
+	return m.store.RecordUsage(ctx, &UsageRecord{
+		UserID:       req.UserID,
+		Feature:      req.Feature,
+		Model:        res.Model,
+		InputTokens:  res.InputTokens,
+		OutputTokens: res.OutputTokens,
+		CostUSD:      m.EstimateCost(res.Model, res.InputTokens, res.OutputTokens),
+	})
+}
+
+// MeteredClient 呼び出し前に利用上限を確認し、呼び出し後に利用量を記録するLLMClient
+type MeteredClient struct {
+	next  LLMClient
+	meter *UsageMeter
+}
+
+// NewMeteredClient 利用量の記録先を指定してクライアントをラップする
+func NewMeteredClient(next LLMClient, meter *UsageMeter) *MeteredClient {
+	return &MeteredClient{next: next, meter: meter}
+}
+
+func (c *MeteredClient) Generate(ctx context.Context, req *Request) (*Response, error) {
+	if err := c.meter.CheckQuota(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	res, err := c.next.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// キャッシュから返した応答はLLMを呼び出していないため記録しない
+	if !res.Cached {
+		if err := c.meter.Record(ctx, req, res); err != nil {
+			log.Printf("Warning: LLM利用量の記録に失敗しました（機能: %s）: %v", req.Feature, err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// stubUsageStore 呼び出し順に指定した利用トークン数を返し、記録した利用量を保持するUsageStore
+type stubUsageStore struct {
+	sums    []int       // SumUserTokensが呼び出し順に返す利用トークン数
+	since   []time.Time // SumUserTokensに指定された日時
+	err     error
+	records []UsageRecord
+}
+
+func (s *stubUsageStore) RecordUsage(ctx context.Context, record *UsageRecord) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, *record)
+	return nil
+}
+
+func (s *stubUsageStore) SumUserTokens(ctx context.Context, userID string, since time.Time) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.since = append(s.since, since)
+	sum := s.sums[0]
+	s.sums = s.sums[1:]
+	return sum, nil
+}
+
+func TestUsageMeterCheckQuota(t *testing.T) {
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	tests := []struct {
+		name       string
+		quota      Quota
+		sums       []int // 当日・当月の利用トークン数（上限を確認した期間のみ。1日の上限を超えた場合は1か月の上限を確認しない）
+		wantPeriod string
+		wantUsed   int
+	}{
+		{name: "無制限", quota: Quota{}},
+		{name: "1日の上限未満", quota: Quota{DailyTokens: 100}, sums: []int{99}},
+		{name: "1日の上限ちょうど", quota: Quota{DailyTokens: 100}, sums: []int{100}, wantPeriod: QuotaPeriodDaily, wantUsed: 100},
+		{name: "1か月の上限未満", quota: Quota{MonthlyTokens: 1000}, sums: []int{999}},
+		{name: "1か月の上限を超える", quota: Quota{MonthlyTokens: 1000}, sums: []int{1200}, wantPeriod: QuotaPeriodMonthly, wantUsed: 1200},
+		{name: "両方の上限未満", quota: Quota{DailyTokens: 100, MonthlyTokens: 1000}, sums: []int{99, 999}},
+		{name: "1日の上限を先に確認する", quota: Quota{DailyTokens: 100, MonthlyTokens: 1000}, sums: []int{100}, wantPeriod: QuotaPeriodDaily, wantUsed: 100},
+		{name: "1日の上限未満で1か月の上限ちょうど", quota: Quota{DailyTokens: 100, MonthlyTokens: 1000}, sums: []int{50, 1000}, wantPeriod: QuotaPeriodMonthly, wantUsed: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &stubUsageStore{sums: tt.sums}
+			meter := NewUsageMeter(store, DefaultPrices, tt.quota)
+
+			err := meter.CheckQuota(context.Background(), "user-1")
+			if len(store.since) != len(tt.sums) {
+				t.Errorf("利用量の取得回数 = %d, want %d", len(store.since), len(tt.sums))
+			}
+			if tt.wantPeriod == "" {
+				if err != nil {
+					t.Errorf("CheckQuota = %v, want nil", err)
+				}
+				return
+			}
+
+			var quotaErr *QuotaExceededError
+			if !errors.As(err, &quotaErr) || !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("CheckQuota = %v, want *QuotaExceededError", err)
+			}
+			wantLimit, wantSince, wantReset := tt.quota.DailyTokens, dayStart, dayStart.AddDate(0, 0, 1)
+			if tt.wantPeriod == QuotaPeriodMonthly {
+				wantLimit, wantSince, wantReset = tt.quota.MonthlyTokens, monthStart, monthStart.AddDate(0, 1, 0)
+			}
+			if quotaErr.Period != tt.wantPeriod || quotaErr.Limit != wantLimit || quotaErr.Used != tt.wantUsed || !quotaErr.ResetAt.Equal(wantReset) {
+				t.Errorf("エラー = %+v, want %s・上限 %d・利用量 %d・リセット %v", quotaErr, tt.wantPeriod, wantLimit, tt.wantUsed, wantReset)
+			}
+			if since := store.since[len(store.since)-1]; !since.Equal(wantSince) {
+				t.Errorf("利用量を集計する期間の開始 = %v, want %v", since, wantSince)
+			}
+		})
+	}
+}
+
+func TestUsageMeterCheckQuotaWithoutUserOrStore(t *testing.T) {
+	// ユーザーを特定しない呼び出し（問題の作成など）は確認しない
+	meter := NewUsageMeter(&stubUsageStore{}, DefaultPrices, Quota{DailyTokens: 100})
+	if err := meter.CheckQuota(context.Background(), ""); err != nil {
+		t.Errorf("CheckQuota = %v, want nil", err)
+	}
+
+	storeErr := errors.New("接続できません")
+	meter = NewUsageMeter(&stubUsageStore{err: storeErr}, DefaultPrices, Quota{DailyTokens: 100})
+	if err := meter.CheckQuota(context.Background(), "user-1"); !errors.Is(err, storeErr) || errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("CheckQuota = %v, want 利用量の取得エラー", err)
+	}
+}
+
+func TestUsageMeterEstimateCost(t *testing.T) {
+	meter := NewUsageMeter(&stubUsageStore{}, map[string]Price{
+		"claude":          {InputPerMTok: 1, OutputPerMTok: 2},
+		"claude-opus-4":   {InputPerMTok: 15, OutputPerMTok: 75},
+		"claude-sonnet-4": {InputPerMTok: 3, OutputPerMTok: 15},
+		FakeModel:         {},
+	}, Quota{})
+
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{model: "claude-sonnet-4", want: 0.033},           // 完全一致: 1000 * 3 + 2000 * 15
+		{model: "claude-sonnet-4-20250514", want: 0.033},  // 前方一致
+		{model: "claude-opus-4-1-20250805", want: 0.165},  // 最長の前方一致: 1000 * 15 + 2000 * 75
+		{model: "claude-3-5-haiku-20241022", want: 0.005}, // 短い前方一致: 1000 * 1 + 2000 * 2
+		{model: FakeModel, want: 0},                       // 無料
+		{model: "gpt-4o", want: 0},                        // 料金表にないモデル
+		{model: "", want: 0},                              // モデル名なし
+	}
+
+	for _, tt := range tests {
+		if got := meter.EstimateCost(tt.model, 1000, 2000); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EstimateCost(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestMeteredClient(t *testing.T) {
+	fake := NewFakeClient()
+	fake.Script(FeatureTutor, "1234567890123456")
+	store := &stubUsageStore{sums: []int{0}}
+	client := NewMeteredClient(fake, NewUsageMeter(store, map[string]Price{FakeModel: {InputPerMTok: 1000, OutputPerMTok: 2000}}, Quota{DailyTokens: 100}))
+
+	req := NewUserRequest(FeatureTutor, FakeModel, 100, "12345678")
+	req.UserID = "user-1"
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	// フェイククライアントのトークン数は文字数の1/4（入力2・出力4）
+	want := UsageRecord{UserID: "user-1", Feature: FeatureTutor, Model: FakeModel, InputTokens: 2, OutputTokens: 4, CostUSD: 0.01}
+	if len(store.records) != 1 || store.records[0].UserID != want.UserID || store.records[0].Feature != want.Feature ||
+		store.records[0].Model != want.Model || store.records[0].InputTokens != want.InputTokens ||
+		store.records[0].OutputTokens != want.OutputTokens || math.Abs(store.records[0].CostUSD-want.CostUSD) > 1e-9 {
+		t.Errorf("記録 = %+v, want %+v", store.records, want)
+	}
+
+	// 上限に達している場合はLLMを呼び出さず、記録もしない
+	store.sums = []int{100}
+	if _, err := client.Generate(context.Background(), req); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("エラー = %v, want ErrQuotaExceeded", err)
+	}
+	if len(fake.Calls()) != 1 || len(store.records) != 1 {
+		t.Errorf("上限に達した後の呼び出し回数 = %d, 記録の数 = %d; want 1, 1", len(fake.Calls()), len(store.records))
+	}
+}
+
+// キャッシュから返した応答・失敗した呼び出しは記録せず、記録の失敗では呼び出しを失敗させない
+func TestMeteredClientSkipsRecording(t *testing.T) {
+	tests := []struct {
+		name     string
+		res      *Response
+		err      error
+		storeErr error
+	}{
+		{name: "キャッシュから返した応答", res: &Response{Text: "応答", Model: FakeModel, InputTokens: 10, Cached: true}},
+		{name: "失敗した呼び出し", err: errors.New("接続できません")},
+		{name: "記録の失敗", res: &Response{Text: "応答", Model: FakeModel, InputTokens: 10}, storeErr: errors.New("保存できません")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := clientFunc(func(ctx context.Context, req *Request) (*Response, error) {
+				return tt.res, tt.err
+			})
+			store := &stubUsageStore{err: tt.storeErr}
+			client := NewMeteredClient(next, NewUsageMeter(store, DefaultPrices, Quota{}))
+
+			res, err := client.Generate(context.Background(), NewUserRequest(FeatureTutor, FakeModel, 100, "質問"))
+			if !errors.Is(err, tt.err) || res != tt.res {
+				t.Errorf("Generate = %+v, %v; want %+v, %v", res, err, tt.res, tt.err)
+			}
+			if len(store.records) != 0 {
+				t.Errorf("記録 = %+v, want なし", store.records)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminConfig struct {
+	// AdminEmails 管理者として扱うメールアドレス
+	AdminEmails []string
+}
+
+// NewAdminMiddleware 管理者のみアクセスを許可するミドルウェア（認証ミドルウェアの後に使用する）
+func NewAdminMiddleware(config AdminConfig) gin.HandlerFunc {
+	admins := make(map[string]struct{}, len(config.AdminEmails))
+	for _, email := range config.AdminEmails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			admins[email] = struct{}{}
+		}
+	}
+
+	return func(c *gin.Context) {
+		email, _ := c.Get("email")
+		emailStr, _ := email.(string)
+		if _, ok := admins[strings.ToLower(emailStr)]; !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "管理者権限が必要です"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
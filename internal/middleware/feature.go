@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeatureConfig struct {
+	// Feature 機能名（レスポンスの feature に設定する）
+	Feature string
+	// Enabled 機能が有効かどうか
+	Enabled bool
+	// Reason 機能が無効な理由
+	Reason string
+}
+
+// NewFeatureMiddleware 機能が無効な場合に 503 Service Unavailable を返すミドルウェア
+// LLMのプロバイダが設定されていないなど、起動時に無効と判定した機能のエンドポイントに使用する
+func NewFeatureMiddleware(config FeatureConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Enabled {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "この機能は現在利用できません: " + config.Reason,
+				"code":    "LLM_UNAVAILABLE",
+				"feature": config.Feature,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+// 差分の操作
+const (
+	DiffOpEqual   = "equal"   // 変更なし
+	DiffOpInsert  = "insert"  // 追加
+	DiffOpDelete  = "delete"  // 削除
+	DiffOpReplace = "replace" // 置換
+)
+
+// 差分の種類（変更なし以外の操作に設定する）
+const (
+	DiffKindWord        = "word"        // 単語の変更
+	DiffKindPunctuation = "punctuation" // 句読点のみの変更
+	DiffKindCase        = "case"        // 大文字小文字のみの変更
+)
+
+// AnswerDiffOp 単語単位の差分の操作
+// 位置は比較元・比較先の文字列の先頭からの文字数（0始まり、終了位置の文字は含まない）
+type AnswerDiffOp struct {
+	Op        string `json:"op"`
+	Kind      string `json:"kind,omitempty"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	FromStart int    `json:"from_start"`
+	FromEnd   int    `json:"from_end"`
+	ToStart   int    `json:"to_start"`
+	ToEnd     int    `json:"to_end"`
+}
+
+// 同じ問題の2回の挑戦の解答を比較
+type CompareAnswersRequest struct {
+	ProjectID                string `json:"project_id" binding:"required"`
+	QuestionTemplateMasterID string `json:"question_template_master_id" binding:"required"`
+	FromChallengeCount       int    `json:"from_challenge_count" binding:"required,min=1"`
+	ToChallengeCount         int    `json:"to_challenge_count" binding:"required,min=1"`
+}
+
+// AnswerAttempt 比較する挑戦の解答と採点結果
+type AnswerAttempt struct {
+	CorrectionResultID string `json:"correction_result_id"`
+	ChallengeCount     int    `json:"challenge_count"`
+	UserAnswer         string `json:"user_answer"`
+	GetPoints          int    `json:"get_points"`
+	CorrectRate        int    `json:"correct_rate"`
+	Status             string `json:"status"`
+}
+
+type CompareAnswersResponse struct {
+	From AnswerAttempt  `json:"from"`
+	To   AnswerAttempt  `json:"to"`
+	Diff []AnswerDiffOp `json:"diff"`
+}
@@ -6,6 +6,21 @@ import (
 	"gorm.io/gorm"
 )
 
+// 事前チェックでLLMを呼ばずに採点した理由
+const (
+	PreCheckEmpty          = "empty"           // 解答が空
+	PreCheckTooShort       = "too_short"       // 解答が短すぎる
+	PreCheckNonEnglish     = "non_english"     // 解答の大部分が英語以外（ラテン文字以外の文字・英単語の一覧にない語）
+	PreCheckCopiedQuestion = "copied_question" // 問題文をそのまま解答している
+	PreCheckReferenceMatch = "reference_match" // 解答例と一致している
+)
+
+// 採点の方法
+const (
+	GradingModeStandard      = "standard"       // 通常の採点
+	GradingModeSecondOpinion = "second_opinion" // 前回の採点と申し立てを踏まえた厳格な再評価
+)
+
 type CorrectionResults struct {
 	ID                       string         `json:"id" gorm:"primaryKey;type:char(36)"`
 	QuestionAnswerID         string         `json:"question_answer_id" gorm:"type:char(36);not null"`
@@ -15,8 +30,19 @@ type CorrectionResults struct {
 	ExampleCorrection        string         `json:"example_correction" gorm:"type:text;null"`
 	CorrectRate              int            `json:"correct_rate" gorm:"type:int;null"`
 	Advice                   string         `json:"advice" gorm:"type:text;null"`
+	PromptVersion            string         `json:"prompt_version" gorm:"type:varchar(100);null"`
+	LLMModel                 string         `json:"llm_model" gorm:"type:varchar(100);null"`
+	LLMProvider              string         `json:"llm_provider" gorm:"type:varchar(50);null"`
+	PreCheck                 string         `json:"pre_check" gorm:"type:varchar(30);null"`
+	ConsensusSamples         int            `json:"consensus_samples" gorm:"type:int;null"`
+	ScoreVariance            float64        `json:"score_variance" gorm:"type:double;null"`
+	NeedsReview              bool           `json:"needs_review" gorm:"not null;default:false"`
 	Status                   string         `json:"status" gorm:"type:varchar(20);not null;default:PROCESSING"`
 	ChallengeCount           int            `json:"challenge_count" gorm:"type:int;not null;default:1"`
+	GradingVersion           int            `json:"grading_version" gorm:"type:int;not null;default:1"`
+	IsAuthoritative          bool           `json:"is_authoritative" gorm:"not null"`
+	GradingMode              string         `json:"grading_mode" gorm:"type:varchar(20);not null;default:standard"`
+	RegradeReason            string         `json:"regrade_reason" gorm:"type:text;null"`
 	CreatedBy                string         `json:"created_by" gorm:"type:char(36);not null"`
 	UpdatedBy                string         `json:"updated_by" gorm:"type:char(36);not null"`
 	DeletedBy                string         `json:"deleted_by" gorm:"type:char(36);null"`
@@ -34,8 +60,17 @@ type CorrectionResultsSummary struct {
 	ExampleCorrection        string                         `json:"example_correction"`
 	CorrectRate              int                            `json:"correct_rate"`
 	Advice                   string                         `json:"advice"`
+	PromptVersion            string                         `json:"prompt_version"`
+	LLMModel                 string                         `json:"llm_model"`
+	LLMProvider              string                         `json:"llm_provider"`
+	PreCheck                 string                         `json:"pre_check,omitempty"`
+	Consensus                *ConsensusSummary              `json:"consensus,omitempty"`
+	RubricScores             []RubricScoreSummary           `json:"rubric_scores"`
+	Errors                   []CorrectionErrorSummary       `json:"errors"`
+	Diff                     []AnswerDiffOp                 `json:"diff"` // 解答から模範解答への単語単位の差分
 	Status                   string                         `json:"status"`
 	ChallengeCount           int                            `json:"challenge_count"`
+	GradingVersion           int                            `json:"grading_version"`
 	QuestionAnswer           QuestionAnswersSummary         `json:"question_answer"`
 	QuestionTemplateMaster   QuestionTemplateMastersSummary `json:"question_template_master"`
 }
@@ -53,12 +88,19 @@ type CreateCorrectionResultRequest struct {
 }
 
 type UpdateCorrectionResultRequest struct {
-	ID                string `json:"id" binding:"required"`
-	GetPoints         int    `json:"get_points"`
-	ExampleCorrection string `json:"example_correction"`
-	CorrectRate       int    `json:"correct_rate"`
-	Advice            string `json:"advice"`
-	Status            string `json:"status"`
+	ID                string  `json:"id" binding:"required"`
+	GetPoints         int     `json:"get_points"`
+	ExampleCorrection string  `json:"example_correction"`
+	CorrectRate       int     `json:"correct_rate"`
+	Advice            string  `json:"advice"`
+	PromptVersion     string  `json:"prompt_version"`
+	LLMModel          string  `json:"llm_model"`
+	LLMProvider       string  `json:"llm_provider"`
+	PreCheck          string  `json:"pre_check"`
+	ConsensusSamples  int     `json:"consensus_samples"`
+	ScoreVariance     float64 `json:"score_variance"`
+	NeedsReview       bool    `json:"needs_review"`
+	Status            string  `json:"status"`
 }
 
 type CreateCorrectionResultResponse struct {
@@ -83,6 +125,9 @@ type UpdateCorrectionResultResponse struct {
 	ExampleCorrection        string `json:"example_correction"`
 	CorrectRate              int    `json:"correct_rate"`
 	Advice                   string `json:"advice"`
+	PromptVersion            string `json:"prompt_version"`
+	LLMModel                 string `json:"llm_model"`
+	LLMProvider              string `json:"llm_provider"`
 	Status                   string `json:"status"`
 	ChallengeCount           int    `json:"challenge_count"`
 }
@@ -92,16 +137,47 @@ type GrandCorrectResultRequest struct {
 }
 
 type GrandCorrectResultResponse struct {
-	ID                       string `json:"id"`
-	QuestionAnswerID         string `json:"question_answer_id"`
-	QuestionTemplateMasterID string `json:"question_template_master_id"`
-	ProjectID                string `json:"project_id"`
-	GetPoints                int    `json:"get_points"`
-	ExampleCorrection        string `json:"example_correction"`
-	CorrectRate              int    `json:"correct_rate"`
-	Advice                   string `json:"advice"`
-	Status                   string `json:"status"`
-	ChallengeCount           int    `json:"challenge_count"`
+	ID                       string                   `json:"id"`
+	QuestionAnswerID         string                   `json:"question_answer_id"`
+	QuestionTemplateMasterID string                   `json:"question_template_master_id"`
+	ProjectID                string                   `json:"project_id"`
+	GetPoints                int                      `json:"get_points"`
+	ExampleCorrection        string                   `json:"example_correction"`
+	CorrectRate              int                      `json:"correct_rate"`
+	Advice                   string                   `json:"advice"`
+	PromptVersion            string                   `json:"prompt_version"`
+	LLMModel                 string                   `json:"llm_model"`
+	LLMProvider              string                   `json:"llm_provider"`
+	PreCheck                 string                   `json:"pre_check,omitempty"`
+	Consensus                *ConsensusSummary        `json:"consensus,omitempty"`
+	RubricScores             []RubricScoreSummary     `json:"rubric_scores"`
+	Errors                   []CorrectionErrorSummary `json:"errors"`
+	Status                   string                   `json:"status"`
+	ChallengeCount           int                      `json:"challenge_count"`
+	GradingVersion           int                      `json:"grading_version"`
+	IsAuthoritative          bool                     `json:"is_authoritative"`
+}
+
+type GetCorrectResultStatusResponse struct {
+	ID                       string                   `json:"id"`
+	QuestionAnswerID         string                   `json:"question_answer_id"`
+	QuestionTemplateMasterID string                   `json:"question_template_master_id"`
+	ProjectID                string                   `json:"project_id"`
+	GetPoints                int                      `json:"get_points"`
+	ExampleCorrection        string                   `json:"example_correction"`
+	CorrectRate              int                      `json:"correct_rate"`
+	Advice                   string                   `json:"advice"`
+	PromptVersion            string                   `json:"prompt_version"`
+	LLMModel                 string                   `json:"llm_model"`
+	LLMProvider              string                   `json:"llm_provider"`
+	PreCheck                 string                   `json:"pre_check,omitempty"`
+	Consensus                *ConsensusSummary        `json:"consensus,omitempty"`
+	RubricScores             []RubricScoreSummary     `json:"rubric_scores"`
+	Errors                   []CorrectionErrorSummary `json:"errors"`
+	Status                   string                   `json:"status"`
+	ChallengeCount           int                      `json:"challenge_count"`
+	GradingVersion           int                      `json:"grading_version"`
+	IsAuthoritative          bool                     `json:"is_authoritative"`
 }
 
 type GetCorrectResultsRequest struct {
@@ -119,10 +195,126 @@ type GetCorrectResultsVersionRequest struct {
 }
 
 type VersionList struct {
-	ChallengeCount int `json:"challenge_count"`
-	CreatedAt time.Time `json:"created_at"`
+	ChallengeCount int       `json:"challenge_count"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type GetCorrectResultsVersionListResponse struct {
 	VersionList []VersionList `json:"version_list"`
-}
\ No newline at end of file
+}
+
+// 再採点の依頼
+type RegradeCorrectionResultRequest struct {
+	ID            string `json:"id" binding:"required"`              // 再採点する添削結果ID
+	Reason        string `json:"reason" binding:"required,max=1000"` // 採点結果に納得できない理由
+	SecondOpinion bool   `json:"second_opinion"`                     // 前回の採点と理由を踏まえた厳格な再評価を行うかどうか
+}
+
+type RegradeCorrectionResultResponse struct {
+	ID                       string `json:"id"`
+	QuestionAnswerID         string `json:"question_answer_id"`
+	QuestionTemplateMasterID string `json:"question_template_master_id"`
+	ProjectID                string `json:"project_id"`
+	GradingVersion           int    `json:"grading_version"`
+	GradingMode              string `json:"grading_mode"`
+	RegradeReason            string `json:"regrade_reason"`
+	Status                   string `json:"status"`
+	ChallengeCount           int    `json:"challenge_count"`
+}
+
+// 解答の採点履歴（再採点を含む、バージョンの古い順）
+type GradingHistoryEntry struct {
+	ID                string                   `json:"id"`
+	GradingVersion    int                      `json:"grading_version"`
+	IsAuthoritative   bool                     `json:"is_authoritative"`
+	GradingMode       string                   `json:"grading_mode"`
+	RegradeReason     string                   `json:"regrade_reason,omitempty"`
+	GetPoints         int                      `json:"get_points"`
+	ExampleCorrection string                   `json:"example_correction"`
+	CorrectRate       int                      `json:"correct_rate"`
+	Advice            string                   `json:"advice"`
+	PromptVersion     string                   `json:"prompt_version"`
+	LLMModel          string                   `json:"llm_model"`
+	LLMProvider       string                   `json:"llm_provider"`
+	PreCheck          string                   `json:"pre_check,omitempty"`
+	Consensus         *ConsensusSummary        `json:"consensus,omitempty"`
+	RubricScores      []RubricScoreSummary     `json:"rubric_scores"`
+	Errors            []CorrectionErrorSummary `json:"errors"`
+	Status            string                   `json:"status"`
+	CreatedAt         time.Time                `json:"created_at"`
+}
+
+type GetGradingHistoryResponse struct {
+	QuestionAnswerID string                `json:"question_answer_id"`
+	ChallengeCount   int                   `json:"challenge_count"`
+	History          []GradingHistoryEntry `json:"history"`
+}
+
+// 合議採点（同じ解答を複数回採点して結果をまとめる）の結果
+type ConsensusSummary struct {
+	Samples       int     `json:"samples"`        // 結果をまとめた採点の回数（失敗した採点を除く）
+	ScoreVariance float64 `json:"score_variance"` // 採点ごとの正答率の分散
+	NeedsReview   bool    `json:"needs_review"`   // 採点のばらつきが大きく、人による確認が必要かどうか
+}
+
+// Consensus 合議採点の結果（合議採点を行っていない場合はnil）
+func (r *CorrectionResults) Consensus() *ConsensusSummary {
+	if r.ConsensusSamples == 0 {
+		return nil
+	}
+	return &ConsensusSummary{
+		Samples:       r.ConsensusSamples,
+		ScoreVariance: r.ScoreVariance,
+		NeedsReview:   r.NeedsReview,
+	}
+}
+
+// 人による確認が必要な添削結果（作成日時の新しい順）
+type ReviewCorrectionResult struct {
+	ID                       string            `json:"id"`
+	QuestionAnswerID         string            `json:"question_answer_id"`
+	QuestionTemplateMasterID string            `json:"question_template_master_id"`
+	ProjectID                string            `json:"project_id"`
+	UserAnswer               string            `json:"user_answer"`
+	GetPoints                int               `json:"get_points"`
+	CorrectRate              int               `json:"correct_rate"`
+	ExampleCorrection        string            `json:"example_correction"`
+	LLMModel                 string            `json:"llm_model"`
+	Consensus                *ConsensusSummary `json:"consensus"`
+	CreatedAt                time.Time         `json:"created_at"`
+}
+
+type GetReviewCorrectionResultsResponse struct {
+	CorrectResults []ReviewCorrectionResult `json:"correct_results"`
+}
+
+// 人による確認が済んだ添削結果の要確認を解除する
+type ResolveReviewRequest struct {
+	ID string `json:"id" binding:"required"`
+}
+
+// 挑戦の一括採点での解答ごとの結果
+type ChallengeGradingItem struct {
+	QuestionAnswerID         string `json:"question_answer_id"`
+	QuestionTemplateMasterID string `json:"question_template_master_id"`
+	CorrectionResultID       string `json:"correction_result_id"`
+	Status                   string `json:"status"` // COMPLETED / FAILED / PROCESSING（別の採点が処理中、または中断してワーカーに引き継いだ）
+	GetPoints                int    `json:"get_points"`
+	MaxPoints                int    `json:"max_points"`
+	CorrectRate              int    `json:"correct_rate"`
+	Graded                   bool   `json:"graded"` // 一括採点で採点したかどうか（採点済みの解答はfalse）
+	Error                    string `json:"error,omitempty"`
+}
+
+// 挑戦全体の採点結果
+type ChallengeGradingSummary struct {
+	ProjectID      string                 `json:"project_id"`
+	ChallengeCount int                    `json:"challenge_count"`
+	TotalPoints    int                    `json:"total_points"` // 採点が完了した解答の得点の合計
+	MaxPoints      int                    `json:"max_points"`   // すべての解答の配点の合計
+	CorrectRate    int                    `json:"correct_rate"` // 配点の合計に対する得点の合計の割合（0-100）
+	Completed      int                    `json:"completed"`
+	Failed         int                    `json:"failed"`
+	Processing     int                    `json:"processing"`
+	Results        []ChallengeGradingItem `json:"results"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// 誤りの種類
+const (
+	CorrectionErrorTypeArticle     = "article"
+	CorrectionErrorTypeTense       = "tense"
+	CorrectionErrorTypePreposition = "preposition"
+	CorrectionErrorTypeWordOrder   = "word_order"
+	CorrectionErrorTypeSpelling    = "spelling"
+	CorrectionErrorTypeAgreement   = "agreement"
+	CorrectionErrorTypeWordChoice  = "word_choice"
+	CorrectionErrorTypePunctuation = "punctuation"
+	CorrectionErrorTypeOther       = "other"
+)
+
+// CorrectionErrorTypes 誤りの種類の一覧
+var CorrectionErrorTypes = []string{
+	CorrectionErrorTypeArticle,
+	CorrectionErrorTypeTense,
+	CorrectionErrorTypePreposition,
+	CorrectionErrorTypeWordOrder,
+	CorrectionErrorTypeSpelling,
+	CorrectionErrorTypeAgreement,
+	CorrectionErrorTypeWordChoice,
+	CorrectionErrorTypePunctuation,
+	CorrectionErrorTypeOther,
+}
+
+// CorrectionErrors は添削結果で指摘した解答中の誤り（箇所・修正案・種類・説明）を保存するテーブル
+type CorrectionErrors struct {
+	ID                 string    `json:"id" gorm:"primaryKey;type:char(36)"`                 // レコードの一意識別子
+	CorrectionResultID string    `json:"correction_result_id" gorm:"type:char(36);not null"` // 添削結果ID
+	StartOffset        int       `json:"start_offset" gorm:"type:int;not null"`              // 解答中の誤りの開始位置（文字単位、0始まり）
+	EndOffset          int       `json:"end_offset" gorm:"type:int;not null"`                // 解答中の誤りの終了位置（文字単位、この位置の文字を含まない）
+	OriginalText       string    `json:"original_text" gorm:"type:text;not null"`            // 誤りのある元の文字列
+	Suggestion         string    `json:"suggestion" gorm:"type:text;not null"`               // 修正案（削除すべき場合は空文字）
+	ErrorType          string    `json:"error_type" gorm:"type:varchar(30);not null"`        // 誤りの種類（CorrectionErrorType* 定数）
+	Explanation        string    `json:"explanation" gorm:"type:text;not null"`              // 誤りの説明
+	CreatedAt          time.Time `json:"created_at" gorm:"not null"`                         // レコード作成日時
+	UpdatedAt          time.Time `json:"updated_at" gorm:"not null"`                         // レコード最終更新日時
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (CorrectionErrors) TableName() string {
+	return "correction_errors"
+}
+
+// CorrectionErrorSummary はレスポンスに含める解答中の誤り
+type CorrectionErrorSummary struct {
+	StartOffset  int    `json:"start_offset"`
+	EndOffset    int    `json:"end_offset"`
+	OriginalText string `json:"original_text"`
+	Suggestion   string `json:"suggestion"`
+	ErrorType    string `json:"error_type"`
+	Explanation  string `json:"explanation"`
+}
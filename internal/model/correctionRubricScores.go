@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// 採点ルーブリックの評価観点
+const (
+	RubricCriterionGrammar        = "grammar"
+	RubricCriterionVocabulary     = "vocabulary"
+	RubricCriterionTaskFulfilment = "task_fulfilment"
+	RubricCriterionNaturalness    = "naturalness"
+	RubricCriterionSpelling       = "spelling"
+)
+
+// CorrectionRubricScores は添削結果の評価観点ごとの得点を保存する correction_results の子テーブル
+type CorrectionRubricScores struct {
+	ID                 string    `json:"id" gorm:"primaryKey;type:char(36)"`                 // レコードの一意識別子
+	CorrectionResultID string    `json:"correction_result_id" gorm:"type:char(36);not null"` // 添削結果ID
+	Criterion          string    `json:"criterion" gorm:"type:varchar(50);not null"`         // 評価観点（RubricCriterion* 定数）
+	Score              int       `json:"score" gorm:"type:int;not null;default:0"`           // 評価観点の得点（0-100）
+	Weight             int       `json:"weight" gorm:"type:int;not null;default:0"`          // 採点時の評価観点の重み（%）
+	Rationale          string    `json:"rationale" gorm:"type:text;not null"`                // 得点の根拠
+	CreatedAt          time.Time `json:"created_at" gorm:"not null"`                         // レコード作成日時
+	UpdatedAt          time.Time `json:"updated_at" gorm:"not null"`                         // レコード最終更新日時
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (CorrectionRubricScores) TableName() string {
+	return "correction_rubric_scores"
+}
+
+// RubricScoreSummary はレスポンスに含める評価観点ごとの得点
+type RubricScoreSummary struct {
+	Criterion string `json:"criterion"`
+	Score     int    `json:"score"`
+	Weight    int    `json:"weight"`
+	Rationale string `json:"rationale"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// 採点時に検出した内容の種類
+const (
+	GradingFlagInjectionSuspected = "injection_suspected" // 解答に採点への指示のような記述が含まれている
+	GradingFlagInconsistentGrade  = "inconsistent_grade"  // 採点結果が解答の特徴（語数・模範解答との一致度など）と矛盾している
+)
+
+// GradingFlags は採点時に検出したプロンプトインジェクションの疑いや矛盾する採点結果を保存するテーブル
+type GradingFlags struct {
+	ID                 string    `json:"id" gorm:"primaryKey;type:char(36)"`                 // レコードの一意識別子
+	CorrectionResultID string    `json:"correction_result_id" gorm:"type:char(36);not null"` // 添削結果ID
+	UserID             string    `json:"user_id" gorm:"type:char(36);not null"`              // 解答したユーザーID
+	FlagType           string    `json:"flag_type" gorm:"type:varchar(30);not null"`         // 検出した内容の種類（GradingFlag* 定数）
+	Details            string    `json:"details" gorm:"type:text;not null"`                  // 検出した内容の詳細（改行区切り）
+	CreatedAt          time.Time `json:"created_at" gorm:"not null"`                         // レコード作成日時
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (GradingFlags) TableName() string {
+	return "grading_flags"
+}
+
+// GradingFlagSummary はレスポンスに含める採点時の検出内容
+type GradingFlagSummary struct {
+	ID                 string    `json:"id"`
+	CorrectionResultID string    `json:"correction_result_id"`
+	UserID             string    `json:"user_id"`
+	FlagType           string    `json:"flag_type"`
+	Details            []string  `json:"details"`
+	Status             string    `json:"status"` // 添削結果のステータス（矛盾が解消されなかった採点はFAILED）
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+type GetGradingFlagsResponse struct {
+	Flags []GradingFlagSummary `json:"flags"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// LLMCall はLLM呼び出しごとのプロンプト（学習者の入力を伏せたもの）・出力・解析結果・所要時間を記録するテーブル
+type LLMCall struct {
+	ID             string    `json:"id" gorm:"primaryKey;type:char(36)"`               // レコードの一意識別子
+	UserID         string    `json:"user_id" gorm:"type:char(36);not null"`            // 呼び出し元のユーザーID
+	Feature        string    `json:"feature" gorm:"type:varchar(50);not null"`         // 呼び出し元の機能
+	SubjectID      string    `json:"subject_id" gorm:"type:char(36);not null"`         // 呼び出しに関連する添削結果・弱点分析のID
+	LLMModel       string    `json:"llm_model" gorm:"type:varchar(100);not null"`      // 使用したモデル
+	Provider       string    `json:"provider" gorm:"type:varchar(50);not null"`        // 応答したプロバイダ
+	PromptVersion  string    `json:"prompt_version" gorm:"type:varchar(100);not null"` // 使用したプロンプトのバージョン
+	Attempt        int       `json:"attempt" gorm:"type:int;not null;default:1"`       // 構造化出力の修正依頼を含めた試行回数
+	RedactedPrompt string    `json:"redacted_prompt" gorm:"type:mediumtext;not null"`  // 学習者の入力を伏せたプロンプト
+	RawResponse    string    `json:"raw_response" gorm:"type:mediumtext;not null"`     // LLMの出力テキスト
+	Outcome        string    `json:"outcome" gorm:"type:varchar(30);not null"`         // 出力の解析結果（llm.CallOutcome* 定数）
+	ErrorMessage   *string   `json:"error_message" gorm:"type:text"`                   // 呼び出しが失敗した場合のエラー
+	InputTokens    int       `json:"input_tokens" gorm:"type:int;not null;default:0"`  // 入力トークン数
+	OutputTokens   int       `json:"output_tokens" gorm:"type:int;not null;default:0"` // 出力トークン数
+	LatencyMs      int       `json:"latency_ms" gorm:"type:int;not null;default:0"`    // 所要時間（ミリ秒）
+	Cached         bool      `json:"cached" gorm:"not null"`                           // キャッシュから返した応答かどうか
+	CreatedAt      time.Time `json:"created_at" gorm:"not null"`                       // レコード作成日時
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (LLMCall) TableName() string {
+	return "llm_calls"
+}
+
+// GetLLMCallsRequest はLLM呼び出しの記録の検索条件
+type GetLLMCallsRequest struct {
+	Feature   string `form:"feature"`    // 呼び出し元の機能
+	UserID    string `form:"user_id"`    // 呼び出し元のユーザーID
+	SubjectID string `form:"subject_id"` // 関連する添削結果・弱点分析のID
+	Outcome   string `form:"outcome"`    // 出力の解析結果
+	Limit     int    `form:"limit"`      // 取得件数（省略時は50、最大200）
+}
+
+// GetLLMCallsResponse はLLM呼び出しの記録の検索結果（新しい順）
+type GetLLMCallsResponse struct {
+	Calls         []LLMCall `json:"calls"`
+	RetentionDays int       `json:"retention_days"` // 記録の保存期間（日）
+}
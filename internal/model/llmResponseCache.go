@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// LLMResponseCache は同一のリクエストに対するLLMの応答を再利用するためのキャッシュテーブル
+type LLMResponseCache struct {
+	CacheKey      string    `json:"cache_key" gorm:"primaryKey;type:char(64)"`        // リクエスト内容のSHA-256ハッシュ
+	Feature       string    `json:"feature" gorm:"type:varchar(50);not null"`         // 応答を生成した機能
+	LLMModel      string    `json:"llm_model" gorm:"type:varchar(100);not null"`      // 応答を生成したモデル
+	Provider      string    `json:"provider" gorm:"type:varchar(50);not null"`        // 応答を生成したプロバイダ
+	PromptVersion string    `json:"prompt_version" gorm:"type:varchar(100);not null"` // 使用したプロンプトのバージョン
+	ResponseText  string    `json:"response_text" gorm:"type:mediumtext;not null"`    // LLMの応答テキスト
+	InputTokens   int       `json:"input_tokens" gorm:"type:int;not null;default:0"`  // 生成時の入力トークン数
+	OutputTokens  int       `json:"output_tokens" gorm:"type:int;not null;default:0"` // 生成時の出力トークン数
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null;index"`                 // 有効期限
+	CreatedAt     time.Time `json:"created_at" gorm:"not null;index"`                 // レコード作成日時
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (LLMResponseCache) TableName() string {
+	return "llm_response_cache"
+}
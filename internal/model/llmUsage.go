@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// LLMUsage はLLM呼び出しごとのトークン数と見積もり料金を記録するテーブル
+type LLMUsage struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:char(36)"`               // レコードの一意識別子
+	UserID       string    `json:"user_id" gorm:"type:char(36);not null"`            // 呼び出し元のユーザーID
+	Feature      string    `json:"feature" gorm:"type:varchar(50);not null"`         // 呼び出し元の機能
+	LLMModel     string    `json:"llm_model" gorm:"type:varchar(100);not null"`      // 使用したモデル
+	InputTokens  int       `json:"input_tokens" gorm:"type:int;not null;default:0"`  // 入力トークン数
+	OutputTokens int       `json:"output_tokens" gorm:"type:int;not null;default:0"` // 出力トークン数
+	CostUSD      float64   `json:"cost_usd" gorm:"type:decimal(12,6);not null"`      // 見積もり料金（USD）
+	CreatedAt    time.Time `json:"created_at" gorm:"not null"`                       // レコード作成日時
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (LLMUsage) TableName() string {
+	return "llm_usages"
+}
+
+// LLM利用量の集計単位
+const (
+	LLMUsageGroupByUser    = "user"
+	LLMUsageGroupByFeature = "feature"
+	LLMUsageGroupByModel   = "model"
+)
+
+// GetLLMUsageSummaryRequest はLLM利用量の集計条件
+type GetLLMUsageSummaryRequest struct {
+	From    string `form:"from"`     // 集計開始日（YYYY-MM-DD、省略時は当月1日）
+	To      string `form:"to"`       // 集計終了日（YYYY-MM-DD、この日を含む。省略時は当日）
+	GroupBy string `form:"group_by"` // 集計単位（user / feature / model、省略時はuser）
+	UserID  string `form:"user_id"`  // 指定した場合はそのユーザーのみ集計する
+}
+
+// LLMUsageSummaryRow は集計単位ごとのLLM利用量
+type LLMUsageSummaryRow struct {
+	Key          string  `json:"key"`           // 集計単位の値（ユーザーID・機能・モデル）
+	Calls        int     `json:"calls"`         // 呼び出し回数
+	InputTokens  int     `json:"input_tokens"`  // 入力トークン数
+	OutputTokens int     `json:"output_tokens"` // 出力トークン数
+	CostUSD      float64 `json:"cost_usd"`      // 見積もり料金（USD）
+}
+
+// GetLLMUsageSummaryResponse はLLM利用量の集計結果
+type GetLLMUsageSummaryResponse struct {
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	GroupBy string               `json:"group_by"`
+	Rows    []LLMUsageSummaryRow `json:"rows"`
+	Total   LLMUsageSummaryRow   `json:"total"`
+}
@@ -60,4 +60,8 @@ type UpdateQuestionAnswersFinishResponse struct {
 type GetProjectQuestionToAnswerResponse struct {
 	Question QuestionTemplateMastersSummary `json:"question"`
 	NowQuestionNumber int `json:"now_question_number"`
-}
\ No newline at end of file
+}
+type FinishAndGradeQuestionAnswersResponse struct {
+	QuestionAnswers []QuestionAnswers        `json:"question_answers"`
+	Grading         *ChallengeGradingSummary `json:"grading"`
+}
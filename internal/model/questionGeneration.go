@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// 質問テンプレートのステータス
+const (
+	QuestionTemplateStatusActive   = "ACTIVE"   // 出題できる
+	QuestionTemplateStatusDraft    = "DRAFT"    // LLMで作成し、管理者の承認を待っている（出題しない）
+	QuestionTemplateStatusRejected = "REJECTED" // 管理者が却下した（出題しない）
+)
+
+// GenerateQuestionTemplatesRequest はLLMによる質問テンプレートの作成のリクエスト
+type GenerateQuestionTemplatesRequest struct {
+	CategoryID   string `json:"category_id" binding:"required"`
+	Level        string `json:"level" binding:"required,oneof=basic inter adv"`
+	QuestionType string `json:"question_type" binding:"required,oneof=essay translate fill"`
+	Count        int    `json:"count" binding:"omitempty,min=1,max=10"` // 作成する問題数（省略時は3）
+}
+
+// GenerateQuestionTemplatesResponse はLLMで作成した下書きの質問テンプレート
+type GenerateQuestionTemplatesResponse struct {
+	QuestionTemplates []QuestionTemplateDraftSummary `json:"question_templates"`
+	PromptVersion     string                         `json:"prompt_version"`
+	LLMModel          string                         `json:"llm_model"`
+}
+
+// QuestionTemplateDraftSummary は承認待ちの質問テンプレートと、作成時に登録した解答例
+type QuestionTemplateDraftSummary struct {
+	QuestionTemplateMastersSummary
+	ReferenceAnswers []QuestionReferenceAnswersSummary `json:"reference_answers"`
+	CreatedBy        string                            `json:"created_by"`
+	CreatedAt        time.Time                         `json:"created_at"`
+}
+
+// GetDraftQuestionTemplatesResponse は承認待ちの質問テンプレートの一覧（新しい順）
+type GetDraftQuestionTemplatesResponse struct {
+	QuestionTemplates []QuestionTemplateDraftSummary `json:"question_templates"`
+}
+
+// ReviewQuestionTemplateRequest は承認待ちの質問テンプレートの承認・却下のリクエスト
+type ReviewQuestionTemplateRequest struct {
+	ID string `json:"id" binding:"required"`
+}
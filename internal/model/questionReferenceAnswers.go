@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 解答例の文体
+const (
+	ReferenceRegisterFormal = "formal"
+	ReferenceRegisterCasual = "casual"
+)
+
+// 解答例の英語の種類
+const (
+	ReferenceVarietyUS = "US"
+	ReferenceVarietyUK = "UK"
+)
+
+// QuestionReferenceAnswers 質問テンプレートごとに正解として認める解答例
+type QuestionReferenceAnswers struct {
+	ID                       string         `json:"id" gorm:"primaryKey;type:char(36)"`
+	QuestionTemplateMasterID string         `json:"question_template_master_id" gorm:"type:char(36);not null"`
+	Answer                   string         `json:"answer" gorm:"type:text;not null"`
+	Register                 string         `json:"register" gorm:"type:varchar(10);not null"`
+	Variety                  string         `json:"variety" gorm:"type:varchar(10);not null"`
+	CreatedBy                string         `json:"created_by" gorm:"type:char(36);not null"`
+	UpdatedBy                string         `json:"updated_by" gorm:"type:char(36);not null"`
+	DeletedBy                string         `json:"deleted_by" gorm:"type:char(36)"`
+	CreatedAt                time.Time      `json:"created_at" gorm:"not null"`
+	UpdatedAt                time.Time      `json:"updated_at" gorm:"not null"`
+	DeletedAt                gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// QuestionReferenceAnswersSummary 解答例の要約情報
+type QuestionReferenceAnswersSummary struct {
+	ID                       string    `json:"id"`
+	QuestionTemplateMasterID string    `json:"question_template_master_id"`
+	Answer                   string    `json:"answer"`
+	Register                 string    `json:"register"`
+	Variety                  string    `json:"variety"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+}
+
+// 解答例の作成
+type CreateQuestionReferenceAnswerRequest struct {
+	QuestionTemplateMasterID string `json:"question_template_master_id" binding:"required"`
+	Answer                   string `json:"answer" binding:"required"`
+	Register                 string `json:"register" binding:"required,oneof=formal casual"`
+	Variety                  string `json:"variety" binding:"required,oneof=US UK"`
+}
+
+// 解答例の一覧取得
+type GetQuestionReferenceAnswersResponse struct {
+	ReferenceAnswers []QuestionReferenceAnswersSummary `json:"reference_answers"`
+}
+
+// 解答例の更新
+type UpdateQuestionReferenceAnswerRequest struct {
+	ID       string `json:"id" binding:"required"`
+	Answer   string `json:"answer" binding:"required"`
+	Register string `json:"register" binding:"required,oneof=formal casual"`
+	Variety  string `json:"variety" binding:"required,oneof=US UK"`
+}
+
+// 解答例の削除
+type DeleteQuestionReferenceAnswerRequest struct {
+	ID string `json:"id" binding:"required"`
+}
+
+type DeleteQuestionReferenceAnswerResponse struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// チューターとの対話のメッセージの発話者
+const (
+	TutorRoleUser      = "user"      // 学習者の質問
+	TutorRoleAssistant = "assistant" // チューターの回答
+)
+
+// TutorMessages はチューターとの対話のメッセージ（学習者の質問とチューターの回答）を保存するテーブル
+type TutorMessages struct {
+	ID            string    `json:"id" gorm:"primaryKey;type:char(36)"`               // レコードの一意識別子
+	ThreadID      string    `json:"thread_id" gorm:"type:char(36);not null"`          // スレッドID
+	UserID        string    `json:"user_id" gorm:"type:char(36);not null"`            // スレッドのユーザーID
+	MessageIndex  int       `json:"message_index" gorm:"type:int;not null"`           // スレッド内のメッセージの順番（1始まり）
+	Role          string    `json:"role" gorm:"type:varchar(20);not null"`            // 発話者（TutorRole* 定数）
+	Content       string    `json:"content" gorm:"type:text;not null"`                // メッセージ本文
+	LLMModel      string    `json:"llm_model" gorm:"type:varchar(100);null"`          // 回答したモデル（チューターの回答のみ）
+	PromptVersion string    `json:"prompt_version" gorm:"type:varchar(100);null"`     // 使用したプロンプトのバージョン（チューターの回答のみ）
+	InputTokens   int       `json:"input_tokens" gorm:"type:int;not null;default:0"`  // 入力トークン数（チューターの回答のみ）
+	OutputTokens  int       `json:"output_tokens" gorm:"type:int;not null;default:0"` // 出力トークン数（チューターの回答のみ）
+	CreatedAt     time.Time `json:"created_at" gorm:"not null"`                       // レコード作成日時
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (TutorMessages) TableName() string {
+	return "tutor_messages"
+}
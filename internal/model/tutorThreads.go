@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// TutorThreads は添削結果ごとにチューター（LLM）と対話するスレッドを管理するテーブル
+type TutorThreads struct {
+	ID                 string    `json:"id" gorm:"primaryKey;type:char(36)"`                 // レコードの一意識別子
+	CorrectionResultID string    `json:"correction_result_id" gorm:"type:char(36);not null"` // 対話の対象となる添削結果ID
+	UserID             string    `json:"user_id" gorm:"type:char(36);not null"`              // 質問するユーザーID
+	CreatedAt          time.Time `json:"created_at" gorm:"not null"`                         // レコード作成日時
+	UpdatedAt          time.Time `json:"updated_at" gorm:"not null"`                         // レコード更新日時（最後のメッセージの日時）
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (TutorThreads) TableName() string {
+	return "tutor_threads"
+}
+
+// PostTutorMessageRequest はチューターへの質問のリクエスト
+type PostTutorMessageRequest struct {
+	CorrectionResultID string `json:"correction_result_id" binding:"required"` // 質問の対象となる添削結果ID
+	Content            string `json:"content" binding:"required"`              // 質問の本文
+}
+
+// PostTutorMessageResponse はチューターへの質問とその回答
+type PostTutorMessageResponse struct {
+	ThreadID string              `json:"thread_id"`
+	Question TutorMessageSummary `json:"question"`
+	Answer   TutorMessageSummary `json:"answer"`
+	Limits   TutorLimitSummary   `json:"limits"`
+}
+
+// GetTutorThreadResponse は添削結果に対するチューターとの対話の履歴（古い順）
+type GetTutorThreadResponse struct {
+	ThreadID           string                `json:"thread_id"` // まだ質問していない場合は空
+	CorrectionResultID string                `json:"correction_result_id"`
+	Messages           []TutorMessageSummary `json:"messages"`
+	Limits             TutorLimitSummary     `json:"limits"`
+}
+
+// TutorMessageSummary はレスポンスに含めるチューターとの対話のメッセージ
+type TutorMessageSummary struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"` // user: 学習者, assistant: チューター
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TutorLimitSummary はチューターへの質問数の上限と残り回数（上限が0の場合は無制限で、残り回数は-1）
+type TutorLimitSummary struct {
+	DailyLimit      int `json:"daily_limit"`      // 1日あたりの質問数の上限
+	DailyRemaining  int `json:"daily_remaining"`  // 今日の残りの質問数
+	ThreadLimit     int `json:"thread_limit"`     // 1つのスレッドの質問数の上限
+	ThreadRemaining int `json:"thread_remaining"` // スレッドの残りの質問数
+}
@@ -6,6 +6,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// 弱点分析の処理ステージ
+// バックグラウンドで実行される分析が現在どの段階にあるかを表す
+const (
+	AnalysisStageQueued   = "QUEUED"   // 実行待ち
+	AnalysisStageCategory = "CATEGORY" // カテゴリ分析中
+	AnalysisStageDetailed = "DETAILED" // 詳細分析中
+	AnalysisStageAdvice   = "ADVICE"   // 学習アドバイス生成中
+	AnalysisStageScoring  = "SCORING"  // 総合スコア算出中
+	AnalysisStageDone     = "DONE"     // 全ステージ完了
+)
+
 // WeaknessAnalysis はプロジェクト内でのユーザーの学習弱点分析結果のメインエンティティ
 // LLM（Claude API）を使用した分析の基本情報とメタデータを管理する
 type WeaknessAnalysis struct {
@@ -16,6 +27,8 @@ type WeaknessAnalysis struct {
 
 	// LLM分析結果の基本情報
 	AnalysisStatus  string `json:"analysis_status" gorm:"type:varchar(20);not null;default:'PROCESSING'"` // 分析処理状況（PROCESSING: 処理中, COMPLETED: 完了, FAILED: 失敗）
+	AnalysisStage   string `json:"analysis_stage" gorm:"type:varchar(20);not null;default:'QUEUED'"`      // 分析処理の現在のステージ（AnalysisStage* 定数）
+	Progress        int    `json:"progress" gorm:"type:int;not null;default:0"`                           // 分析処理の進捗率（0-100）
 	OverallScore    int    `json:"overall_score" gorm:"type:int;default:0"`                               // LLMが算出した総合学習スコア（0-100点）
 	ImprovementRate int    `json:"improvement_rate" gorm:"type:int;default:0"`                            // 前回分析からの改善率（パーセンテージ、-100〜+100）
 
@@ -24,8 +37,9 @@ type WeaknessAnalysis struct {
 	AnalyzedAnswers int       `json:"analyzed_answers" gorm:"type:int;not null;default:0"` // 分析対象となった回答数
 	DataPeriodStart time.Time `json:"data_period_start" gorm:"not null"`                   // 分析対象データの期間開始日
 	DataPeriodEnd   time.Time `json:"data_period_end" gorm:"not null"`                     // 分析対象データの期間終了日
-	LLMModel        string    `json:"llm_model" gorm:"type:varchar(50);not null"`          // 使用したLLMモデル名（例: claude-3-sonnet-20240229）
-	AnalysisVersion string    `json:"analysis_version" gorm:"type:varchar(10);not null"`   // 分析ロジックのバージョン（プロンプトや処理の変更管理用）
+	LLMModel        string    `json:"llm_model" gorm:"type:varchar(255);not null"`         // 分析に使用したLLMモデル名（複数の場合はカンマ区切り）
+	LLMProvider     string    `json:"llm_provider" gorm:"type:varchar(255);null"`          // 分析に応答したLLMプロバイダ（複数の場合はカンマ区切り）
+	AnalysisVersion string    `json:"analysis_version" gorm:"type:varchar(255);not null"`  // 分析に使用したプロンプトのバージョン（<プロンプト名>@<バージョン>のカンマ区切り）
 
 	// 標準的なデータベース管理フィールド
 	CreatedAt time.Time      `json:"created_at" gorm:"not null"`               // レコード作成日時
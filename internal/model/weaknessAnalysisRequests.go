@@ -21,6 +21,8 @@ type CreateWeaknessAnalysisResponse struct {
 	ID             string `json:"id"`              // 作成された分析レコードのID
 	ProjectID      string `json:"project_id"`      // 分析対象プロジェクトのID
 	AnalysisStatus string `json:"analysis_status"` // 分析処理の初期状態（通常は"PROCESSING"）
+	AnalysisStage  string `json:"analysis_stage"`  // 分析処理の現在のステージ
+	Progress       int    `json:"progress"`        // 分析処理の進捗率（0-100）
 	OverallScore   int    `json:"overall_score"`   // 総合学習スコア（0-100）
 }
 
@@ -95,9 +97,13 @@ type WeaknessAnalysisAllSummary struct {
 }
 
 // WeaknessAnalysisStatusSummary は分析状況のサマリーを表す構造体
+// フロントエンドはこの情報をポーリングして進捗を表示する
 type WeaknessAnalysisStatusSummary struct {
-	ID string `json:"id"` // 分析結果のID
-	ProjectID string `json:"project_id"` // 分析対象プロジェクトのID
-	UserID string `json:"user_id"` // 分析対象ユーザーのID
-	AnalysisStatus string `json:"analysis_status"` // 分析処理状況
+	ID             string    `json:"id"`              // 分析結果のID
+	ProjectID      string    `json:"project_id"`      // 分析対象プロジェクトのID
+	UserID         string    `json:"user_id"`         // 分析対象ユーザーのID
+	AnalysisStatus string    `json:"analysis_status"` // 分析処理状況
+	AnalysisStage  string    `json:"analysis_stage"`  // 分析処理の現在のステージ
+	Progress       int       `json:"progress"`        // 分析処理の進捗率（0-100）
+	UpdatedAt      time.Time `json:"updated_at"`      // 進捗の最終更新日時
 }
@@ -0,0 +1,96 @@
+package prompt
+
+// GradingData 採点プロンプト（grading）に埋め込む変数
+type GradingData struct {
+	English    string             // 問題文
+	Japanese   string             // 日本語での説明
+	UserAnswer string             // 学習者の解答
+	MaxPoints  int                // 配点
+	Criteria   []GradingCriterion // 採点ルーブリックの評価観点
+	ErrorTypes []string           // 解答中の誤りの種類
+
+	// 正解として認める解答例（登録されていない場合は空）
+	ReferenceAnswers []GradingReferenceAnswer
+	// 事前チェックで見つかった指摘（綴りの誤りの候補など）
+	PreCheckFindings []string
+	// 学習者の入力から検出した採点への指示のような記述の種類（学習者の入力そのものは含めない）
+	InjectionSignals []string
+}
+
+// SecondOpinionData 再採点の厳格な再評価プロンプト（grading_second_opinion）に埋め込む変数
+type SecondOpinionData struct {
+	GradingData
+
+	PreviousPoints int                    // 前回の採点の得点
+	PreviousRubric []GradingPreviousScore // 前回の採点の評価観点ごとの得点
+	AppealReason   string                 // 学習者が再採点を依頼した理由
+}
+
+// GradingPreviousScore 前回の採点の評価観点ごとの得点と根拠
+type GradingPreviousScore struct {
+	Name      string // 評価観点（出力のキー）
+	Score     int    // 得点（0-100）
+	Rationale string // 得点の根拠
+}
+
+// GradingReferenceAnswer 正解として認める解答例
+type GradingReferenceAnswer struct {
+	Answer   string // 解答例
+	Register string // 文体（formal / casual）
+	Variety  string // 英語の種類（US / UK）
+}
+
+// GradingCriterion 採点ルーブリックの評価観点
+type GradingCriterion struct {
+	Name        string // 出力のキー
+	Description string // 評価観点の説明
+	Weight      int    // 得点に対する重み（%）
+}
+
+// CategoryAnalysisData カテゴリ分析プロンプト（category_analysis）に埋め込む変数
+type CategoryAnalysisData struct {
+	CategoryName string // カテゴリ名
+	Data         string // カテゴリの学習データ（JSON）
+}
+
+// AnalysisData 詳細分析・学習アドバイスのプロンプト（detailed_analysis / learning_advice）に埋め込む変数
+type AnalysisData struct {
+	Data string // 分析対象のデータ（JSON）
+}
+
+// TutorData チューターとの対話のシステムプロンプト（tutor）に埋め込む変数
+type TutorData struct {
+	English           string                 // 問題文
+	Japanese          string                 // 日本語での説明
+	UserAnswer        string                 // 学習者の解答
+	MaxPoints         int                    // 配点
+	GetPoints         int                    // 得点
+	ExampleCorrection string                 // 模範解答
+	Advice            string                 // 採点時のアドバイス
+	Rubric            []TutorRubricScore     // 評価観点ごとの得点と根拠
+	Errors            []TutorCorrectionError // 解答中の誤りの指摘
+}
+
+// TutorRubricScore 採点の評価観点ごとの得点と根拠
+type TutorRubricScore struct {
+	Name      string // 評価観点
+	Score     int    // 得点（0-100）
+	Rationale string // 得点の根拠
+}
+
+// TutorCorrectionError 採点時に指摘した解答中の誤り
+type TutorCorrectionError struct {
+	Original    string // 解答中の該当箇所
+	Suggestion  string // 修正案
+	ErrorType   string // 誤りの種類
+	Explanation string // 誤りの説明
+}
+
+// QuestionGenerationData 問題作成プロンプト（question_generation）に埋め込む変数
+type QuestionGenerationData struct {
+	CategoryName      string   // カテゴリ名
+	Level             string   // 問題のレベル（basic / inter / adv）
+	QuestionType      string   // 問題の種類（essay / translate / fill）
+	Count             int      // 作成する問題数
+	ExistingQuestions []string // 重複を避ける既存の問題文
+}
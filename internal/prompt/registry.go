@@ -0,0 +1,236 @@
+package prompt
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// プロンプト名
+const (
+	NameGrading              = "grading"
+	NameGradingSecondOpinion = "grading_second_opinion"
+	NameCategoryAnalysis     = "category_analysis"
+	NameDetailedAnalysis     = "detailed_analysis"
+	NameLearningAdvice       = "learning_advice"
+	NameTutor                = "tutor"
+	NameQuestionGeneration   = "question_generation"
+)
+
+// requiredNames 起動時に存在していなければならないプロンプト
+var requiredNames = []string{
+	NameGrading,
+	NameGradingSecondOpinion,
+	NameCategoryAnalysis,
+	NameDetailedAnalysis,
+	NameLearningAdvice,
+	NameTutor,
+	NameQuestionGeneration,
+}
+
+// embeddedTemplates バイナリに組み込まれたプロンプト（templates/<プロンプト名>/<バージョン>.tmpl）
+//
+//go:embed templates/*/*.tmpl
+var embeddedTemplates embed.FS
+
+// Template 名前とバージョンを持つプロンプトテンプレート
+type Template struct {
+	Name    string
+	Version string
+	source  string
+	tmpl    *template.Template
+}
+
+// ID プロンプトを一意に識別する文字列（<プロンプト名>@<バージョン>）
+func (t *Template) ID() string {
+	return t.Name + "@" + t.Version
+}
+
+// Rendered 変数を埋め込んだプロンプト
+type Rendered struct {
+	Text    string // プロンプト本文
+	Version string // 使用したプロンプトのID（<プロンプト名>@<バージョン>）
+}
+
+// Registry プロンプト名ごとに最新バージョンのテンプレートを保持する
+// テンプレートはバイナリ組み込みのものに加え、ディレクトリを指定した場合はそこからも読み込む
+// （同じ名前・バージョンのファイルはディレクトリ側が優先される）
+type Registry struct {
+	dir string
+
+	mu     sync.RWMutex
+	active map[string]*Template
+}
+
+// NewRegistry テンプレートを読み込んでレジストリを作成する（dirが空の場合は組み込みのテンプレートのみ）
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{dir: dir}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload テンプレートを読み込み直す
+// 読み込みに失敗した場合は現在のテンプレートをそのまま使い続ける
+func (r *Registry) Reload() error {
+	templates, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, next := range templates {
+		current, ok := r.active[name]
+		switch {
+		case !ok:
+			log.Printf("プロンプト %s を読み込みました", next.ID())
+		case current.Version != next.Version:
+			log.Printf("プロンプト %s を %s に切り替えました", current.ID(), next.Version)
+		case current.source != next.source:
+			log.Printf("Warning: プロンプト %s の内容がバージョンを変えずに変更されました", next.ID())
+		}
+	}
+	r.active = templates
+	return nil
+}
+
+// Watch 指定した間隔でテンプレートを読み込み直す（ホットリロード）。返り値の関数で停止する
+func (r *Registry) Watch(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := r.Reload(); err != nil {
+					log.Printf("Warning: プロンプトの再読み込みに失敗しました（現在のプロンプトを使い続けます）: %v", err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Get プロンプト名に対応する現在のテンプレートを取得する
+func (r *Registry) Get(name string) (*Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.active[name]
+	if !ok {
+		return nil, fmt.Errorf("プロンプト %q が登録されていません", name)
+	}
+	return t, nil
+}
+
+// Version プロンプト名に対応する現在のテンプレートのID（<プロンプト名>@<バージョン>）を返す
+func (r *Registry) Version(name string) string {
+	t, err := r.Get(name)
+	if err != nil {
+		return ""
+	}
+	return t.ID()
+}
+
+// Render 現在のテンプレートに変数を埋め込んでプロンプトを作成する
+func (r *Registry) Render(name string, data any) (*Rendered, error) {
+	t, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("プロンプト %s の作成に失敗しました: %w", t.ID(), err)
+	}
+	return &Rendered{Text: buf.String(), Version: t.ID()}, nil
+}
+
+// load 組み込みとディレクトリのテンプレートを読み込み、プロンプト名ごとに最新バージョンを選ぶ
+func (r *Registry) load() (map[string]*Template, error) {
+	sources := make(map[string]map[string]string) // プロンプト名 -> バージョン -> 本文
+	if err := collectTemplates(embeddedTemplates, "templates", sources); err != nil {
+		return nil, err
+	}
+	if r.dir != "" {
+		if err := collectTemplates(os.DirFS(r.dir), ".", sources); err != nil {
+			return nil, err
+		}
+	}
+
+	templates := make(map[string]*Template, len(sources))
+	for name, versions := range sources {
+		version := latestVersion(versions)
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(versions[version])
+		if err != nil {
+			return nil, fmt.Errorf("プロンプト %s@%s の解析に失敗しました: %w", name, version, err)
+		}
+		templates[name] = &Template{Name: name, Version: version, source: versions[version], tmpl: tmpl}
+	}
+
+	for _, name := range requiredNames {
+		if _, ok := templates[name]; !ok {
+			return nil, fmt.Errorf("プロンプト %q が見つかりません", name)
+		}
+	}
+	return templates, nil
+}
+
+// collectTemplates root配下の <プロンプト名>/<バージョン>.tmpl を読み込む
+func collectTemplates(fsys fs.FS, root string, sources map[string]map[string]string) error {
+	files, err := fs.Glob(fsys, path.Join(root, "*", "*.tmpl"))
+	if err != nil {
+		return fmt.Errorf("プロンプトファイルの検索に失敗しました: %w", err)
+	}
+
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("プロンプトファイル %s の読み込みに失敗しました: %w", file, err)
+		}
+		name := path.Base(path.Dir(file))
+		version := strings.TrimSuffix(path.Base(file), ".tmpl")
+		if sources[name] == nil {
+			sources[name] = make(map[string]string)
+		}
+		sources[name][version] = string(content)
+	}
+	return nil
+}
+
+// latestVersion 最新のバージョンを返す（v1, v2, ..., v10 のように数値部分で比較する）
+func latestVersion(versions map[string]string) string {
+	keys := make([]string, 0, len(versions))
+	for version := range versions {
+		keys = append(keys, version)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ni, errI := strconv.Atoi(strings.TrimPrefix(keys[i], "v"))
+		nj, errJ := strconv.Atoi(strings.TrimPrefix(keys[j], "v"))
+		if errI == nil && errJ == nil && ni != nj {
+			return ni < nj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys[len(keys)-1]
+}
@@ -0,0 +1,236 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTemplate dir配下に <プロンプト名>/<バージョン>.tmpl を作成する
+func writeTemplate(t *testing.T, dir, name, version, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name, version+".tmpl"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 組み込みのテンプレートはすべて読み込め、各プロンプトの変数で作成できる
+func TestEmbeddedTemplates(t *testing.T) {
+	r, err := NewRegistry("")
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	grading := GradingData{
+		English:          "Translate: 私は毎朝コーヒーを飲みます。",
+		Japanese:         "私は毎朝コーヒーを飲みます。",
+		UserAnswer:       "I drink coffee every morning.",
+		MaxPoints:        10,
+		Criteria:         []GradingCriterion{{Name: "grammar", Description: "文法", Weight: 100}},
+		ErrorTypes:       []string{"grammar"},
+		ReferenceAnswers: []GradingReferenceAnswer{{Answer: "I have coffee every morning.", Register: "casual", Variety: "US"}},
+		PreCheckFindings: []string{"綴りの誤りの可能性があります"},
+		InjectionSignals: []string{"採点への指示"},
+	}
+	data := map[string]any{
+		NameGrading: grading,
+		NameGradingSecondOpinion: SecondOpinionData{
+			GradingData:    grading,
+			PreviousPoints: 8,
+			PreviousRubric: []GradingPreviousScore{{Name: "grammar", Score: 80, Rationale: "時制の誤り"}},
+			AppealReason:   "時制は正しいはずです",
+		},
+		NameCategoryAnalysis: CategoryAnalysisData{CategoryName: "時制", Data: "{}"},
+		NameDetailedAnalysis: AnalysisData{Data: "{}"},
+		NameLearningAdvice:   AnalysisData{Data: "{}"},
+		NameTutor: TutorData{
+			English:    "Translate: 私は毎朝コーヒーを飲みます。",
+			UserAnswer: "I drink coffee every morning.",
+			MaxPoints:  10,
+			GetPoints:  8,
+			Rubric:     []TutorRubricScore{{Name: "grammar", Score: 80, Rationale: "時制の誤り"}},
+			Errors:     []TutorCorrectionError{{Original: "drink", Suggestion: "drank", ErrorType: "grammar", Explanation: "過去形"}},
+		},
+		NameQuestionGeneration: QuestionGenerationData{CategoryName: "時制", Level: "basic", QuestionType: "translate", Count: 3, ExistingQuestions: []string{"I went to school."}},
+	}
+
+	for _, name := range requiredNames {
+		rendered, err := r.Render(name, data[name])
+		if err != nil {
+			t.Errorf("Render(%s): %v", name, err)
+			continue
+		}
+		if rendered.Version != r.Version(name) || !strings.HasPrefix(rendered.Version, name+"@v") || strings.TrimSpace(rendered.Text) == "" {
+			t.Errorf("Render(%s) = %+v", name, rendered)
+		}
+	}
+}
+
+// プロンプト名ごとに数値部分が最も大きいバージョンを <プロンプト名>@<バージョン> として使う
+func TestRegistryResolvesLatestVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, NameGrading, "v9", "v9: {{.UserAnswer}}")
+	writeTemplate(t, dir, NameGrading, "v10", "v10: {{.UserAnswer}}")
+	writeTemplate(t, dir, NameTutor, "v1", "ディレクトリのv1: {{.UserAnswer}}")
+	writeTemplate(t, dir, "custom", "draft", "下書き")
+	writeTemplate(t, dir, "custom", "v2", "v2")
+
+	r, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		wantVersion string
+		data        any
+		wantText    string
+	}{
+		{name: NameGrading, wantVersion: "grading@v10", data: GradingData{UserAnswer: "answer"}, wantText: "v10: answer"},
+		// 同じ名前・バージョンのファイルはディレクトリ側を優先する
+		{name: NameTutor, wantVersion: "tutor@v1", data: TutorData{UserAnswer: "answer"}, wantText: "ディレクトリのv1: answer"},
+		// 数値でないバージョンは文字列として比較する
+		{name: "custom", wantVersion: "custom@v2", wantText: "v2"},
+	}
+	for _, tt := range tests {
+		if got := r.Version(tt.name); got != tt.wantVersion {
+			t.Errorf("Version(%s) = %s, want %s", tt.name, got, tt.wantVersion)
+		}
+		rendered, err := r.Render(tt.name, tt.data)
+		if err != nil {
+			t.Errorf("Render(%s): %v", tt.name, err)
+			continue
+		}
+		if rendered.Text != tt.wantText || rendered.Version != tt.wantVersion {
+			t.Errorf("Render(%s) = %+v, want %q（%s）", tt.name, rendered, tt.wantText, tt.wantVersion)
+		}
+	}
+
+	if _, err := r.Get("unknown"); err == nil {
+		t.Error("登録されていないプロンプトを取得できます")
+	}
+	if got := r.Version("unknown"); got != "" {
+		t.Errorf("Version(unknown) = %q, want 空", got)
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	tests := []struct {
+		versions []string
+		want     string
+	}{
+		{versions: []string{"v1"}, want: "v1"},
+		{versions: []string{"v2", "v1", "v10", "v9"}, want: "v10"},
+		{versions: []string{"v1", "v1-draft"}, want: "v1-draft"},
+	}
+	for _, tt := range tests {
+		versions := make(map[string]string, len(tt.versions))
+		for _, v := range tt.versions {
+			versions[v] = ""
+		}
+		if got := latestVersion(versions); got != tt.want {
+			t.Errorf("latestVersion(%v) = %s, want %s", tt.versions, got, tt.want)
+		}
+	}
+}
+
+// 変数の不足はプロンプトの作成エラーとする
+func TestRegistryRenderMissingVariable(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "custom", "v1", "{{.Missing}}")
+
+	r, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	if _, err := r.Render("custom", AnalysisData{}); err == nil || !strings.Contains(err.Error(), "custom@v1") {
+		t.Errorf("Render = %v, want custom@v1 の作成エラー", err)
+	}
+	if _, err := r.Render("custom", map[string]any{}); err == nil {
+		t.Error("mapにない変数でプロンプトを作成できます")
+	}
+}
+
+// PROMPTS_DIRのテンプレートを再読み込みで追加・切り替えし、読み込みに失敗した場合は直前のテンプレートを使い続ける
+func TestRegistryReload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, NameGrading, "v100", "v100")
+
+	r, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	if got := r.Version(NameGrading); got != "grading@v100" {
+		t.Fatalf("Version = %s, want grading@v100", got)
+	}
+
+	writeTemplate(t, dir, NameGrading, "v101", "v101")
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := r.Version(NameGrading); got != "grading@v101" {
+		t.Errorf("再読み込み後の Version = %s, want grading@v101", got)
+	}
+
+	writeTemplate(t, dir, NameGrading, "v102", "{{.UserAnswer")
+	if err := r.Reload(); err == nil {
+		t.Error("解析できないテンプレートを読み込めます")
+	}
+	if got := r.Version(NameGrading); got != "grading@v101" {
+		t.Errorf("読み込みに失敗した後の Version = %s, want grading@v101", got)
+	}
+
+	// ファイルを削除した場合は組み込みのテンプレートに戻る
+	if err := os.RemoveAll(filepath.Join(dir, NameGrading)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	embedded, _ := NewRegistry("")
+	if got, want := r.Version(NameGrading), embedded.Version(NameGrading); got != want {
+		t.Errorf("削除後の Version = %s, want %s", got, want)
+	}
+}
+
+// Watch 指定した間隔で再読み込みし、停止後は読み込まない
+func TestRegistryWatch(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	stop := r.Watch(5 * time.Millisecond)
+
+	writeTemplate(t, dir, NameGrading, "v100", "v100")
+	deadline := time.Now().Add(2 * time.Second)
+	for r.Version(NameGrading) != "grading@v100" {
+		if time.Now().After(deadline) {
+			t.Fatal("ホットリロードでテンプレートが切り替わりません")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stop()
+	stop() // 複数回呼び出してもよい
+	time.Sleep(10 * time.Millisecond)
+	writeTemplate(t, dir, NameGrading, "v101", "v101")
+	time.Sleep(30 * time.Millisecond)
+	if got := r.Version(NameGrading); got != "grading@v100" {
+		t.Errorf("停止後の Version = %s, want grading@v100", got)
+	}
+}
+
+func TestNewRegistryInvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, NameTutor, "v100", "{{if}}")
+
+	if _, err := NewRegistry(dir); err == nil || !strings.Contains(err.Error(), "tutor@v100") {
+		t.Errorf("NewRegistry = %v, want tutor@v100 の解析エラー", err)
+	}
+}
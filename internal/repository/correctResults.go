@@ -1,21 +1,42 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Takanpon2512/english-app/internal/model"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrGradingInProgress 同じ解答の採点が処理中のため再採点できない
+var ErrGradingInProgress = errors.New("採点が処理中のため再採点できません")
+
 type CorrectResultsRepository interface {
 	CreateCorrectionResult(req *model.CreateCorrectionResultRequest) (*model.CreateCorrectionResultResponse, error)
 	UpdateCorrectionResult(req *model.UpdateCorrectionResultRequest) (*model.UpdateCorrectionResultResponse, error)
+	CompleteCorrectionResult(tx *gorm.DB, req *model.UpdateCorrectionResultRequest) error
 
 	GetCorrectionResultById(id string) (*model.CorrectionResults, error)
+	GetCorrectionResultsByStatus(status string) ([]model.CorrectionResults, error)
+	GetCorrectionResultByChallenge(projectID string, questionTemplateMasterID string, challengeCount int) (*model.CorrectionResults, error)
 	GetCorrectResults(req *model.GetCorrectResultsRequest) (*model.GetCorrectResultsResponse, error)
 	GetCorrectResultsVersionList(req *model.GetCorrectResultsVersionRequest) ([]model.VersionList, error)
+
+	CreateRegrade(source *model.CorrectionResults, gradingMode string, reason string) (*model.CorrectionResults, error)
+	PrepareChallengeGrading(questionAnswer *model.QuestionAnswers) (*model.CorrectionResults, bool, error)
+	GetGradingHistory(questionAnswerID string) ([]model.CorrectionResults, error)
+	SetAuthoritativeGrading(tx *gorm.DB, correctionResultID string) error
+
+	GetCorrectionResultsNeedingReview(limit int) ([]model.CorrectionResults, error)
+	ResolveReview(correctionResultID string) error
+
+	SaveRubricScores(tx *gorm.DB, correctionResultID string, scores []model.CorrectionRubricScores) error
+	GetRubricScores(correctionResultIDs []string) (map[string][]model.CorrectionRubricScores, error)
+	SaveCorrectionErrors(tx *gorm.DB, correctionResultID string, correctionErrors []model.CorrectionErrors) error
+	GetCorrectionErrors(correctionResultIDs []string) (map[string][]model.CorrectionErrors, error)
 }
 
 type correctResultsRepository struct {
@@ -41,6 +62,9 @@ func (r *correctResultsRepository) CreateCorrectionResult(req *model.CreateCorre
 		Advice:                   req.Advice,
 		Status:                   req.Status,
 		ChallengeCount:           req.ChallengeCount,
+		GradingVersion:           1,
+		IsAuthoritative:          true,
+		GradingMode:              model.GradingModeStandard,
 		CreatedAt:                now,
 		UpdatedAt:                now,
 		CreatedBy:                "system",
@@ -65,6 +89,7 @@ func (r *correctResultsRepository) CreateCorrectionResult(req *model.CreateCorre
 	}, nil
 }
 
+// 添削結果を更新する（ゼロ値の項目は更新しないため、ステータスのみの更新などに使う）
 func (r *correctResultsRepository) UpdateCorrectionResult(req *model.UpdateCorrectionResultRequest) (*model.UpdateCorrectionResultResponse, error) {
 	now := time.Now()
 	correctionResult := &model.CorrectionResults{
@@ -73,6 +98,13 @@ func (r *correctResultsRepository) UpdateCorrectionResult(req *model.UpdateCorre
 		ExampleCorrection: req.ExampleCorrection,
 		CorrectRate:       req.CorrectRate,
 		Advice:            req.Advice,
+		PromptVersion:     req.PromptVersion,
+		LLMModel:          req.LLMModel,
+		LLMProvider:       req.LLMProvider,
+		PreCheck:          req.PreCheck,
+		ConsensusSamples:  req.ConsensusSamples,
+		ScoreVariance:     req.ScoreVariance,
+		NeedsReview:       req.NeedsReview,
 		Status:            req.Status,
 		UpdatedAt:         now,
 		UpdatedBy:         "system",
@@ -91,6 +123,9 @@ func (r *correctResultsRepository) UpdateCorrectionResult(req *model.UpdateCorre
 		ExampleCorrection:        correctionResult.ExampleCorrection,
 		CorrectRate:              correctionResult.CorrectRate,
 		Advice:                   correctionResult.Advice,
+		PromptVersion:            correctionResult.PromptVersion,
+		LLMModel:                 correctionResult.LLMModel,
+		LLMProvider:              correctionResult.LLMProvider,
 		Status:                   correctionResult.Status,
 		ChallengeCount:           correctionResult.ChallengeCount,
 	}, nil
@@ -105,7 +140,34 @@ func (r *correctResultsRepository) GetCorrectionResultById(id string) (*model.Co
 	return &correctionResult, nil
 }
 
-// 添削結果の一覧取得
+// ステータスを指定して添削結果を取得（作成日時の古い順）
+func (r *correctResultsRepository) GetCorrectionResultsByStatus(status string) ([]model.CorrectionResults, error) {
+	var correctionResults []model.CorrectionResults
+	if err := r.db.Model(&model.CorrectionResults{}).Where("status = ?", status).Order("created_at ASC").Find(&correctionResults).Error; err != nil {
+		return nil, fmt.Errorf("添削結果の取得に失敗しました: %w", err)
+	}
+
+	return correctionResults, nil
+}
+
+// 挑戦回数を指定して問題の正式な添削結果を取得（存在しない場合はnil）
+func (r *correctResultsRepository) GetCorrectionResultByChallenge(projectID string, questionTemplateMasterID string, challengeCount int) (*model.CorrectionResults, error) {
+	var correctionResults []model.CorrectionResults
+	if err := r.db.Model(&model.CorrectionResults{}).
+		Where("project_id = ? AND question_template_master_id = ? AND challenge_count = ? AND is_authoritative = ?", projectID, questionTemplateMasterID, challengeCount, true).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&correctionResults).Error; err != nil {
+		return nil, fmt.Errorf("添削結果の取得に失敗しました: %w", err)
+	}
+	if len(correctionResults) == 0 {
+		return nil, nil
+	}
+
+	return &correctionResults[0], nil
+}
+
+// 添削結果の一覧取得（再採点した解答は正式な採点結果のみ）
 func (r *correctResultsRepository) GetCorrectResults(req *model.GetCorrectResultsRequest) (*model.GetCorrectResultsResponse, error) {
 	var correctResults []model.CorrectionResults
 
@@ -119,7 +181,7 @@ func (r *correctResultsRepository) GetCorrectResults(req *model.GetCorrectResult
 		challengeCount = maxChallengeCount
 	}
 
-	if err := r.db.Model(&model.CorrectionResults{}).Where("project_id = ? AND challenge_count = ?", req.ProjectID, challengeCount).Find(&correctResults).Error; err != nil {
+	if err := r.db.Model(&model.CorrectionResults{}).Where("project_id = ? AND challenge_count = ? AND is_authoritative = ?", req.ProjectID, challengeCount, true).Find(&correctResults).Error; err != nil {
 		return nil, fmt.Errorf("添削結果の取得に失敗しました: %w", err)
 	}
 
@@ -134,8 +196,13 @@ func (r *correctResultsRepository) GetCorrectResults(req *model.GetCorrectResult
 			ExampleCorrection:        correctResult.ExampleCorrection,
 			CorrectRate:              correctResult.CorrectRate,
 			Advice:                   correctResult.Advice,
+			PromptVersion:            correctResult.PromptVersion,
+			LLMModel:                 correctResult.LLMModel,
+			LLMProvider:              correctResult.LLMProvider,
 			Status:                   correctResult.Status,
 			ChallengeCount:           correctResult.ChallengeCount,
+			GradingVersion:           correctResult.GradingVersion,
+			Consensus:                correctResult.Consensus(),
 		})
 	}
 
@@ -172,3 +239,312 @@ func (r *correctResultsRepository) GetCorrectResultsVersionList(req *model.GetCo
 
 	return versionList, nil
 }
+
+// 再採点のための添削結果を処理中で作成する（採点のバージョンは同じ解答の最新のバージョン+1）
+// 採点が完了するまでは正式な採点結果にしない
+func (r *correctResultsRepository) CreateRegrade(source *model.CorrectionResults, gradingMode string, reason string) (*model.CorrectionResults, error) {
+	now := time.Now()
+	correctionResult := &model.CorrectionResults{
+		ID:                       uuid.New().String(),
+		QuestionAnswerID:         source.QuestionAnswerID,
+		QuestionTemplateMasterID: source.QuestionTemplateMasterID,
+		ProjectID:                source.ProjectID,
+		Status:                   "PROCESSING",
+		ChallengeCount:           source.ChallengeCount,
+		IsAuthoritative:          false,
+		GradingMode:              gradingMode,
+		RegradeReason:            reason,
+		CreatedAt:                now,
+		UpdatedAt:                now,
+		CreatedBy:                "system",
+		UpdatedBy:                "system",
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 同時に再採点を依頼された場合にバージョンが重複しないよう、同じ解答の採点をロックする
+		var gradings []model.CorrectionResults
+		if err := tx.Model(&model.CorrectionResults{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("grading_version", "status").
+			Where("question_answer_id = ?", source.QuestionAnswerID).
+			Find(&gradings).Error; err != nil {
+			return fmt.Errorf("採点のバージョンの取得に失敗しました: %w", err)
+		}
+		for _, grading := range gradings {
+			// 採点中の添削結果がある場合は、結果が確定するまで再採点を受け付けない
+			if grading.Status == "PROCESSING" {
+				return fmt.Errorf("%w（採点のバージョン: %d）", ErrGradingInProgress, grading.GradingVersion)
+			}
+			correctionResult.GradingVersion = max(correctionResult.GradingVersion, grading.GradingVersion)
+		}
+		correctionResult.GradingVersion++
+
+		if err := tx.Create(correctionResult).Error; err != nil {
+			return fmt.Errorf("再採点の添削結果の作成に失敗しました: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return correctionResult, nil
+}
+
+// 挑戦の一括採点で解答の正式な添削結果を取得し、採点が必要かどうか（未作成・採点に失敗した）を返す
+// 添削結果がない場合は作成し、採点に失敗した添削結果は処理中に戻す
+func (r *correctResultsRepository) PrepareChallengeGrading(questionAnswer *model.QuestionAnswers) (*model.CorrectionResults, bool, error) {
+	var (
+		correctionResult *model.CorrectionResults
+		needsGrading     bool
+	)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 同時に一括採点を依頼された場合に添削結果が重複しないよう、解答をロックする
+		var locked model.QuestionAnswers
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", questionAnswer.ID).
+			First(&locked).Error; err != nil {
+			return fmt.Errorf("回答データの取得に失敗しました: %w", err)
+		}
+
+		var correctionResults []model.CorrectionResults
+		if err := tx.Model(&model.CorrectionResults{}).
+			Where("question_answer_id = ? AND is_authoritative = ?", questionAnswer.ID, true).
+			Order("created_at DESC").
+			Limit(1).
+			Find(&correctionResults).Error; err != nil {
+			return fmt.Errorf("添削結果の取得に失敗しました: %w", err)
+		}
+
+		now := time.Now()
+		switch {
+		case len(correctionResults) == 0:
+			correctionResult = &model.CorrectionResults{
+				ID:                       uuid.New().String(),
+				QuestionAnswerID:         questionAnswer.ID,
+				QuestionTemplateMasterID: questionAnswer.QuestionTemplateMasterID,
+				ProjectID:                questionAnswer.ProjectID,
+				Status:                   "PROCESSING",
+				ChallengeCount:           questionAnswer.ChallengeCount,
+				GradingVersion:           1,
+				IsAuthoritative:          true,
+				GradingMode:              model.GradingModeStandard,
+				CreatedAt:                now,
+				UpdatedAt:                now,
+				CreatedBy:                "system",
+				UpdatedBy:                "system",
+			}
+			if err := tx.Create(correctionResult).Error; err != nil {
+				return fmt.Errorf("修正結果の作成に失敗しました: %w", err)
+			}
+			needsGrading = true
+		case correctionResults[0].Status == "FAILED":
+			correctionResult = &correctionResults[0]
+			if err := tx.Model(&model.CorrectionResults{}).
+				Where("id = ?", correctionResult.ID).
+				Updates(map[string]interface{}{"status": "PROCESSING", "updated_at": now, "updated_by": "system"}).Error; err != nil {
+				return fmt.Errorf("添削結果 %s のステータス更新に失敗しました: %w", correctionResult.ID, err)
+			}
+			correctionResult.Status = "PROCESSING"
+			needsGrading = true
+		default:
+			correctionResult = &correctionResults[0]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return correctionResult, needsGrading, nil
+}
+
+// 解答の採点履歴を取得（採点のバージョンの古い順）
+func (r *correctResultsRepository) GetGradingHistory(questionAnswerID string) ([]model.CorrectionResults, error) {
+	var correctionResults []model.CorrectionResults
+	if err := r.db.Model(&model.CorrectionResults{}).
+		Where("question_answer_id = ?", questionAnswerID).
+		Order("grading_version ASC").
+		Find(&correctionResults).Error; err != nil {
+		return nil, fmt.Errorf("採点履歴の取得に失敗しました: %w", err)
+	}
+
+	return correctionResults, nil
+}
+
+// 採点結果を添削結果に保存する（0点や要確認でないことも記録するため、ゼロ値の項目も更新する）
+func (r *correctResultsRepository) CompleteCorrectionResult(tx *gorm.DB, req *model.UpdateCorrectionResultRequest) error {
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	if err := db.Model(&model.CorrectionResults{}).
+		Where("id = ?", req.ID).
+		Select("GetPoints", "ExampleCorrection", "CorrectRate", "Advice", "PromptVersion", "LLMModel", "LLMProvider",
+			"PreCheck", "ConsensusSamples", "ScoreVariance", "NeedsReview", "Status", "UpdatedAt", "UpdatedBy").
+		Updates(&model.CorrectionResults{
+			GetPoints:         req.GetPoints,
+			ExampleCorrection: req.ExampleCorrection,
+			CorrectRate:       req.CorrectRate,
+			Advice:            req.Advice,
+			PromptVersion:     req.PromptVersion,
+			LLMModel:          req.LLMModel,
+			LLMProvider:       req.LLMProvider,
+			PreCheck:          req.PreCheck,
+			ConsensusSamples:  req.ConsensusSamples,
+			ScoreVariance:     req.ScoreVariance,
+			NeedsReview:       req.NeedsReview,
+			Status:            req.Status,
+			UpdatedAt:         time.Now(),
+			UpdatedBy:         "system",
+		}).Error; err != nil {
+		return fmt.Errorf("添削結果の更新に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// 添削結果を解答の正式な採点結果にする（同じ解答の他の採点は正式な採点結果でなくなる）
+func (r *correctResultsRepository) SetAuthoritativeGrading(tx *gorm.DB, correctionResultID string) error {
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var correctionResult model.CorrectionResults
+		if err := tx.Where("id = ?", correctionResultID).First(&correctionResult).Error; err != nil {
+			return fmt.Errorf("添削結果の取得に失敗しました: %w", err)
+		}
+
+		if err := tx.Model(&model.CorrectionResults{}).
+			Where("question_answer_id = ? AND id <> ?", correctionResult.QuestionAnswerID, correctionResultID).
+			Update("is_authoritative", false).Error; err != nil {
+			return fmt.Errorf("正式な採点結果の更新に失敗しました: %w", err)
+		}
+		if err := tx.Model(&model.CorrectionResults{}).
+			Where("id = ?", correctionResultID).
+			Update("is_authoritative", true).Error; err != nil {
+			return fmt.Errorf("正式な採点結果の更新に失敗しました: %w", err)
+		}
+		return nil
+	})
+}
+
+// 人による確認が必要な正式な添削結果を取得（作成日時の新しい順）
+func (r *correctResultsRepository) GetCorrectionResultsNeedingReview(limit int) ([]model.CorrectionResults, error) {
+	var correctionResults []model.CorrectionResults
+	if err := r.db.Model(&model.CorrectionResults{}).
+		Where("needs_review = ? AND is_authoritative = ?", true, true).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&correctionResults).Error; err != nil {
+		return nil, fmt.Errorf("要確認の添削結果の取得に失敗しました: %w", err)
+	}
+
+	return correctionResults, nil
+}
+
+// 添削結果の要確認を解除する
+func (r *correctResultsRepository) ResolveReview(correctionResultID string) error {
+	if err := r.db.Model(&model.CorrectionResults{}).Where("id = ?", correctionResultID).Update("needs_review", false).Error; err != nil {
+		return fmt.Errorf("要確認の解除に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// 評価観点ごとの得点を保存する（再採点の場合は既存の得点を置き換える）
+func (r *correctResultsRepository) SaveRubricScores(tx *gorm.DB, correctionResultID string, scores []model.CorrectionRubricScores) error {
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("correction_result_id = ?", correctionResultID).Delete(&model.CorrectionRubricScores{}).Error; err != nil {
+			return fmt.Errorf("評価観点ごとの得点の削除に失敗しました: %w", err)
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+
+		records := make([]model.CorrectionRubricScores, 0, len(scores))
+		for _, score := range scores {
+			score.ID = uuid.New().String()
+			score.CorrectionResultID = correctionResultID
+			score.CreatedAt = now
+			score.UpdatedAt = now
+			records = append(records, score)
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return fmt.Errorf("評価観点ごとの得点の保存に失敗しました: %w", err)
+		}
+		return nil
+	})
+}
+
+// 添削結果IDごとに評価観点ごとの得点を取得する
+func (r *correctResultsRepository) GetRubricScores(correctionResultIDs []string) (map[string][]model.CorrectionRubricScores, error) {
+	scoresByResult := make(map[string][]model.CorrectionRubricScores, len(correctionResultIDs))
+	if len(correctionResultIDs) == 0 {
+		return scoresByResult, nil
+	}
+
+	var scores []model.CorrectionRubricScores
+	if err := r.db.Where("correction_result_id IN ?", correctionResultIDs).Order("created_at ASC").Find(&scores).Error; err != nil {
+		return nil, fmt.Errorf("評価観点ごとの得点の取得に失敗しました: %w", err)
+	}
+	for _, score := range scores {
+		scoresByResult[score.CorrectionResultID] = append(scoresByResult[score.CorrectionResultID], score)
+	}
+	return scoresByResult, nil
+}
+
+// 解答中の誤りを保存する（再採点の場合は既存の誤りを置き換える）
+func (r *correctResultsRepository) SaveCorrectionErrors(tx *gorm.DB, correctionResultID string, correctionErrors []model.CorrectionErrors) error {
+	db := r.db
+	if tx != nil {
+		db = tx
+	}
+
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("correction_result_id = ?", correctionResultID).Delete(&model.CorrectionErrors{}).Error; err != nil {
+			return fmt.Errorf("解答中の誤りの削除に失敗しました: %w", err)
+		}
+		if len(correctionErrors) == 0 {
+			return nil
+		}
+
+		records := make([]model.CorrectionErrors, 0, len(correctionErrors))
+		for _, correctionError := range correctionErrors {
+			correctionError.ID = uuid.New().String()
+			correctionError.CorrectionResultID = correctionResultID
+			correctionError.CreatedAt = now
+			correctionError.UpdatedAt = now
+			records = append(records, correctionError)
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return fmt.Errorf("解答中の誤りの保存に失敗しました: %w", err)
+		}
+		return nil
+	})
+}
+
+// 添削結果IDごとに解答中の誤りを取得する（解答中の位置順）
+func (r *correctResultsRepository) GetCorrectionErrors(correctionResultIDs []string) (map[string][]model.CorrectionErrors, error) {
+	errorsByResult := make(map[string][]model.CorrectionErrors, len(correctionResultIDs))
+	if len(correctionResultIDs) == 0 {
+		return errorsByResult, nil
+	}
+
+	var correctionErrors []model.CorrectionErrors
+	if err := r.db.Where("correction_result_id IN ?", correctionResultIDs).Order("start_offset ASC").Find(&correctionErrors).Error; err != nil {
+		return nil, fmt.Errorf("解答中の誤りの取得に失敗しました: %w", err)
+	}
+	for _, correctionError := range correctionErrors {
+		errorsByResult[correctionError.CorrectionResultID] = append(errorsByResult[correctionError.CorrectionResultID], correctionError)
+	}
+	return errorsByResult, nil
+}
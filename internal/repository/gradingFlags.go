@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GradingFlagsRepository interface {
+	CreateGradingFlags(flags []model.GradingFlags) error
+	GetGradingFlags(limit int) ([]model.GradingFlags, error)
+}
+
+type gradingFlagsRepository struct {
+	db *gorm.DB
+}
+
+func NewGradingFlagsRepository(db *gorm.DB) GradingFlagsRepository {
+	return &gradingFlagsRepository{db: db}
+}
+
+// 採点時に検出した内容を保存する
+func (r *gradingFlagsRepository) CreateGradingFlags(flags []model.GradingFlags) error {
+	if len(flags) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	records := make([]model.GradingFlags, 0, len(flags))
+	for _, flag := range flags {
+		flag.ID = uuid.New().String()
+		flag.CreatedAt = now
+		records = append(records, flag)
+	}
+	if err := r.db.Create(&records).Error; err != nil {
+		return fmt.Errorf("採点の要確認記録の保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// 採点時に検出した内容を取得（作成日時の新しい順）
+func (r *gradingFlagsRepository) GetGradingFlags(limit int) ([]model.GradingFlags, error) {
+	var flags []model.GradingFlags
+	if err := r.db.Order("created_at DESC").Limit(limit).Find(&flags).Error; err != nil {
+		return nil, fmt.Errorf("採点の要確認記録の取得に失敗しました: %w", err)
+	}
+	return flags, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+type LLMCallsRepository interface {
+	llm.CallStore
+	GetLLMCalls(req *model.GetLLMCallsRequest, limit int) ([]model.LLMCall, error)
+	DeleteLLMCallsBefore(before time.Time) (int64, error)
+}
+
+type llmCallsRepository struct {
+	db *gorm.DB
+}
+
+func NewLLMCallsRepository(db *gorm.DB) LLMCallsRepository {
+	return &llmCallsRepository{db: db}
+}
+
+// RecordCall LLM呼び出しを記録する
+func (r *llmCallsRepository) RecordCall(ctx context.Context, record *llm.CallRecord) error {
+	call := &model.LLMCall{
+		ID:             uuid.New().String(),
+		UserID:         record.UserID,
+		Feature:        record.Feature,
+		SubjectID:      record.SubjectID,
+		LLMModel:       record.Model,
+		Provider:       record.Provider,
+		PromptVersion:  record.PromptVersion,
+		Attempt:        record.Attempt,
+		RedactedPrompt: record.Prompt,
+		RawResponse:    record.Response,
+		Outcome:        record.Outcome,
+		InputTokens:    record.InputTokens,
+		OutputTokens:   record.OutputTokens,
+		LatencyMs:      int(record.Latency.Milliseconds()),
+		Cached:         record.Cached,
+		CreatedAt:      time.Now(),
+	}
+	if record.Error != "" {
+		call.ErrorMessage = &record.Error
+	}
+
+	if err := r.db.WithContext(ctx).Create(call).Error; err != nil {
+		return fmt.Errorf("LLM呼び出しの記録に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// GetLLMCalls 条件に一致するLLM呼び出しの記録を取得する（新しい順）
+func (r *llmCallsRepository) GetLLMCalls(req *model.GetLLMCallsRequest, limit int) ([]model.LLMCall, error) {
+	query := r.db.Model(&model.LLMCall{})
+	if req.Feature != "" {
+		query = query.Where("feature = ?", req.Feature)
+	}
+	if req.UserID != "" {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.SubjectID != "" {
+		query = query.Where("subject_id = ?", req.SubjectID)
+	}
+	if req.Outcome != "" {
+		query = query.Where("outcome = ?", req.Outcome)
+	}
+
+	var calls []model.LLMCall
+	if err := query.Order("created_at DESC").Limit(limit).Find(&calls).Error; err != nil {
+		return nil, fmt.Errorf("LLM呼び出しの記録の取得に失敗しました: %w", err)
+	}
+	return calls, nil
+}
+
+// DeleteLLMCallsBefore 指定日時より前のLLM呼び出しの記録を削除し、削除した件数を返す
+func (r *llmCallsRepository) DeleteLLMCallsBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&model.LLMCall{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("保存期間を過ぎたLLM呼び出しの記録の削除に失敗しました: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
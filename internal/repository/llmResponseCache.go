@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+// llmResponseCacheRepository LLM応答キャッシュをMySQLに保存するllm.CacheStore実装
+type llmResponseCacheRepository struct {
+	db         *gorm.DB
+	maxEntries int
+}
+
+// NewLLMResponseCacheRepository 最大エントリ数を指定してMySQLのキャッシュ保存先を作成する（0以下の場合は無制限）
+func NewLLMResponseCacheRepository(db *gorm.DB, maxEntries int) llm.CacheStore {
+	return &llmResponseCacheRepository{db: db, maxEntries: maxEntries}
+}
+
+// Get 有効期限内のキャッシュを取得する
+func (r *llmResponseCacheRepository) Get(ctx context.Context, key string) (*llm.CacheEntry, error) {
+	var cache model.LLMResponseCache
+	err := r.db.WithContext(ctx).
+		Where("cache_key = ? AND expires_at > ?", key, time.Now()).
+		First(&cache).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("LLM応答キャッシュの取得に失敗しました: %w", err)
+	}
+
+	return &llm.CacheEntry{
+		Feature:       cache.Feature,
+		Model:         cache.LLMModel,
+		Provider:      cache.Provider,
+		PromptVersion: cache.PromptVersion,
+		Text:          cache.ResponseText,
+		InputTokens:   cache.InputTokens,
+		OutputTokens:  cache.OutputTokens,
+	}, nil
+}
+
+// Set キャッシュを保存し、有効期限切れと上限を超えた古いエントリを削除する
+func (r *llmResponseCacheRepository) Set(ctx context.Context, key string, entry *llm.CacheEntry, ttl time.Duration) error {
+	now := time.Now()
+	cache := &model.LLMResponseCache{
+		CacheKey:      key,
+		Feature:       entry.Feature,
+		LLMModel:      entry.Model,
+		Provider:      entry.Provider,
+		PromptVersion: entry.PromptVersion,
+		ResponseText:  entry.Text,
+		InputTokens:   entry.InputTokens,
+		OutputTokens:  entry.OutputTokens,
+		ExpiresAt:     now.Add(ttl),
+		CreatedAt:     now,
+	}
+
+	db := r.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(cache).Error; err != nil {
+		return fmt.Errorf("LLM応答キャッシュの保存に失敗しました: %w", err)
+	}
+
+	if err := db.Where("expires_at <= ?", now).Delete(&model.LLMResponseCache{}).Error; err != nil {
+		return fmt.Errorf("期限切れのLLM応答キャッシュの削除に失敗しました: %w", err)
+	}
+
+	if r.maxEntries <= 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&model.LLMResponseCache{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("LLM応答キャッシュの件数取得に失敗しました: %w", err)
+	}
+	if excess := int(count) - r.maxEntries; excess > 0 {
+		// 作成日時の古いエントリから削除する
+		var oldKeys []string
+		if err := db.Model(&model.LLMResponseCache{}).
+			Order("created_at ASC").
+			Limit(excess).
+			Pluck("cache_key", &oldKeys).Error; err != nil {
+			return fmt.Errorf("古いLLM応答キャッシュの取得に失敗しました: %w", err)
+		}
+		if err := db.Where("cache_key IN ?", oldKeys).Delete(&model.LLMResponseCache{}).Error; err != nil {
+			return fmt.Errorf("古いLLM応答キャッシュの削除に失敗しました: %w", err)
+		}
+	}
+
+	return nil
+}
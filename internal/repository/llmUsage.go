@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+type LLMUsageRepository interface {
+	llm.UsageStore
+	GetLLMUsageSummary(from time.Time, to time.Time, groupColumn string, userID string) ([]model.LLMUsageSummaryRow, error)
+}
+
+type llmUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewLLMUsageRepository(db *gorm.DB) LLMUsageRepository {
+	return &llmUsageRepository{db: db}
+}
+
+// RecordUsage LLM呼び出しの利用量を記録する
+func (r *llmUsageRepository) RecordUsage(ctx context.Context, record *llm.UsageRecord) error {
+	usage := &model.LLMUsage{
+		ID:           uuid.New().String(),
+		UserID:       record.UserID,
+		Feature:      record.Feature,
+		LLMModel:     record.Model,
+		InputTokens:  record.InputTokens,
+		OutputTokens: record.OutputTokens,
+		CostUSD:      record.CostUSD,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := r.db.WithContext(ctx).Create(usage).Error; err != nil {
+		return fmt.Errorf("LLM利用量の記録に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// SumUserTokens 指定日時以降のユーザーの利用トークン数を取得する
+func (r *llmUsageRepository) SumUserTokens(ctx context.Context, userID string, since time.Time) (int, error) {
+	var total int
+	if err := r.db.WithContext(ctx).Model(&model.LLMUsage{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Select("COALESCE(SUM(input_tokens + output_tokens), 0)").
+		Scan(&total).Error; err != nil {
+		return 0, fmt.Errorf("LLM利用量の集計に失敗しました: %w", err)
+	}
+	return total, nil
+}
+
+// GetLLMUsageSummary 期間内の利用量を指定したカラムごとに集計する（料金の高い順）
+func (r *llmUsageRepository) GetLLMUsageSummary(from time.Time, to time.Time, groupColumn string, userID string) ([]model.LLMUsageSummaryRow, error) {
+	query := r.db.Model(&model.LLMUsage{}).
+		Select(groupColumn+" AS `key`, COUNT(*) AS calls, SUM(input_tokens) AS input_tokens, SUM(output_tokens) AS output_tokens, SUM(cost_usd) AS cost_usd").
+		Where("created_at >= ? AND created_at < ?", from, to)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var rows []model.LLMUsageSummaryRow
+	if err := query.Group(groupColumn).Order("cost_usd DESC").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("LLM利用量の集計に失敗しました: %w", err)
+	}
+	return rows, nil
+}
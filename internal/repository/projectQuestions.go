@@ -2,6 +2,8 @@ package repository
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Takanpon2512/english-app/internal/model"
@@ -22,25 +24,49 @@ func NewProjectQuestionsRepository(db *gorm.DB) ProjectQuestionsRepository {
 	return &projectQuestionsRepository{db: db}
 }
 
+// プロジェクト質問を作成（出題できる（ACTIVE）質問テンプレートのみ紐づけられる）
 func (r *projectQuestionsRepository) CreateProjectQuestions(req *model.CreateProjectQuestionsRequest) (*model.CreateProjectQuestionsResponse, error) {
 	now := time.Now()
 	var createdProjectQuestions []model.ProjectQuestions
 
-	for _, questionTemplateMasterID := range req.QuestionTemplateMasterIDs {
-		projectQuestion := &model.ProjectQuestions{
-			ID:                       uuid.New().String(),
-			ProjectID:                req.ProjectID,
-			QuestionTemplateMasterID: questionTemplateMasterID,
-			CreatedAt:                now,
-			UpdatedAt:                now,
-			CreatedBy:                req.UserID,
-			UpdatedBy:                req.UserID,
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 承認待ち・却下した質問テンプレートは存在しないものとして扱う
+		var activeIDs []string
+		if err := tx.Model(&model.QuestionTemplateMasters{}).
+			Where("id IN ? AND status = ?", req.QuestionTemplateMasterIDs, model.QuestionTemplateStatusActive).
+			Pluck("id", &activeIDs).Error; err != nil {
+			return fmt.Errorf("質問テンプレートマスターの取得に失敗しました: %w", err)
 		}
+		var missingIDs []string
+		for _, questionTemplateMasterID := range req.QuestionTemplateMasterIDs {
+			if !slices.Contains(activeIDs, questionTemplateMasterID) {
+				missingIDs = append(missingIDs, questionTemplateMasterID)
+			}
+		}
+		if len(missingIDs) > 0 {
+			return fmt.Errorf("出題できる質問テンプレートではありません（ID: %s）: %w", strings.Join(missingIDs, ", "), gorm.ErrRecordNotFound)
+		}
+
+		for _, questionTemplateMasterID := range req.QuestionTemplateMasterIDs {
+			projectQuestion := &model.ProjectQuestions{
+				ID:                       uuid.New().String(),
+				ProjectID:                req.ProjectID,
+				QuestionTemplateMasterID: questionTemplateMasterID,
+				CreatedAt:                now,
+				UpdatedAt:                now,
+				CreatedBy:                req.UserID,
+				UpdatedBy:                req.UserID,
+			}
 
-		if err := r.db.Create(projectQuestion).Error; err != nil {
-			return nil, fmt.Errorf("プロジェクト質問の作成に失敗しました: %w", err)
+			if err := tx.Create(projectQuestion).Error; err != nil {
+				return fmt.Errorf("プロジェクト質問の作成に失敗しました: %w", err)
+			}
+			createdProjectQuestions = append(createdProjectQuestions, *projectQuestion)
 		}
-		createdProjectQuestions = append(createdProjectQuestions, *projectQuestion)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 作成されたプロジェクト質問のサマリーを作成
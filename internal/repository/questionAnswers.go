@@ -15,6 +15,7 @@ type QuestionAnswersRepository interface {
 	GetQuestionAnswerById(id string) (*model.QuestionAnswers, error)
 	GetQuestionAnswersByProjectID(projectID string) (*model.GetQuestionAnswersResponse, error)
 	GetQuestionAnswersByProjectIDAndStatus(projectID, status string) ([]model.QuestionAnswers, error)
+	GetQuestionAnswersByChallenge(projectID string, challengeCount int) ([]model.QuestionAnswers, error)
 	UpdateQuestionAnswer(tx *gorm.DB, questionAnswer *model.QuestionAnswers) error
 }
 
@@ -123,6 +124,15 @@ func (r *questionAnswersRepository) GetQuestionAnswersByProjectIDAndStatus(proje
 	return questionAnswers, nil
 }
 
+// GetQuestionAnswersByChallenge プロジェクトIDと挑戦回数で回答データを取得（ステータスによらない）
+func (r *questionAnswersRepository) GetQuestionAnswersByChallenge(projectID string, challengeCount int) ([]model.QuestionAnswers, error) {
+	var questionAnswers []model.QuestionAnswers
+	if err := r.db.Where("project_id = ? AND challenge_count = ?", projectID, challengeCount).Order("created_at ASC").Find(&questionAnswers).Error; err != nil {
+		return nil, fmt.Errorf("回答データの取得に失敗しました: %w", err)
+	}
+	return questionAnswers, nil
+}
+
 // UpdateQuestionAnswer 回答データを更新（トランザクション対応）
 func (r *questionAnswersRepository) UpdateQuestionAnswer(tx *gorm.DB, questionAnswer *model.QuestionAnswers) error {
 	db := r.db
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type QuestionReferenceAnswersRepository interface {
+	CreateReferenceAnswer(referenceAnswer *model.QuestionReferenceAnswers) error
+	GetReferenceAnswers(questionTemplateMasterID string) ([]model.QuestionReferenceAnswers, error)
+	GetReferenceAnswerByID(id string) (*model.QuestionReferenceAnswers, error)
+	UpdateReferenceAnswer(referenceAnswer *model.QuestionReferenceAnswers) error
+	DeleteReferenceAnswer(referenceAnswer *model.QuestionReferenceAnswers) error
+}
+
+type questionReferenceAnswersRepository struct {
+	db *gorm.DB
+}
+
+func NewQuestionReferenceAnswersRepository(db *gorm.DB) QuestionReferenceAnswersRepository {
+	return &questionReferenceAnswersRepository{db: db}
+}
+
+func (r *questionReferenceAnswersRepository) CreateReferenceAnswer(referenceAnswer *model.QuestionReferenceAnswers) error {
+	if referenceAnswer.ID == "" {
+		referenceAnswer.ID = uuid.New().String()
+	}
+	if err := r.db.Create(referenceAnswer).Error; err != nil {
+		return fmt.Errorf("解答例の作成に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// 質問テンプレートの解答例を取得（作成日時の古い順）
+func (r *questionReferenceAnswersRepository) GetReferenceAnswers(questionTemplateMasterID string) ([]model.QuestionReferenceAnswers, error) {
+	var referenceAnswers []model.QuestionReferenceAnswers
+	if err := r.db.Where("question_template_master_id = ?", questionTemplateMasterID).Order("created_at ASC").Find(&referenceAnswers).Error; err != nil {
+		return nil, fmt.Errorf("解答例の取得に失敗しました: %w", err)
+	}
+	return referenceAnswers, nil
+}
+
+func (r *questionReferenceAnswersRepository) GetReferenceAnswerByID(id string) (*model.QuestionReferenceAnswers, error) {
+	var referenceAnswer model.QuestionReferenceAnswers
+	if err := r.db.Where("id = ?", id).First(&referenceAnswer).Error; err != nil {
+		return nil, fmt.Errorf("解答例の取得に失敗しました: %w", err)
+	}
+	return &referenceAnswer, nil
+}
+
+func (r *questionReferenceAnswersRepository) UpdateReferenceAnswer(referenceAnswer *model.QuestionReferenceAnswers) error {
+	if err := r.db.Save(referenceAnswer).Error; err != nil {
+		return fmt.Errorf("解答例の更新に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// 解答例を論理削除（削除者も記録する）
+func (r *questionReferenceAnswersRepository) DeleteReferenceAnswer(referenceAnswer *model.QuestionReferenceAnswers) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(referenceAnswer).Update("deleted_by", referenceAnswer.DeletedBy).Error; err != nil {
+			return fmt.Errorf("解答例の削除に失敗しました: %w", err)
+		}
+		if err := tx.Delete(referenceAnswer).Error; err != nil {
+			return fmt.Errorf("解答例の削除に失敗しました: %w", err)
+		}
+		return nil
+	})
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/Takanpon2512/english-app/internal/model"
 	"gorm.io/gorm"
@@ -11,7 +12,12 @@ import (
 type QuestionTemplateMastersRepository interface {
 	GetQuestionTemplateMasters(req *model.GetQuestionTemplateMastersSearchRequest) (*model.GetQuestionTemplateMastersSearchResponse, error)
 	GetQuestionTemplateMasterByID(id string) (*model.QuestionTemplateMastersSummary, error)
+	GetQuestionTemplateMasterByIDAnyStatus(id string) (*model.QuestionTemplateMastersSummary, error)
 	GetQuestionTemplateMasterLLMById(id string) (*model.GetQuestionTemplateMastersLLMResponse, error)
+	GetQuestionTemplateTexts(categoryID string, questionType string) ([]string, error)
+	GetQuestionTemplateMastersByStatus(status string, limit int) ([]model.QuestionTemplateMasters, error)
+	CreateQuestionTemplateDrafts(questionTemplates []model.QuestionTemplateMasters, referenceAnswers []model.QuestionReferenceAnswers) error
+	UpdateQuestionTemplateStatus(id string, fromStatus string, toStatus string, userID string) (bool, error)
 }
 
 type questionTemplateMastersRepository struct {
@@ -26,7 +32,7 @@ func (r *questionTemplateMastersRepository) GetQuestionTemplateMasters(req *mode
 	var questionTemplateMasters []model.QuestionTemplateMasters
 	var total int64
 
-	query := r.db.Model(&model.QuestionTemplateMasters{}).Where("status = ?", "ACTIVE")
+	query := r.db.Model(&model.QuestionTemplateMasters{}).Where("status = ?", model.QuestionTemplateStatusActive)
 
 	// プロジェクトにすでに紐づいている質問テンプレート以外の質問テンプレートを取得
 	if req.ProjectID != "" {
@@ -114,10 +120,20 @@ func (r *questionTemplateMastersRepository) GetQuestionTemplateMasters(req *mode
 	}, nil
 }
 
+// 出題できる（ACTIVE）質問テンプレートをIDで取得（承認待ち・却下したものは存在しないものとして扱う）
 func (r *questionTemplateMastersRepository) GetQuestionTemplateMasterByID(id string) (*model.QuestionTemplateMastersSummary, error) {
+	return r.getQuestionTemplateMasterSummary(r.db.Where("id = ? AND status = ?", id, model.QuestionTemplateStatusActive))
+}
+
+// ステータスによらず質問テンプレートをIDで取得（管理者の確認や過去の解答の参照に使用する）
+func (r *questionTemplateMastersRepository) GetQuestionTemplateMasterByIDAnyStatus(id string) (*model.QuestionTemplateMastersSummary, error) {
+	return r.getQuestionTemplateMasterSummary(r.db.Where("id = ?", id))
+}
+
+func (r *questionTemplateMastersRepository) getQuestionTemplateMasterSummary(query *gorm.DB) (*model.QuestionTemplateMastersSummary, error) {
 	var questionTemplateMaster model.QuestionTemplateMasters
 
-	if err := r.db.Model(&model.QuestionTemplateMasters{}).Where("id = ?", id).First(&questionTemplateMaster).Error; err != nil {
+	if err := query.Model(&model.QuestionTemplateMasters{}).First(&questionTemplateMaster).Error; err != nil {
 		return nil, fmt.Errorf("質問テンプレートマスターの取得に失敗しました: %w", err)
 	}
 
@@ -176,3 +192,58 @@ func (r *questionTemplateMastersRepository) GetQuestionTemplateMasterLLMById(id
 		Points:        questionTemplateMaster.Points,
 	}, nil
 }
+
+// カテゴリ・問題の種類が同じ質問テンプレートの問題文を取得（却下したものを除く、作成日時の新しい順）
+func (r *questionTemplateMastersRepository) GetQuestionTemplateTexts(categoryID string, questionType string) ([]string, error) {
+	var texts []string
+	if err := r.db.Model(&model.QuestionTemplateMasters{}).
+		Where("category_id = ? AND question_type = ? AND status <> ?", categoryID, questionType, model.QuestionTemplateStatusRejected).
+		Order("created_at DESC").
+		Pluck("english", &texts).Error; err != nil {
+		return nil, fmt.Errorf("質問テンプレートマスターの取得に失敗しました: %w", err)
+	}
+
+	return texts, nil
+}
+
+// ステータスを指定して質問テンプレートを取得（作成日時の新しい順）
+func (r *questionTemplateMastersRepository) GetQuestionTemplateMastersByStatus(status string, limit int) ([]model.QuestionTemplateMasters, error) {
+	var questionTemplateMasters []model.QuestionTemplateMasters
+	if err := r.db.Where("status = ?", status).Order("created_at DESC").Limit(limit).Find(&questionTemplateMasters).Error; err != nil {
+		return nil, fmt.Errorf("質問テンプレートマスターの取得に失敗しました: %w", err)
+	}
+
+	return questionTemplateMasters, nil
+}
+
+// 下書きの質問テンプレートと解答例をまとめて作成
+func (r *questionTemplateMastersRepository) CreateQuestionTemplateDrafts(questionTemplates []model.QuestionTemplateMasters, referenceAnswers []model.QuestionReferenceAnswers) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&questionTemplates).Error; err != nil {
+			return fmt.Errorf("質問テンプレートマスターの作成に失敗しました: %w", err)
+		}
+		if len(referenceAnswers) > 0 {
+			if err := tx.Create(&referenceAnswers).Error; err != nil {
+				return fmt.Errorf("解答例の作成に失敗しました: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// 質問テンプレートのステータスを更新（更新者も記録する）
+// 同時に承認・却下された場合に上書きしないよう、ステータスがfromStatusの場合のみ更新し、更新したかどうかを返す
+func (r *questionTemplateMastersRepository) UpdateQuestionTemplateStatus(id string, fromStatus string, toStatus string, userID string) (bool, error) {
+	result := r.db.Model(&model.QuestionTemplateMasters{}).
+		Where("id = ? AND status = ?", id, fromStatus).
+		Updates(map[string]interface{}{
+			"status":     toStatus,
+			"updated_by": userID,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("質問テンプレートマスターのステータスの更新に失敗しました: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+type TutorThreadsRepository interface {
+	GetTutorThread(correctionResultID string) (*model.TutorThreads, error)
+	GetTutorMessages(threadID string) ([]model.TutorMessages, error)
+	CountTutorQuestionsSince(userID string, since time.Time) (int, error)
+	AppendTutorMessages(correctionResultID string, userID string, messages []model.TutorMessages, dailySince time.Time, check func(threadQuestions int, dailyQuestions int) error) (*model.TutorThreads, []model.TutorMessages, error)
+}
+
+type tutorThreadsRepository struct {
+	db *gorm.DB
+}
+
+func NewTutorThreadsRepository(db *gorm.DB) TutorThreadsRepository {
+	return &tutorThreadsRepository{db: db}
+}
+
+// 添削結果に対するチューターとの対話のスレッドを取得（まだ質問していない場合はnil）
+func (r *tutorThreadsRepository) GetTutorThread(correctionResultID string) (*model.TutorThreads, error) {
+	var threads []model.TutorThreads
+	if err := r.db.Where("correction_result_id = ?", correctionResultID).Limit(1).Find(&threads).Error; err != nil {
+		return nil, fmt.Errorf("チューターとの対話スレッドの取得に失敗しました: %w", err)
+	}
+	if len(threads) == 0 {
+		return nil, nil
+	}
+
+	return &threads[0], nil
+}
+
+// スレッドのメッセージを取得（古い順）
+func (r *tutorThreadsRepository) GetTutorMessages(threadID string) ([]model.TutorMessages, error) {
+	var messages []model.TutorMessages
+	if err := r.db.Where("thread_id = ?", threadID).Order("message_index ASC").Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("チューターとの対話メッセージの取得に失敗しました: %w", err)
+	}
+
+	return messages, nil
+}
+
+// 指定日時以降のユーザーのチューターへの質問数を取得
+func (r *tutorThreadsRepository) CountTutorQuestionsSince(userID string, since time.Time) (int, error) {
+	var count int64
+	if err := r.db.Model(&model.TutorMessages{}).
+		Where("user_id = ? AND role = ? AND created_at >= ?", userID, model.TutorRoleUser, since).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("チューターへの質問数の取得に失敗しました: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// スレッドにメッセージを追加する（スレッドがない場合は作成する）
+// 追加したメッセージには、スレッドの既存のメッセージに続く順番を振る
+// checkにはスレッドの質問数とdailySince以降のユーザーの質問数を渡し、エラーを返した場合は追加しない
+// 同じユーザーの質問の追加はユーザーの行をロックして順に行うため、同時に質問された場合も上限を超えない
+func (r *tutorThreadsRepository) AppendTutorMessages(correctionResultID string, userID string, messages []model.TutorMessages, dailySince time.Time, check func(threadQuestions int, dailyQuestions int) error) (*model.TutorThreads, []model.TutorMessages, error) {
+	now := time.Now()
+	var thread model.TutorThreads
+	records := make([]model.TutorMessages, 0, len(messages))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 質問数の確認と追加の間に同じユーザーの質問が追加されないよう、ユーザーをロックする
+		var user model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", userID).
+			First(&user).Error; err != nil {
+			return fmt.Errorf("ユーザーの取得に失敗しました: %w", err)
+		}
+
+		// 同じ添削結果に同時に質問された場合もスレッドが1つになるよう、作成済みの場合は作成しない
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.TutorThreads{
+			ID:                 uuid.New().String(),
+			CorrectionResultID: correctionResultID,
+			UserID:             userID,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+		}).Error; err != nil {
+			return fmt.Errorf("チューターとの対話スレッドの作成に失敗しました: %w", err)
+		}
+
+		// メッセージの順番が重複しないよう、スレッドをロックする
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("correction_result_id = ?", correctionResultID).
+			First(&thread).Error; err != nil {
+			return fmt.Errorf("チューターとの対話スレッドの取得に失敗しました: %w", err)
+		}
+
+		var lastIndex int
+		if err := tx.Model(&model.TutorMessages{}).
+			Where("thread_id = ?", thread.ID).
+			Select("COALESCE(MAX(message_index), 0)").
+			Scan(&lastIndex).Error; err != nil {
+			return fmt.Errorf("チューターとの対話メッセージの順番の取得に失敗しました: %w", err)
+		}
+
+		var threadQuestions, dailyQuestions int64
+		if err := tx.Model(&model.TutorMessages{}).
+			Where("thread_id = ? AND role = ?", thread.ID, model.TutorRoleUser).
+			Count(&threadQuestions).Error; err != nil {
+			return fmt.Errorf("チューターへの質問数の取得に失敗しました: %w", err)
+		}
+		if err := tx.Model(&model.TutorMessages{}).
+			Where("user_id = ? AND role = ? AND created_at >= ?", userID, model.TutorRoleUser, dailySince).
+			Count(&dailyQuestions).Error; err != nil {
+			return fmt.Errorf("チューターへの質問数の取得に失敗しました: %w", err)
+		}
+		if err := check(int(threadQuestions), int(dailyQuestions)); err != nil {
+			return err
+		}
+
+		for i, message := range messages {
+			message.ID = uuid.New().String()
+			message.ThreadID = thread.ID
+			message.UserID = thread.UserID
+			message.MessageIndex = lastIndex + i + 1
+			if message.CreatedAt.IsZero() {
+				message.CreatedAt = now
+			}
+			records = append(records, message)
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return fmt.Errorf("チューターとの対話メッセージの保存に失敗しました: %w", err)
+		}
+
+		thread.UpdatedAt = now
+		if err := tx.Model(&thread).Update("updated_at", now).Error; err != nil {
+			return fmt.Errorf("チューターとの対話スレッドの更新に失敗しました: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &thread, records, nil
+}
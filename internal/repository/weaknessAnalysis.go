@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
@@ -16,8 +15,12 @@ type WeaknessAnalysisRepository interface {
 	CreateWeaknessAnalysis(userId string, req *model.CreateWeaknessAnalysisRequest) (*model.CreateWeaknessAnalysisResponse, error)
 	GetWeaknessAnalysis(userId string, req *model.GetWeaknessAnalysisRequest) (*model.GetWeaknessAnalysisResponse, error)
 	GetWeaknessAnalysisStatusSummary(userId string, analysisId string) (*model.WeaknessAnalysisStatusSummary, error)
+	GetWeaknessAnalysesByStatus(status string) ([]model.WeaknessAnalysis, error)
 	UpdateAnalysisStatus(analysisId string, status string) error
+	UpdateAnalysisProgress(analysisId string, stage string, progress int) error
 	UpdateOverallScore(analysisId string, overallScore int) error
+	UpdateAnalysisVersion(analysisId string, analysisVersion string) error
+	UpdateLLMModel(analysisId string, llmModel string, llmProvider string) error
 }
 
 type weaknessAnalysisRepository struct {
@@ -38,14 +41,16 @@ func (r *weaknessAnalysisRepository) CreateWeaknessAnalysis(userId string, req *
 		ProjectID:       req.ProjectID,
 		UserID:          userId,       // 外部キー制約のため必須
 		AnalysisStatus:  "PROCESSING", // 初期状態は処理中
+		AnalysisStage:   model.AnalysisStageQueued,
+		Progress:        0,
 		OverallScore:    0,
 		ImprovementRate: 0,
 		AnalysisDate:    now,
 		AnalyzedAnswers: 0,
 		DataPeriodStart: now, // TODO: 実際のデータ期間を設定
 		DataPeriodEnd:   now, // TODO: 実際のデータ期間を設定
-		LLMModel:        string(anthropic.ModelClaude3_7Sonnet20250219),
-		AnalysisVersion: "1.0", // TODO: 設定から取得
+		LLMModel:        "",  // 分析の完了時に実際に使用したモデルを記録する
+		AnalysisVersion: "",  // 分析の実行時に使用したプロンプトのバージョンを記録する
 		CreatedAt:       now,
 		UpdatedAt:       now,
 		CreatedBy:       userId,
@@ -62,6 +67,8 @@ func (r *weaknessAnalysisRepository) CreateWeaknessAnalysis(userId string, req *
 		ID:             weaknessAnalysis.ID,
 		ProjectID:      weaknessAnalysis.ProjectID,
 		AnalysisStatus: weaknessAnalysis.AnalysisStatus,
+		AnalysisStage:  weaknessAnalysis.AnalysisStage,
+		Progress:       weaknessAnalysis.Progress,
 		OverallScore:   weaknessAnalysis.OverallScore,
 	}
 
@@ -154,6 +161,40 @@ func (r *weaknessAnalysisRepository) UpdateAnalysisStatus(analysisId string, sta
 	return nil
 }
 
+// UpdateAnalysisProgress 分析の処理ステージと進捗率を更新する
+func (r *weaknessAnalysisRepository) UpdateAnalysisProgress(analysisId string, stage string, progress int) error {
+	now := time.Now()
+
+	result := r.db.Model(&model.WeaknessAnalysis{}).
+		Where("id = ?", analysisId).
+		Updates(map[string]interface{}{
+			"analysis_stage": stage,
+			"progress":       progress,
+			"updated_at":     now,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update analysis progress: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("analysis not found with id: %s", analysisId)
+	}
+
+	return nil
+}
+
+// GetWeaknessAnalysesByStatus ステータスを指定して分析を取得する
+func (r *weaknessAnalysisRepository) GetWeaknessAnalysesByStatus(status string) ([]model.WeaknessAnalysis, error) {
+	var weaknessAnalyses []model.WeaknessAnalysis
+
+	if err := r.db.Where("analysis_status = ?", status).Order("created_at ASC").Find(&weaknessAnalyses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get weakness analyses by status: %w", err)
+	}
+
+	return weaknessAnalyses, nil
+}
+
 // UpdateOverallScore 総合スコアを更新する
 func (r *weaknessAnalysisRepository) UpdateOverallScore(analysisId string, overallScore int) error {
 	now := time.Now()
@@ -176,6 +217,51 @@ func (r *weaknessAnalysisRepository) UpdateOverallScore(analysisId string, overa
 	return nil
 }
 
+// UpdateAnalysisVersion 分析に使用したプロンプトのバージョンを更新する
+func (r *weaknessAnalysisRepository) UpdateAnalysisVersion(analysisId string, analysisVersion string) error {
+	now := time.Now()
+
+	result := r.db.Model(&model.WeaknessAnalysis{}).
+		Where("id = ?", analysisId).
+		Updates(map[string]interface{}{
+			"analysis_version": analysisVersion,
+			"updated_at":       now,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update analysis version: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("analysis not found with id: %s", analysisId)
+	}
+
+	return nil
+}
+
+// UpdateLLMModel 分析に使用したLLMモデルと応答したプロバイダを更新する
+func (r *weaknessAnalysisRepository) UpdateLLMModel(analysisId string, llmModel string, llmProvider string) error {
+	now := time.Now()
+
+	result := r.db.Model(&model.WeaknessAnalysis{}).
+		Where("id = ?", analysisId).
+		Updates(map[string]interface{}{
+			"llm_model":    llmModel,
+			"llm_provider": llmProvider,
+			"updated_at":   now,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update llm model: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("analysis not found with id: %s", analysisId)
+	}
+
+	return nil
+}
+
 // GetWeaknessAnalysisStatusSummary 分析状況のサマリーを取得する
 func (r *weaknessAnalysisRepository) GetWeaknessAnalysisStatusSummary(userId string, analysisId string) (*model.WeaknessAnalysisStatusSummary, error) {
 	var weaknessAnalysis model.WeaknessAnalysis
@@ -192,5 +278,8 @@ func (r *weaknessAnalysisRepository) GetWeaknessAnalysisStatusSummary(userId str
 		ProjectID:      weaknessAnalysis.ProjectID,
 		UserID:         weaknessAnalysis.UserID,
 		AnalysisStatus: weaknessAnalysis.AnalysisStatus,
+		AnalysisStage:  weaknessAnalysis.AnalysisStage,
+		Progress:       weaknessAnalysis.Progress,
+		UpdatedAt:      weaknessAnalysis.UpdatedAt,
 	}, nil
 }
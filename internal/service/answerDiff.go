@@ -0,0 +1,238 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+// maxDiffTokens 前後の一致する部分を除いて単語単位で差分を計算するトークン数の上限（超える場合は全体を置換として扱う）
+// 最長共通部分列の表は (n+1)×(m+1) 要素になるため、1回の差分で数百KB程度に収まるようにする
+const maxDiffTokens = 400
+
+// diffToken 差分の比較単位（単語または句読点）
+type diffToken struct {
+	Text  string
+	Start int // 文字列の先頭からの文字数
+	End   int
+	Punct bool
+}
+
+// tokenizeForDiff 文字列を単語と句読点に分割する（空白は比較しない）
+// 単語中のアポストロフィとハイフン（don't / well-known）は単語の一部として扱う
+func tokenizeForDiff(text string) []diffToken {
+	runes := []rune(text)
+
+	var tokens []diffToken
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && (isWordRune(runes[i]) || (isWordJoiner(runes[i]) && i+1 < len(runes) && isWordRune(runes[i+1]))) {
+				i++
+			}
+			tokens = append(tokens, diffToken{Text: string(runes[start:i]), Start: start, End: i})
+		default:
+			tokens = append(tokens, diffToken{Text: string(r), Start: i, End: i + 1, Punct: true})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWordJoiner(r rune) bool {
+	return r == '\'' || r == '’' || r == '-'
+}
+
+// diffAnswers 2つの文字列の単語単位の差分を計算する
+// 変更のない部分も含めて比較元・比較先の順に操作を返すため、操作を順に並べると両方の文字列を再構成できる
+func diffAnswers(from string, to string) []model.AnswerDiffOp {
+	fromRunes, toRunes := []rune(from), []rune(to)
+	fromTokens, toTokens := tokenizeForDiff(from), tokenizeForDiff(to)
+
+	builder := &diffBuilder{fromRunes: fromRunes, toRunes: toRunes, ops: []model.AnswerDiffOp{}}
+
+	// 前後の一致するトークンは最長共通部分列を計算せずに変更なしとする
+	head := 0
+	for head < len(fromTokens) && head < len(toTokens) && fromTokens[head].Text == toTokens[head].Text {
+		builder.equal(fromTokens[head], toTokens[head])
+		head++
+	}
+	tail := 0
+	for tail < len(fromTokens)-head && tail < len(toTokens)-head && fromTokens[len(fromTokens)-1-tail].Text == toTokens[len(toTokens)-1-tail].Text {
+		tail++
+	}
+	builder.middle(fromTokens[head:len(fromTokens)-tail], toTokens[head:len(toTokens)-tail])
+	for k := tail; k > 0; k-- {
+		builder.equal(fromTokens[len(fromTokens)-k], toTokens[len(toTokens)-k])
+	}
+	return builder.ops
+}
+
+// diffBuilder 差分の操作を組み立てる（連続する変更なしの操作は1つにまとめる）
+type diffBuilder struct {
+	fromRunes []rune
+	toRunes   []rune
+	ops       []model.AnswerDiffOp
+}
+
+// middle 最長共通部分列で一致するトークンを求め、その間の削除・追加を変更の操作にする
+func (b *diffBuilder) middle(fromTokens []diffToken, toTokens []diffToken) {
+	n, m := len(fromTokens), len(toTokens)
+	if n > maxDiffTokens || m > maxDiffTokens {
+		b.change(fromTokens, toTokens)
+		return
+	}
+
+	// 最長共通部分列（大文字小文字・句読点を区別して一致を判定する）
+	// lcs[i*(m+1)+j] は fromTokens[i:] と toTokens[j:] の最長共通部分列の長さ（maxDiffTokens以下のためuint16に収まる）
+	width := m + 1
+	lcs := make([]uint16, (n+1)*width)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if fromTokens[i].Text == toTokens[j].Text {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	// 一致するトークンの間にある削除・追加をまとめて1つの変更として扱う
+	i, j := 0, 0
+	fromChanged, toChanged := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && fromTokens[i].Text == toTokens[j].Text:
+			b.change(fromTokens[fromChanged:i], toTokens[toChanged:j])
+			b.equal(fromTokens[i], toTokens[j])
+			i++
+			j++
+			fromChanged, toChanged = i, j
+		case j < m && (i == n || lcs[i*width+j+1] >= lcs[(i+1)*width+j]):
+			j++
+		default:
+			i++
+		}
+	}
+	b.change(fromTokens[fromChanged:], toTokens[toChanged:])
+}
+
+func (b *diffBuilder) equal(from diffToken, to diffToken) {
+	if last := len(b.ops) - 1; last >= 0 && b.ops[last].Op == model.DiffOpEqual {
+		b.ops[last].FromEnd = from.End
+		b.ops[last].ToEnd = to.End
+		b.ops[last].From = string(b.fromRunes[b.ops[last].FromStart:from.End])
+		b.ops[last].To = string(b.toRunes[b.ops[last].ToStart:to.End])
+		return
+	}
+	b.ops = append(b.ops, model.AnswerDiffOp{
+		Op:        model.DiffOpEqual,
+		From:      from.Text,
+		To:        to.Text,
+		FromStart: from.Start,
+		FromEnd:   from.End,
+		ToStart:   to.Start,
+		ToEnd:     to.End,
+	})
+}
+
+// change 一致するトークンに挟まれた削除・追加を操作にする
+// 前後の大文字小文字のみが異なるトークンは1語ずつの置換（完全に一致するトークンは変更なし）とし、残りをまとめて1つの操作にする
+func (b *diffBuilder) change(from []diffToken, to []diffToken) {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && strings.EqualFold(from[prefix].Text, to[prefix].Text) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && strings.EqualFold(from[len(from)-1-suffix].Text, to[len(to)-1-suffix].Text) {
+		suffix++
+	}
+
+	for k := 0; k < prefix; k++ {
+		b.caseChange(from[k], to[k])
+	}
+	b.words(from[prefix:len(from)-suffix], to[prefix:len(to)-suffix])
+	for k := suffix; k > 0; k-- {
+		b.caseChange(from[len(from)-k], to[len(to)-k])
+	}
+}
+
+// caseChange 大文字小文字のみが異なるトークンを置換にする（完全に一致する場合は変更なし）
+func (b *diffBuilder) caseChange(from diffToken, to diffToken) {
+	if from.Text == to.Text {
+		b.equal(from, to)
+		return
+	}
+	b.ops = append(b.ops, b.op(model.DiffOpReplace, model.DiffKindCase, []diffToken{from}, []diffToken{to}))
+}
+
+// words 単語・句読点の削除・追加・置換を1つの操作にする
+func (b *diffBuilder) words(from []diffToken, to []diffToken) {
+	if len(from) == 0 && len(to) == 0 {
+		return
+	}
+
+	kind := model.DiffKindWord
+	if allPunct(from) && allPunct(to) {
+		kind = model.DiffKindPunctuation
+	}
+	switch {
+	case len(from) == 0:
+		b.ops = append(b.ops, b.op(model.DiffOpInsert, kind, from, to))
+	case len(to) == 0:
+		b.ops = append(b.ops, b.op(model.DiffOpDelete, kind, from, to))
+	default:
+		b.ops = append(b.ops, b.op(model.DiffOpReplace, kind, from, to))
+	}
+}
+
+// op トークンの範囲の元の文字列（間の空白を含む）から操作を作成する
+// 削除・追加の場合は、もう一方の文字列の挿入位置を開始位置・終了位置に設定する
+func (b *diffBuilder) op(op string, kind string, from []diffToken, to []diffToken) model.AnswerDiffOp {
+	fromStart, fromEnd := b.position(from, true)
+	toStart, toEnd := b.position(to, false)
+	return model.AnswerDiffOp{
+		Op:        op,
+		Kind:      kind,
+		From:      string(b.fromRunes[fromStart:fromEnd]),
+		To:        string(b.toRunes[toStart:toEnd]),
+		FromStart: fromStart,
+		FromEnd:   fromEnd,
+		ToStart:   toStart,
+		ToEnd:     toEnd,
+	}
+}
+
+// position トークンの範囲の位置（空の場合は直前の操作の終了位置）
+func (b *diffBuilder) position(tokens []diffToken, from bool) (int, int) {
+	if len(tokens) > 0 {
+		return tokens[0].Start, tokens[len(tokens)-1].End
+	}
+	if len(b.ops) == 0 {
+		return 0, 0
+	}
+	last := b.ops[len(b.ops)-1]
+	if from {
+		return last.FromEnd, last.FromEnd
+	}
+	return last.ToEnd, last.ToEnd
+}
+
+// allPunct トークンがすべて句読点かどうか
+func allPunct(tokens []diffToken) bool {
+	for _, t := range tokens {
+		if !t.Punct {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+func TestTokenizeForDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []diffToken
+	}{
+		{name: "空文字", text: "", want: nil},
+		{name: "空白のみ", text: "  \n", want: nil},
+		{
+			name: "アポストロフィ・ハイフンを含む単語と句読点",
+			text: "I don't know well-known facts, ok?",
+			want: []diffToken{
+				{Text: "I", Start: 0, End: 1},
+				{Text: "don't", Start: 2, End: 7},
+				{Text: "know", Start: 8, End: 12},
+				{Text: "well-known", Start: 13, End: 23},
+				{Text: "facts", Start: 24, End: 29},
+				{Text: ",", Start: 29, End: 30, Punct: true},
+				{Text: "ok", Start: 31, End: 33},
+				{Text: "?", Start: 33, End: 34, Punct: true},
+			},
+		},
+		{
+			name: "単語の前後のアポストロフィ・ハイフンは句読点",
+			text: "'hi' -ok",
+			want: []diffToken{
+				{Text: "'", Start: 0, End: 1, Punct: true},
+				{Text: "hi", Start: 1, End: 3},
+				{Text: "'", Start: 3, End: 4, Punct: true},
+				{Text: "-", Start: 5, End: 6, Punct: true},
+				{Text: "ok", Start: 6, End: 8},
+			},
+		},
+		{
+			name: "位置は文字数で数える",
+			text: "café au lait",
+			want: []diffToken{
+				{Text: "café", Start: 0, End: 4},
+				{Text: "au", Start: 5, End: 7},
+				{Text: "lait", Start: 8, End: 12},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenizeForDiff(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenizeForDiff(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffAnswers(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []model.AnswerDiffOp
+	}{
+		{name: "どちらも空", from: "", to: "", want: []model.AnswerDiffOp{}},
+		{
+			name: "変更なし",
+			from: "I went home.",
+			to:   "I went home.",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpEqual, From: "I went home.", To: "I went home.", FromStart: 0, FromEnd: 12, ToStart: 0, ToEnd: 12},
+			},
+		},
+		{
+			name: "比較元が空",
+			from: "",
+			to:   "Hello.",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpInsert, Kind: model.DiffKindWord, From: "", To: "Hello.", FromStart: 0, FromEnd: 0, ToStart: 0, ToEnd: 6},
+			},
+		},
+		{
+			name: "比較先が空",
+			from: "Hi.",
+			to:   "",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpDelete, Kind: model.DiffKindWord, From: "Hi.", To: "", FromStart: 0, FromEnd: 3, ToStart: 0, ToEnd: 0},
+			},
+		},
+		{
+			name: "単語の置換",
+			from: "I go to school.",
+			to:   "I went to school.",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpEqual, From: "I", To: "I", FromStart: 0, FromEnd: 1, ToStart: 0, ToEnd: 1},
+				{Op: model.DiffOpReplace, Kind: model.DiffKindWord, From: "go", To: "went", FromStart: 2, FromEnd: 4, ToStart: 2, ToEnd: 6},
+				{Op: model.DiffOpEqual, From: "to school.", To: "to school.", FromStart: 5, FromEnd: 15, ToStart: 7, ToEnd: 17},
+			},
+		},
+		{
+			name: "単語の追加",
+			from: "I went home.",
+			to:   "I went back home.",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpEqual, From: "I went", To: "I went", FromStart: 0, FromEnd: 6, ToStart: 0, ToEnd: 6},
+				{Op: model.DiffOpInsert, Kind: model.DiffKindWord, From: "", To: "back", FromStart: 6, FromEnd: 6, ToStart: 7, ToEnd: 11},
+				{Op: model.DiffOpEqual, From: "home.", To: "home.", FromStart: 7, FromEnd: 12, ToStart: 12, ToEnd: 17},
+			},
+		},
+		{
+			name: "単語の削除",
+			from: "I went back home.",
+			to:   "I went home.",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpEqual, From: "I went", To: "I went", FromStart: 0, FromEnd: 6, ToStart: 0, ToEnd: 6},
+				{Op: model.DiffOpDelete, Kind: model.DiffKindWord, From: "back", To: "", FromStart: 7, FromEnd: 11, ToStart: 6, ToEnd: 6},
+				{Op: model.DiffOpEqual, From: "home.", To: "home.", FromStart: 12, FromEnd: 17, ToStart: 7, ToEnd: 12},
+			},
+		},
+		{
+			name: "大文字小文字のみの変更",
+			from: "i went home.",
+			to:   "I went home.",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpReplace, Kind: model.DiffKindCase, From: "i", To: "I", FromStart: 0, FromEnd: 1, ToStart: 0, ToEnd: 1},
+				{Op: model.DiffOpEqual, From: "went home.", To: "went home.", FromStart: 2, FromEnd: 12, ToStart: 2, ToEnd: 12},
+			},
+		},
+		{
+			name: "句読点のみの変更",
+			from: "I went home.",
+			to:   "I went home!",
+			want: []model.AnswerDiffOp{
+				{Op: model.DiffOpEqual, From: "I went home", To: "I went home", FromStart: 0, FromEnd: 11, ToStart: 0, ToEnd: 11},
+				{Op: model.DiffOpReplace, Kind: model.DiffKindPunctuation, From: ".", To: "!", FromStart: 11, FromEnd: 12, ToStart: 11, ToEnd: 12},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffAnswers(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffAnswers(%q, %q) =\n%+v\nwant\n%+v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+// 前後の一致する部分を除いたトークン数がmaxDiffTokensを超える場合は、全体を1つの置換として扱う
+func TestDiffAnswersTooManyTokens(t *testing.T) {
+	words := func(n int) string {
+		ws := make([]string, n)
+		for i := range ws {
+			ws[i] = fmt.Sprintf("w%d", i)
+		}
+		return strings.Join(ws, " ")
+	}
+
+	tests := []struct {
+		name    string
+		common  int // 先頭・末尾の異なる単語に挟まれた共通の単語数
+		wantOps int
+	}{
+		{name: "上限以下は共通の単語を変更なしにする", common: maxDiffTokens - 2, wantOps: 3},
+		{name: "上限を超えると全体を置換にする", common: maxDiffTokens - 1, wantOps: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := "first " + words(tt.common) + " last"
+			to := "begin " + words(tt.common) + " end"
+
+			got := diffAnswers(from, to)
+			if len(got) != tt.wantOps {
+				t.Fatalf("操作の数 = %d, want %d", len(got), tt.wantOps)
+			}
+			if tt.wantOps == 1 {
+				want := model.AnswerDiffOp{Op: model.DiffOpReplace, Kind: model.DiffKindWord, From: from, To: to, FromEnd: len(from), ToEnd: len(to)}
+				if got[0] != want {
+					t.Errorf("操作 = %+v, want 全体の置換", got[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+// 採点に使うLLMの利用上限を超えていないか確認する（超えている場合は llm.ErrQuotaExceeded）
+// 挑戦の解答を完了にする前に確認し、完了にしたのに採点できない状態を避ける
+func (s *correctResultsService) CheckGradingQuota(ctx context.Context, userID string) error {
+	return s.quota.CheckQuota(ctx, userID)
+}
+
+// 挑戦の解答をまとめて採点し、挑戦全体の得点を返す（challengeCountが0の場合は完了した最新の挑戦）
+// 挑戦のすべての解答のうち、正式な採点が完了していない解答（未採点・採点に失敗した解答）のみを
+// 最大 batchConcurrency 件ずつ並行して採点し、採点済みの解答はそのまま集計する
+func (s *correctResultsService) GradeChallenge(ctx context.Context, userID string, projectID string, challengeCount int) (*model.ChallengeGradingSummary, error) {
+	// LLMの利用上限を超えている場合は受け付けない
+	if err := s.quota.CheckQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if challengeCount == 0 {
+		latest, err := s.latestFinishedChallenge(projectID)
+		if err != nil {
+			return nil, err
+		}
+		challengeCount = latest
+	}
+	questionAnswers, err := s.questionAnswersRepo.GetQuestionAnswersByChallenge(projectID, challengeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.ChallengeGradingSummary{
+		ProjectID:      projectID,
+		ChallengeCount: challengeCount,
+		Results:        []model.ChallengeGradingItem{},
+	}
+	var pending []int
+	for _, questionAnswer := range questionAnswers {
+		// 本人の解答のみを採点する
+		if questionAnswer.UserID != userID {
+			continue
+		}
+		questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterLLMById(questionAnswer.QuestionTemplateMasterID)
+		if err != nil {
+			return nil, err
+		}
+		item := model.ChallengeGradingItem{
+			QuestionAnswerID:         questionAnswer.ID,
+			QuestionTemplateMasterID: questionAnswer.QuestionTemplateMasterID,
+			MaxPoints:                questionTemplateMaster.Points,
+		}
+
+		correctionResult, needsGrading, err := s.repo.PrepareChallengeGrading(&questionAnswer)
+		if err != nil {
+			return nil, err
+		}
+		item.CorrectionResultID = correctionResult.ID
+		item.Status = correctionResult.Status
+		item.GetPoints = correctionResult.GetPoints
+		item.CorrectRate = correctionResult.CorrectRate
+		if needsGrading {
+			pending = append(pending, len(summary.Results))
+		}
+		summary.Results = append(summary.Results, item)
+	}
+
+	// 未採点の解答を並行して採点する（失敗した解答はFAILED、中断した解答はワーカーに引き継ぐ）
+	sem := make(chan struct{}, max(s.batchConcurrency, 1))
+	var wg sync.WaitGroup
+	for _, i := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			item := &summary.Results[i]
+			item.Graded = true
+			res, err := s.gradeInRequest(ctx, userID, item.CorrectionResultID, nil)
+			switch {
+			case err == nil:
+				item.Status = res.Status
+				item.GetPoints = res.GetPoints
+				item.CorrectRate = res.CorrectRate
+			case ctx.Err() != nil:
+				item.Status = "PROCESSING"
+				item.Error = err.Error()
+			default:
+				item.Status = "FAILED"
+				item.Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, item := range summary.Results {
+		summary.MaxPoints += item.MaxPoints
+		switch item.Status {
+		case "COMPLETED":
+			summary.Completed++
+			summary.TotalPoints += item.GetPoints
+		case "FAILED":
+			summary.Failed++
+		default:
+			summary.Processing++
+		}
+	}
+	if summary.MaxPoints > 0 {
+		summary.CorrectRate = int(math.Round(float64(summary.TotalPoints) * 100 / float64(summary.MaxPoints)))
+	}
+	log.Printf("プロジェクト %s の挑戦 %d を一括採点しました（採点: %d件, 完了: %d件, 失敗: %d件）", summary.ProjectID, summary.ChallengeCount, len(pending), summary.Completed, summary.Failed)
+
+	return summary, nil
+}
+
+// latestFinishedChallenge プロジェクトで完了した最新の挑戦回数を返す（完了した挑戦がない場合は0）
+func (s *correctResultsService) latestFinishedChallenge(projectID string) (int, error) {
+	finished, err := s.questionAnswersRepo.GetQuestionAnswersByProjectIDAndStatus(projectID, "FINISHED")
+	if err != nil {
+		return 0, err
+	}
+	latest := 0
+	for _, questionAnswer := range finished {
+		latest = max(latest, questionAnswer.ChallengeCount)
+	}
+	return latest, nil
+}
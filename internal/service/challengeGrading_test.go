@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/repository"
+)
+
+// stubChallengeCorrectResultsRepository 解答ごとの正式な添削結果をメモリで管理するCorrectResultsRepository
+type stubChallengeCorrectResultsRepository struct {
+	repository.CorrectResultsRepository
+
+	mu      sync.Mutex
+	results map[string]*model.CorrectionResults // 解答IDごとの正式な添削結果
+}
+
+func (r *stubChallengeCorrectResultsRepository) PrepareChallengeGrading(questionAnswer *model.QuestionAnswers) (*model.CorrectionResults, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result, ok := r.results[questionAnswer.ID]
+	switch {
+	case !ok:
+		result = &model.CorrectionResults{
+			ID:                       "result-" + questionAnswer.ID,
+			QuestionAnswerID:         questionAnswer.ID,
+			QuestionTemplateMasterID: questionAnswer.QuestionTemplateMasterID,
+			ProjectID:                questionAnswer.ProjectID,
+			Status:                   "PROCESSING",
+			ChallengeCount:           questionAnswer.ChallengeCount,
+			GradingVersion:           1,
+			IsAuthoritative:          true,
+			GradingMode:              model.GradingModeStandard,
+		}
+		r.results[questionAnswer.ID] = result
+	case result.Status == "FAILED":
+		result.Status = "PROCESSING"
+	default:
+		copied := *result
+		return &copied, false, nil
+	}
+	copied := *result
+	return &copied, true, nil
+}
+
+func (r *stubChallengeCorrectResultsRepository) GetCorrectionResultById(id string) (*model.CorrectionResults, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, result := range r.results {
+		if result.ID == id {
+			copied := *result
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("添削結果 %s がありません", id)
+}
+
+func (r *stubChallengeCorrectResultsRepository) SaveRubricScores(tx *gorm.DB, correctionResultID string, scores []model.CorrectionRubricScores) error {
+	return nil
+}
+
+func (r *stubChallengeCorrectResultsRepository) SaveCorrectionErrors(tx *gorm.DB, correctionResultID string, correctionErrors []model.CorrectionErrors) error {
+	return nil
+}
+
+func (r *stubChallengeCorrectResultsRepository) CompleteCorrectionResult(tx *gorm.DB, req *model.UpdateCorrectionResultRequest) error {
+	return r.update(req)
+}
+
+func (r *stubChallengeCorrectResultsRepository) UpdateCorrectionResult(req *model.UpdateCorrectionResultRequest) (*model.UpdateCorrectionResultResponse, error) {
+	if err := r.update(req); err != nil {
+		return nil, err
+	}
+	return &model.UpdateCorrectionResultResponse{ID: req.ID}, nil
+}
+
+func (r *stubChallengeCorrectResultsRepository) update(req *model.UpdateCorrectionResultRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, result := range r.results {
+		if result.ID == req.ID {
+			result.Status = req.Status
+			result.GetPoints = req.GetPoints
+			result.CorrectRate = req.CorrectRate
+			return nil
+		}
+	}
+	return fmt.Errorf("添削結果 %s がありません", req.ID)
+}
+
+// status 解答の正式な添削結果のステータス
+func (r *stubChallengeCorrectResultsRepository) status(questionAnswerID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.results[questionAnswerID].Status
+}
+
+// stubChallengeAnswersRepository 指定した解答を返すQuestionAnswersRepository
+type stubChallengeAnswersRepository struct {
+	repository.QuestionAnswersRepository
+
+	answers []model.QuestionAnswers
+}
+
+func (r *stubChallengeAnswersRepository) GetQuestionAnswerById(id string) (*model.QuestionAnswers, error) {
+	for _, answer := range r.answers {
+		if answer.ID == id {
+			return &answer, nil
+		}
+	}
+	return nil, fmt.Errorf("解答 %s がありません", id)
+}
+
+func (r *stubChallengeAnswersRepository) GetQuestionAnswersByChallenge(projectID string, challengeCount int) ([]model.QuestionAnswers, error) {
+	var answers []model.QuestionAnswers
+	for _, answer := range r.answers {
+		if answer.ProjectID == projectID && answer.ChallengeCount == challengeCount {
+			answers = append(answers, answer)
+		}
+	}
+	return answers, nil
+}
+
+func (r *stubChallengeAnswersRepository) GetQuestionAnswersByProjectIDAndStatus(projectID, status string) ([]model.QuestionAnswers, error) {
+	var answers []model.QuestionAnswers
+	for _, answer := range r.answers {
+		if answer.ProjectID == projectID && answer.Status == status {
+			answers = append(answers, answer)
+		}
+	}
+	return answers, nil
+}
+
+// batchClient 指定した添削結果の採点を失敗させ、同時に処理中の採点数の最大値を記録するLLMClient
+type batchClient struct {
+	next  llm.LLMClient
+	delay time.Duration
+
+	mu          sync.Mutex
+	failing     map[string]bool // 採点を失敗させる添削結果のID
+	inFlight    int
+	maxInFlight int
+}
+
+func (c *batchClient) Generate(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+	c.mu.Lock()
+	fail := c.failing[req.SubjectID]
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	time.Sleep(c.delay)
+	if fail {
+		return nil, errors.New("採点できませんでした")
+	}
+	return c.next.Generate(ctx, req)
+}
+
+// testChallengeAnswer プロジェクト project-1 の解答
+func testChallengeAnswer(id string, userID string, challengeCount int) model.QuestionAnswers {
+	return model.QuestionAnswers{
+		ID:                       id,
+		UserID:                   userID,
+		ProjectID:                "project-1",
+		QuestionTemplateMasterID: "template-" + id,
+		UserAnswer:               "I went to see a movie with my friends yesterday.",
+		ChallengeCount:           challengeCount,
+		Status:                   "FINISHED",
+	}
+}
+
+func TestGradeChallenge(t *testing.T) {
+	repo := &stubChallengeCorrectResultsRepository{results: map[string]*model.CorrectionResults{
+		"graded": {ID: "result-graded", QuestionAnswerID: "graded", QuestionTemplateMasterID: "template-graded", Status: "COMPLETED", GetPoints: 6, CorrectRate: 60, ChallengeCount: 2},
+		"failed": {ID: "result-failed", QuestionAnswerID: "failed", QuestionTemplateMasterID: "template-failed", Status: "FAILED", ChallengeCount: 2, GradingVersion: 1, IsAuthoritative: true, GradingMode: model.GradingModeStandard},
+	}}
+	answers := &stubChallengeAnswersRepository{answers: []model.QuestionAnswers{
+		testChallengeAnswer("graded", "user-1", 2),
+		testChallengeAnswer("ungraded", "user-1", 2),
+		testChallengeAnswer("failed", "user-1", 2),
+		testChallengeAnswer("broken", "user-1", 2),
+		testChallengeAnswer("other-user", "user-2", 2),
+		testChallengeAnswer("previous", "user-1", 1),
+	}}
+	fake := llm.NewFakeClient()
+	client := &batchClient{next: fake, failing: map[string]bool{"result-broken": true}}
+	s := newTestCorrectResultsServiceWith(t, repo, answers, client, 2)
+
+	// 採点済みの解答はそのまま集計し、未採点・採点に失敗した解答を採点する（配点は各10点）
+	summary, err := s.GradeChallenge(context.Background(), "user-1", "project-1", 2)
+	if err != nil {
+		t.Fatalf("一括採点に失敗しました: %v", err)
+	}
+	want := map[string]struct {
+		status string
+		points int
+		graded bool
+	}{
+		"graded":   {status: "COMPLETED", points: 6},
+		"ungraded": {status: "COMPLETED", points: 8, graded: true},
+		"failed":   {status: "COMPLETED", points: 8, graded: true},
+		"broken":   {status: "FAILED", graded: true},
+	}
+	if len(summary.Results) != len(want) {
+		t.Fatalf("採点した解答の数 = %d, want %d", len(summary.Results), len(want))
+	}
+	for _, item := range summary.Results {
+		w, ok := want[item.QuestionAnswerID]
+		if !ok {
+			t.Errorf("対象外の解答 %s を採点しています", item.QuestionAnswerID)
+			continue
+		}
+		if item.Status != w.status || item.GetPoints != w.points || item.Graded != w.graded || item.MaxPoints != 10 {
+			t.Errorf("解答 %s の結果 = %+v, want %+v", item.QuestionAnswerID, item, w)
+		}
+		if (item.Status == "FAILED") != (item.Error != "") {
+			t.Errorf("解答 %s のエラー = %q", item.QuestionAnswerID, item.Error)
+		}
+	}
+	if summary.ChallengeCount != 2 || summary.TotalPoints != 22 || summary.MaxPoints != 40 || summary.CorrectRate != 55 ||
+		summary.Completed != 3 || summary.Failed != 1 || summary.Processing != 0 {
+		t.Errorf("集計 = %+v, want 22/40点・55%%・完了3件・失敗1件", *summary)
+	}
+	if calls := fake.Calls(); len(calls) != 2 {
+		t.Errorf("LLMの呼び出し回数 = %d, want 2", len(calls))
+	}
+	if status := repo.status("broken"); status != "FAILED" {
+		t.Errorf("採点に失敗した添削結果のステータス = %s, want FAILED", status)
+	}
+
+	// 挑戦回数を指定しない場合は完了した最新の挑戦を対象とし、採点に失敗した解答のみを採点し直す
+	client.mu.Lock()
+	client.failing = nil
+	client.mu.Unlock()
+	summary, err = s.GradeChallenge(context.Background(), "user-1", "project-1", 0)
+	if err != nil {
+		t.Fatalf("一括採点の再試行に失敗しました: %v", err)
+	}
+	if summary.ChallengeCount != 2 || summary.TotalPoints != 30 || summary.CorrectRate != 75 || summary.Completed != 4 || summary.Failed != 0 {
+		t.Errorf("再試行後の集計 = %+v, want 30/40点・75%%・完了4件", *summary)
+	}
+	for _, item := range summary.Results {
+		if item.Graded != (item.QuestionAnswerID == "broken") {
+			t.Errorf("再試行で解答 %s を採点したか = %v", item.QuestionAnswerID, item.Graded)
+		}
+	}
+	if calls := fake.Calls(); len(calls) != 3 {
+		t.Errorf("再試行後のLLMの呼び出し回数 = %d, want 3", len(calls))
+	}
+}
+
+// 未採点の解答はGRADING_BATCH_CONCURRENCY件ずつ並行して採点する
+func TestGradeChallengeConcurrency(t *testing.T) {
+	const concurrency = 2
+
+	var questionAnswers []model.QuestionAnswers
+	for i := range 6 {
+		questionAnswers = append(questionAnswers, testChallengeAnswer(fmt.Sprintf("answer-%d", i), "user-1", 1))
+	}
+	repo := &stubChallengeCorrectResultsRepository{results: map[string]*model.CorrectionResults{}}
+	client := &batchClient{next: llm.NewFakeClient(), delay: 20 * time.Millisecond}
+	s := newTestCorrectResultsServiceWith(t, repo, &stubChallengeAnswersRepository{answers: questionAnswers}, client, concurrency)
+
+	summary, err := s.GradeChallenge(context.Background(), "user-1", "project-1", 1)
+	if err != nil {
+		t.Fatalf("一括採点に失敗しました: %v", err)
+	}
+	if summary.Completed != len(questionAnswers) {
+		t.Errorf("完了した解答の数 = %d, want %d", summary.Completed, len(questionAnswers))
+	}
+	if client.maxInFlight != concurrency {
+		t.Errorf("同時に採点した解答の最大数 = %d, want %d", client.maxInFlight, concurrency)
+	}
+}
@@ -2,25 +2,67 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"strings"
+	"unicode/utf8"
 
-	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/anthropics/anthropic-sdk-go/option"
 	"gorm.io/gorm"
 
+	"github.com/Takanpon2512/english-app/internal/llm"
 	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
 	"github.com/Takanpon2512/english-app/internal/repository"
+	"github.com/Takanpon2512/english-app/internal/spelling"
+	"github.com/Takanpon2512/english-app/internal/worker"
 )
 
+// ErrCorrectionResultNotFound 指定した添削結果が存在しない（本人の解答でない場合を含む）
+var ErrCorrectionResultNotFound = errors.New("添削結果が見つかりません")
+
+// ErrGradingInProgress 同じ解答の採点が処理中のため再採点できない
+var ErrGradingInProgress = repository.ErrGradingInProgress
+
+// LLMによる採点結果（得点・正答率は評価観点ごとの得点から算出する）
+type gradingOutput struct {
+	Rubric            map[string]rubricScoreOutput `json:"rubric"`
+	Errors            []correctionErrorOutput      `json:"errors"`
+	ExampleCorrection string                       `json:"example_correction"`
+	Advice            string                       `json:"advice"`
+}
+
+// 採点結果の出力スキーマ（評価観点ごとの得点は0-100）
+func gradingOutputSchema() *llm.OutputSchema {
+	return &llm.OutputSchema{
+		Name:        "submit_grading",
+		Description: "英作文の採点結果を登録する",
+		Schema: llm.ObjectSchema(map[string]*llm.Schema{
+			"rubric":             rubricOutputSchema(),
+			"errors":             correctionErrorsOutputSchema(),
+			"example_correction": llm.StringSchema("模範解答"),
+			"advice":             llm.StringSchema("改善のためのアドバイス（日本語）"),
+		}),
+	}
+}
+
 type CorrectResultsService interface {
-	CreateCorrectionResult(userID string, req *model.CreateCorrectionResultRequest) (*model.CreateCorrectionResultResponse, error)
-	GrandCorrectResult(userID string, req *model.GrandCorrectResultRequest) (*model.GrandCorrectResultResponse, error)
+	CreateCorrectionResult(ctx context.Context, userID string, req *model.CreateCorrectionResultRequest) (*model.CreateCorrectionResultResponse, error)
+	GrandCorrectResult(ctx context.Context, userID string, req *model.GrandCorrectResultRequest) (*model.GrandCorrectResultResponse, error)
+	GradeCorrectionResultStream(ctx context.Context, userID string, correctionResultID string, observer GradingStreamObserver) (*model.GrandCorrectResultResponse, error)
+	EnqueueGrading(userID string, correctionResultID string) error
+	ResumePendingGradings(ctx context.Context) (int, error)
+	GetCorrectResultStatus(userID string, id string) (*model.GetCorrectResultStatusResponse, error)
 	GetCorrectResults(userID string, req *model.GetCorrectResultsRequest) (*model.GetCorrectResultsResponse, error)
 	GetCorrectResultsVersionList(userID string, req *model.GetCorrectResultsVersionRequest) (*model.GetCorrectResultsVersionListResponse, error)
+	CompareAnswers(userID string, req *model.CompareAnswersRequest) (*model.CompareAnswersResponse, error)
+	RegradeCorrectionResult(ctx context.Context, userID string, req *model.RegradeCorrectionResultRequest) (*model.RegradeCorrectionResultResponse, error)
+	GetGradingHistory(userID string, correctionResultID string) (*model.GetGradingHistoryResponse, error)
+	GetReviewCorrectionResults() (*model.GetReviewCorrectionResultsResponse, error)
+	ResolveReview(req *model.ResolveReviewRequest) error
+	GradeChallenge(ctx context.Context, userID string, projectID string, challengeCount int) (*model.ChallengeGradingSummary, error)
+	CheckGradingQuota(ctx context.Context, userID string) error
+	GetGradingFlags() (*model.GetGradingFlagsResponse, error)
 }
 
 type correctResultsService struct {
@@ -29,7 +71,16 @@ type correctResultsService struct {
 	questionTemplateMastersRepo repository.QuestionTemplateMastersRepository
 	questionAnswersRepo         repository.QuestionAnswersRepository
 	categoryMastersRepo         repository.CategoryMastersRepository
-	claudeClient                anthropic.Client
+	referenceAnswersRepo        repository.QuestionReferenceAnswersRepository
+	gradingFlagsRepo            repository.GradingFlagsRepository
+	llmClient                   llm.LLMClient
+	router                      *llm.ModelRouter
+	quota                       llm.QuotaChecker
+	gradingPool                 *worker.Pool
+	prompts                     *prompt.Registry
+	consensus                   ConsensusConfig
+	batchConcurrency            int
+	spelling                    *spelling.Checker
 }
 
 func NewCorrectResultsService(
@@ -38,26 +89,42 @@ func NewCorrectResultsService(
 	questionTemplateMastersRepo repository.QuestionTemplateMastersRepository,
 	questionAnswersRepo repository.QuestionAnswersRepository,
 	categoryMastersRepo repository.CategoryMastersRepository,
+	referenceAnswersRepo repository.QuestionReferenceAnswersRepository,
+	gradingFlagsRepo repository.GradingFlagsRepository,
+	llmClient llm.LLMClient,
+	router *llm.ModelRouter,
+	quota llm.QuotaChecker,
+	gradingPool *worker.Pool,
+	prompts *prompt.Registry,
+	consensus ConsensusConfig,
+	batchConcurrency int,
 ) CorrectResultsService {
-	apiKey := os.Getenv("CLAUDE_API_KEY")
-	if apiKey == "" {
-		log.Fatal("CLAUDE_API_KEY environment variable is not set")
-	}
-	claudeClient := anthropic.NewClient(
-		option.WithAPIKey(apiKey),
-	)
 	return &correctResultsService{
 		db:                          db,
 		repo:                        repo,
 		questionTemplateMastersRepo: questionTemplateMastersRepo,
 		questionAnswersRepo:         questionAnswersRepo,
 		categoryMastersRepo:         categoryMastersRepo,
-		claudeClient:                claudeClient,
+		referenceAnswersRepo:        referenceAnswersRepo,
+		gradingFlagsRepo:            gradingFlagsRepo,
+		llmClient:                   llmClient,
+		router:                      router,
+		quota:                       quota,
+		gradingPool:                 gradingPool,
+		prompts:                     prompts,
+		consensus:                   consensus,
+		batchConcurrency:            batchConcurrency,
+		spelling:                    spelling.NewChecker(),
 	}
 }
 
 // 添削結果のデータを作成
-func (s *correctResultsService) CreateCorrectionResult(userID string, req *model.CreateCorrectionResultRequest) (*model.CreateCorrectionResultResponse, error) {
+func (s *correctResultsService) CreateCorrectionResult(ctx context.Context, userID string, req *model.CreateCorrectionResultRequest) (*model.CreateCorrectionResultResponse, error) {
+	// LLMの利用上限を超えている場合は受け付けない
+	if err := s.quota.CheckQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
 	// 解答データ取得
 	userAnswer, err := s.questionAnswersRepo.GetQuestionAnswerById(req.QuestionAnswerID)
 	if err != nil {
@@ -65,17 +132,162 @@ func (s *correctResultsService) CreateCorrectionResult(userID string, req *model
 	}
 
 	req.ChallengeCount = userAnswer.ChallengeCount
+	// 採点はワーカーで非同期に行うため、作成時は常に処理中とする
+	req.Status = "PROCESSING"
 
 	return s.repo.CreateCorrectionResult(req)
 }
 
+// 採点ジョブをワーカープールに投入する
+// キューに投入できなかった場合は添削結果をFAILEDにする
+func (s *correctResultsService) EnqueueGrading(userID string, correctionResultID string) error {
+	err := s.gradingPool.Submit(func(ctx context.Context) {
+		s.runGrading(ctx, userID, correctionResultID)
+	})
+	if err != nil {
+		s.markGradingFailed(correctionResultID)
+		return fmt.Errorf("採点ジョブの登録に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// 起動時に処理中のまま残っている添削結果を再度キューに投入する
+// （単一プロセスでの運用を前提としている）
+// キューの長さを超える場合はワーカーに空きができるまで待って投入し、投入できなかった添削結果は次回起動時に再開するためPROCESSINGのまま残す
+func (s *correctResultsService) ResumePendingGradings(ctx context.Context) (int, error) {
+	pendingResults, err := s.repo.GetCorrectionResultsByStatus("PROCESSING")
+	if err != nil {
+		return 0, fmt.Errorf("未処理の添削結果の取得に失敗しました: %w", err)
+	}
+
+	resumed := 0
+	for _, pendingResult := range pendingResults {
+		// 採点ジョブは解答者のユーザーIDで実行する
+		userAnswer, err := s.questionAnswersRepo.GetQuestionAnswerById(pendingResult.QuestionAnswerID)
+		if err != nil {
+			log.Printf("添削結果 %s の解答データ取得に失敗しました: %v", pendingResult.ID, err)
+			s.markGradingFailed(pendingResult.ID)
+			continue
+		}
+
+		userID, correctionResultID := userAnswer.UserID, pendingResult.ID
+		if err := s.gradingPool.SubmitWait(ctx, func(ctx context.Context) {
+			s.runGrading(ctx, userID, correctionResultID)
+		}); err != nil {
+			log.Printf("添削結果 %s の再投入を中断しました（次回起動時に再開します）: %v", pendingResult.ID, err)
+			return resumed, nil
+		}
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+// 採点ジョブ本体。失敗した場合は添削結果をFAILEDにする
+func (s *correctResultsService) runGrading(ctx context.Context, userID string, correctionResultID string) {
+	_, err := s.GrandCorrectResult(ctx, userID, &model.GrandCorrectResultRequest{ID: correctionResultID})
+	if err == nil {
+		return
+	}
+
+	// シャットダウンによる中断の場合は次回起動時に再開するため、PROCESSINGのまま残す
+	if ctx.Err() != nil {
+		log.Printf("添削結果 %s の採点を中断しました: %v", correctionResultID, err)
+		return
+	}
+
+	log.Printf("添削結果 %s の採点に失敗しました: %v", correctionResultID, err)
+	s.markGradingFailed(correctionResultID)
+}
+
+// 添削結果のステータスをFAILEDに更新する
+func (s *correctResultsService) markGradingFailed(correctionResultID string) {
+	if _, err := s.repo.UpdateCorrectionResult(&model.UpdateCorrectionResultRequest{
+		ID:     correctionResultID,
+		Status: "FAILED",
+	}); err != nil {
+		log.Printf("添削結果 %s のステータス更新に失敗しました: %v", correctionResultID, err)
+	}
+}
+
+// 添削結果の採点状況を取得
+func (s *correctResultsService) GetCorrectResultStatus(userID string, id string) (*model.GetCorrectResultStatusResponse, error) {
+	// 本人の解答に紐づく添削結果のみ参照可能
+	correctionResult, err := s.ownedCorrectionResult(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rubricScores, err := s.repo.GetRubricScores([]string{correctionResult.ID})
+	if err != nil {
+		return nil, err
+	}
+	correctionErrors, err := s.repo.GetCorrectionErrors([]string{correctionResult.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GetCorrectResultStatusResponse{
+		ID:                       correctionResult.ID,
+		QuestionAnswerID:         correctionResult.QuestionAnswerID,
+		QuestionTemplateMasterID: correctionResult.QuestionTemplateMasterID,
+		ProjectID:                correctionResult.ProjectID,
+		GetPoints:                correctionResult.GetPoints,
+		ExampleCorrection:        correctionResult.ExampleCorrection,
+		CorrectRate:              correctionResult.CorrectRate,
+		Advice:                   correctionResult.Advice,
+		PromptVersion:            correctionResult.PromptVersion,
+		LLMModel:                 correctionResult.LLMModel,
+		LLMProvider:              correctionResult.LLMProvider,
+		PreCheck:                 correctionResult.PreCheck,
+		Consensus:                correctionResult.Consensus(),
+		RubricScores:             rubricScoreSummaries(rubricScores[correctionResult.ID]),
+		Errors:                   correctionErrorSummaries(correctionErrors[correctionResult.ID]),
+		Status:                   correctionResult.Status,
+		ChallengeCount:           correctionResult.ChallengeCount,
+		GradingVersion:           correctionResult.GradingVersion,
+		IsAuthoritative:          correctionResult.IsAuthoritative,
+	}, nil
+}
+
 // 添削結果のデータを更新
 func (s *correctResultsService) UpdateCorrectionResult(userID string, req *model.UpdateCorrectionResultRequest) (*model.UpdateCorrectionResultResponse, error) {
 	return s.repo.UpdateCorrectionResult(req)
 }
 
 // LLMで作成した添削結果のデータを取得し、反映
-func (s *correctResultsService) GrandCorrectResult(userID string, req *model.GrandCorrectResultRequest) (*model.GrandCorrectResultResponse, error) {
+func (s *correctResultsService) GrandCorrectResult(ctx context.Context, userID string, req *model.GrandCorrectResultRequest) (*model.GrandCorrectResultResponse, error) {
+	return s.grade(ctx, userID, req, nil)
+}
+
+// 採点を行い、LLMが生成中のアドバイスを通知する
+func (s *correctResultsService) GradeCorrectionResultStream(ctx context.Context, userID string, correctionResultID string, observer GradingStreamObserver) (*model.GrandCorrectResultResponse, error) {
+	return s.gradeInRequest(ctx, userID, correctionResultID, observer)
+}
+
+// リクエストの処理中に採点を行う（observerを指定した場合はLLMが生成中のアドバイスを通知する）
+// 採点に失敗した場合は添削結果をFAILEDにし、接続が切れて中断した場合は残りの採点をワーカーに引き継ぐ
+func (s *correctResultsService) gradeInRequest(ctx context.Context, userID string, correctionResultID string, observer GradingStreamObserver) (*model.GrandCorrectResultResponse, error) {
+	res, err := s.grade(ctx, userID, &model.GrandCorrectResultRequest{ID: correctionResultID}, observer)
+	if err == nil {
+		return res, nil
+	}
+
+	if ctx.Err() != nil {
+		log.Printf("添削結果 %s の採点が中断されたため、ワーカーで採点します: %v", correctionResultID, err)
+		if enqueueErr := s.EnqueueGrading(userID, correctionResultID); enqueueErr != nil {
+			log.Printf("添削結果 %s の採点ジョブの投入に失敗しました: %v", correctionResultID, enqueueErr)
+		}
+		return nil, err
+	}
+
+	log.Printf("添削結果 %s の採点に失敗しました: %v", correctionResultID, err)
+	s.markGradingFailed(correctionResultID)
+	return nil, err
+}
+
+// grade 添削結果を採点して保存する（observerを指定した場合はLLMの出力をストリーミングで受け取る）
+func (s *correctResultsService) grade(ctx context.Context, userID string, req *model.GrandCorrectResultRequest, observer GradingStreamObserver) (*model.GrandCorrectResultResponse, error) {
 	// 問題・解答データ取得のためのデータを取得
 	correctionResult, err := s.repo.GetCorrectionResultById(req.ID)
 	if err != nil {
@@ -94,99 +306,237 @@ func (s *correctResultsService) GrandCorrectResult(userID string, req *model.Gra
 		return nil, fmt.Errorf("質問テンプレートマスターの取得に失敗しました: %w", err)
 	}
 
-	// LLMで添削を行うプロンプトを作成
-	prompt := fmt.Sprintf(`
-		あなたは英語の作文を採点する教師です。以下の条件で採点を行ってください：
-		
-		問題：
-		%s
-
-		日本語での説明：
-		%s
-
-		学習者の解答：
-		%s
-
-		出力要件：
-		- 次の厳密なJSONオブジェクト「のみ」を返してください。
-		- コードブロック( バッククォート3つ )や前後の説明文、余計な文字は一切出力しないでください。
-		- 値は有効なJSONとし、数値は整数で出力してください。
-		- キーは英語のまま使用してください。
-		- アドバイスは日本語で出力してください。
-		
-		出力フォーマット（参考）：
-		{
-			"points": 採点結果（%d点満点の整数）, 
-			"correct_rate": 正答率（0-100の整数）, 
-			"example_correction": 模範解答の文字列, 
-			"advice": 改善のためのアドバイスの文字列
-		}
-	`, questionTemplateMaster.English, questionTemplateMaster.Japanese, userAnswer.UserAnswer, questionTemplateMaster.Points)
-
-	log.Println("prompt", prompt)
-
-	// Claudeに採点リクエストを送信（Anthropic Go SDK v1）
-	msg, err := s.claudeClient.Messages.New(
-		context.Background(),
-		anthropic.MessageNewParams{
-			Model:     anthropic.ModelClaude3_7Sonnet20250219,
-			MaxTokens: 5000,
-			Messages: []anthropic.MessageParam{
-				anthropic.NewUserMessage(
-					anthropic.NewTextBlock(prompt),
-				),
-			},
-		},
-	)
+	// 正解として認める解答例を取得（別の正しい言い回しを減点しないようにプロンプトに含める）
+	referenceAnswers, err := s.referenceAnswersRepo.GetReferenceAnswers(correctionResult.QuestionTemplateMasterID)
 	if err != nil {
-		return nil, fmt.Errorf("Claudeによる採点に失敗しました: %w", err)
+		return nil, err
 	}
 
-	// レスポンスをパース（Contentのテキスト結合 → JSONとして解釈）
-	var output string
-	for _, block := range msg.Content {
-		if block.Type == "text" {
-			output += block.Text
-		}
+	// 空・英語以外・問題文の丸写し・解答例との一致はLLMを呼ばずに採点する
+	// 厳格な再評価では事前チェックの判定自体を見直せるよう、空の解答以外はLLMで採点する
+	preCheck := preCheckAnswer(s.spelling, userAnswer.UserAnswer, questionTemplateMaster, referenceAnswers)
+	secondOpinion := correctionResult.GradingMode == model.GradingModeSecondOpinion
+	if preCheck.Output != nil && (!secondOpinion || preCheck.Outcome == model.PreCheckEmpty) {
+		log.Printf("添削結果 %s を事前チェックで採点しました（理由: %s）", correctionResult.ID, preCheck.Outcome)
+		return s.completeGrading(correctionResult, questionTemplateMaster.Points, preCheck.Output, gradingRecord{PreCheck: preCheck.Outcome})
+	}
+
+	// 解答（と厳格な再評価の理由）から採点への指示のような記述を検出し、検出した記述と採点結果の矛盾を採点後に記録する
+	appealReason := ""
+	if secondOpinion {
+		appealReason = correctionResult.RegradeReason
+	}
+	guard := newAnswerGuard(userAnswer.UserAnswer, appealReason, questionTemplateMaster.QuestionType)
+	if len(guard.signals) > 0 {
+		log.Printf("添削結果 %s の解答に採点への指示のような記述が含まれています（%d件）", correctionResult.ID, len(guard.signals))
+	}
+	defer s.recordGradingFlags(correctionResult.ID, userID, guard)
+
+	// LLMで添削を行うプロンプトを作成（事前チェックで見つかった綴りの誤りの候補を含める）
+	// 解答はプロンプトの区切りタグを無効にして埋め込み、検出した記述は種類のみを伝える
+	gradingPrompt, err := s.renderGradingPrompt(correctionResult, prompt.GradingData{
+		English:          questionTemplateMaster.English,
+		Japanese:         questionTemplateMaster.Japanese,
+		UserAnswer:       guard.answer,
+		MaxPoints:        questionTemplateMaster.Points,
+		Criteria:         rubricPromptCriteria(),
+		ErrorTypes:       model.CorrectionErrorTypes,
+		ReferenceAnswers: referencePromptAnswers(referenceAnswers),
+		PreCheckFindings: preCheck.Findings,
+		InjectionSignals: guard.signalDescriptions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// LLMに採点リクエストを送信（出力はスキーマと配点で検証し、不正な場合は修正を依頼する）
+	// モデルは問題のレベル・種類と解答の長さで選ぶ（基本問題・短い解答は低コストのモデル、上級の英作文は高性能なモデル）
+	llmReq := s.router.NewRequest(llm.FeatureGrading, llm.RouteInput{
+		Level:        questionTemplateMaster.Level,
+		QuestionType: questionTemplateMaster.QuestionType,
+		AnswerLength: utf8.RuneCountInString(userAnswer.UserAnswer),
+	}, gradingPrompt.Text)
+	llmReq.UserID = userID
+	llmReq.SubjectID = correctionResult.ID
+	llmReq.PromptVersion = gradingPrompt.Version
+	llmReq.Output = gradingOutputSchema()
+	// 再採点では前回と同じプロンプトでもキャッシュした応答を使わずに採点し直す
+	llmReq.NoCache = correctionResult.GradingVersion > 1
+
+	// 問題のレベルに合議採点の回数が設定されている場合は、複数回の採点を並行して行い結果をまとめる
+	// 採点ごとにアドバイスが異なるため、生成中のアドバイスは通知しない
+	if samples := s.consensus.samplesFor(questionTemplateMaster.Level); samples > 1 {
+		llmReq.NoCache = true
+		return s.gradeByConsensus(ctx, correctionResult, questionTemplateMaster.Points, llmReq, samples, guard, gradingPrompt.Version)
+	}
+
+	if observer != nil {
+		llmReq.Stream = newAdviceStream(observer)
 	}
-	// Claudeの出力にコードフェンスや説明が混ざる場合があるため、
-	// 最初の完全なJSONオブジェクトのみを抽出してからUnmarshalする
-	jsonStr, err := extractFirstJSONObject(output)
+
+	var llmResponse gradingOutput
+	// 誤りの位置が解答と一致しない場合は補正し、解答中に見つからない誤りは修正を依頼する
+	// 解答の語数・誤りの件数・模範解答との一致度と矛盾する高得点は採点のやり直しを依頼する
+	checkOutput := func() []string {
+		return guard.check(&llmResponse)
+	}
+	llmRes, err := llm.GenerateStructured(ctx, s.llmClient, llmReq, &llmResponse, checkOutput)
 	if err != nil {
-		return nil, fmt.Errorf("ClaudeのレスポンスからJSON抽出に失敗しました: %w", err)
+		return nil, fmt.Errorf("LLMによる採点に失敗しました: %w", err)
 	}
-	var llmResponse struct {
-		Points            int    `json:"points"`
-		CorrectRate       int    `json:"correct_rate"`
-		ExampleCorrection string `json:"example_correction"`
-		Advice            string `json:"advice"`
+
+	return s.completeGrading(correctionResult, questionTemplateMaster.Points, &llmResponse, gradingRecord{
+		PromptVersion: gradingPrompt.Version,
+		LLMModel:      llmRes.Model,
+		LLMProvider:   llmRes.Provider,
+	})
+}
+
+// gradeByConsensus 同じ解答をsamples回採点し、評価観点ごとの得点の中央値と最も多い模範解答で添削結果を完了にする
+// 採点ごとの正答率のばらつきが大きい場合は人による確認が必要として記録する
+func (s *correctResultsService) gradeByConsensus(ctx context.Context, correctionResult *model.CorrectionResults, maxPoints int, llmReq *llm.Request, samples int, guard *answerGuard, promptVersion string) (*model.GrandCorrectResultResponse, error) {
+	gradings, err := sampleGradings(ctx, s.llmClient, llmReq, samples, guard)
+	if err != nil {
+		return nil, fmt.Errorf("LLMによる採点に失敗しました: %w", err)
 	}
-	if err := json.Unmarshal([]byte(jsonStr), &llmResponse); err != nil {
-		return nil, fmt.Errorf("Claudeのレスポンスのパースに失敗しました: %w", err)
+
+	consensus := buildConsensus(gradings, s.consensus.ReviewStdDev)
+	if consensus.Summary.NeedsReview {
+		log.Printf("添削結果 %s の合議採点のばらつきが大きいため要確認とします（採点回数: %d, 分散: %.2f）", correctionResult.ID, consensus.Summary.Samples, consensus.Summary.ScoreVariance)
 	}
 
-	s.repo.UpdateCorrectionResult(&model.UpdateCorrectionResultRequest{
+	return s.completeGrading(correctionResult, maxPoints, consensus.Output, gradingRecord{
+		PromptVersion: promptVersion,
+		LLMModel:      llm.JoinUnique(consensus.Models),
+		LLMProvider:   llm.JoinUnique(consensus.Providers),
+		Consensus:     consensus.Summary,
+	})
+}
+
+// recordGradingFlags 採点時に検出した指示のような記述と採点結果の矛盾を記録する（記録に失敗しても採点は続ける）
+func (s *correctResultsService) recordGradingFlags(correctionResultID string, userID string, guard *answerGuard) {
+	flags := guard.flags(correctionResultID, userID)
+	if len(flags) == 0 {
+		return
+	}
+	if err := s.gradingFlagsRepo.CreateGradingFlags(flags); err != nil {
+		log.Printf("Warning: 添削結果 %s の採点の検出内容の記録に失敗しました: %v", correctionResultID, err)
+	}
+}
+
+// gradingRecord 採点の方法の記録
+type gradingRecord struct {
+	PromptVersion string
+	LLMModel      string
+	LLMProvider   string
+	PreCheck      string                  // 事前チェックでLLMを呼ばずに採点した理由
+	Consensus     *model.ConsensusSummary // 合議採点の結果（合議採点を行った場合のみ）
+}
+
+// completeGrading 採点結果から得点・正答率を算出し、評価観点ごとの得点・解答中の誤りとともに保存して添削結果を完了にする
+func (s *correctResultsService) completeGrading(correctionResult *model.CorrectionResults, maxPoints int, output *gradingOutput, record gradingRecord) (*model.GrandCorrectResultResponse, error) {
+	// 評価観点ごとの得点と重みから得点・正答率を算出する
+	correctRate, points := scoreRubric(output.Rubric, maxPoints)
+	rubricScores := rubricScoreRecords(output.Rubric)
+	correctionErrors := correctionErrorRecords(output.Errors)
+
+	update := &model.UpdateCorrectionResultRequest{
 		ID:                correctionResult.ID,
-		GetPoints:         llmResponse.Points,
-		ExampleCorrection: llmResponse.ExampleCorrection,
-		CorrectRate:       llmResponse.CorrectRate,
-		Advice:            llmResponse.Advice,
+		GetPoints:         points,
+		ExampleCorrection: output.ExampleCorrection,
+		CorrectRate:       correctRate,
+		Advice:            output.Advice,
+		PromptVersion:     record.PromptVersion,
+		LLMModel:          record.LLMModel,
+		LLMProvider:       record.LLMProvider,
+		PreCheck:          record.PreCheck,
 		Status:            "COMPLETED",
+	}
+	if record.Consensus != nil {
+		update.ConsensusSamples = record.Consensus.Samples
+		update.ScoreVariance = record.Consensus.ScoreVariance
+		update.NeedsReview = record.Consensus.NeedsReview
+	}
+
+	// 評価観点ごとの得点・解答中の誤り・採点結果を保存し、完了にする
+	// 途中で失敗した場合に完了していない添削結果に得点や誤りが残らないよう、1つのトランザクションで行う
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.SaveRubricScores(tx, correctionResult.ID, rubricScores); err != nil {
+			return err
+		}
+		if err := s.repo.SaveCorrectionErrors(tx, correctionResult.ID, correctionErrors); err != nil {
+			return err
+		}
+		if err := s.repo.CompleteCorrectionResult(tx, update); err != nil {
+			return err
+		}
+
+		// 再採点の場合は完了した採点を解答の正式な採点結果にする（失敗した場合は前回の採点結果のまま）
+		if !correctionResult.IsAuthoritative {
+			return s.repo.SetAuthoritativeGrading(tx, correctionResult.ID)
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &model.GrandCorrectResultResponse{
 		ID:                       correctionResult.ID,
 		QuestionAnswerID:         correctionResult.QuestionAnswerID,
 		QuestionTemplateMasterID: correctionResult.QuestionTemplateMasterID,
-		GetPoints:                llmResponse.Points,
-		ExampleCorrection:        llmResponse.ExampleCorrection,
-		CorrectRate:              llmResponse.CorrectRate,
-		Advice:                   llmResponse.Advice,
+		ProjectID:                correctionResult.ProjectID,
+		GetPoints:                points,
+		ExampleCorrection:        output.ExampleCorrection,
+		CorrectRate:              correctRate,
+		Advice:                   output.Advice,
+		RubricScores:             rubricScoreSummaries(rubricScores),
+		Errors:                   correctionErrorSummaries(correctionErrors),
+		PromptVersion:            record.PromptVersion,
+		LLMModel:                 record.LLMModel,
+		LLMProvider:              record.LLMProvider,
+		PreCheck:                 record.PreCheck,
+		Consensus:                record.Consensus,
 		Status:                   "COMPLETED",
 		ChallengeCount:           correctionResult.ChallengeCount,
+		GradingVersion:           correctionResult.GradingVersion,
+		IsAuthoritative:          true,
 	}, nil
 }
 
+// renderGradingPrompt 採点のプロンプトを作成する
+// 厳格な再評価の場合は、正式な採点結果（前回の採点）と再採点を依頼した理由を含める
+func (s *correctResultsService) renderGradingPrompt(correctionResult *model.CorrectionResults, data prompt.GradingData) (*prompt.Rendered, error) {
+	if correctionResult.GradingMode != model.GradingModeSecondOpinion {
+		return s.prompts.Render(prompt.NameGrading, data)
+	}
+
+	history, err := s.repo.GetGradingHistory(correctionResult.QuestionAnswerID)
+	if err != nil {
+		return nil, err
+	}
+	secondOpinion := prompt.SecondOpinionData{GradingData: data, AppealReason: neutralizeDelimiters(correctionResult.RegradeReason)}
+	for _, previous := range history {
+		if !previous.IsAuthoritative || previous.ID == correctionResult.ID {
+			continue
+		}
+		rubricScores, err := s.repo.GetRubricScores([]string{previous.ID})
+		if err != nil {
+			return nil, err
+		}
+		secondOpinion.PreviousPoints = previous.GetPoints
+		for _, score := range rubricScoreSummaries(rubricScores[previous.ID]) {
+			secondOpinion.PreviousRubric = append(secondOpinion.PreviousRubric, prompt.GradingPreviousScore{
+				Name:      score.Criterion,
+				Score:     score.Score,
+				Rationale: score.Rationale,
+			})
+		}
+	}
+
+	return s.prompts.Render(prompt.NameGradingSecondOpinion, secondOpinion)
+}
+
 // 添削結果の取得
 func (s *correctResultsService) GetCorrectResults(userID string, req *model.GetCorrectResultsRequest) (*model.GetCorrectResultsResponse, error) {
 	// 添削結果の取得
@@ -195,6 +545,20 @@ func (s *correctResultsService) GetCorrectResults(userID string, req *model.GetC
 		return nil, fmt.Errorf("添削結果の取得に失敗しました: %w", err)
 	}
 
+	// 評価観点ごとの得点と解答中の誤りをまとめて取得
+	correctResultIDs := make([]string, 0, len(correctResults.CorrectResults))
+	for _, correctResult := range correctResults.CorrectResults {
+		correctResultIDs = append(correctResultIDs, correctResult.ID)
+	}
+	rubricScores, err := s.repo.GetRubricScores(correctResultIDs)
+	if err != nil {
+		return nil, err
+	}
+	correctionErrors, err := s.repo.GetCorrectionErrors(correctResultIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	var correctResultsSummary []model.CorrectionResultsSummary
 	for _, correctResult := range correctResults.CorrectResults {
 		questionAnswer, err := s.questionAnswersRepo.GetQuestionAnswerById(correctResult.QuestionAnswerID)
@@ -205,7 +569,7 @@ func (s *correctResultsService) GetCorrectResults(userID string, req *model.GetC
 			return nil, fmt.Errorf("質問回答（ID: %s）が見つかりません", correctResult.QuestionAnswerID)
 		}
 
-		questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByID(correctResult.QuestionTemplateMasterID)
+		questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByIDAnyStatus(correctResult.QuestionTemplateMasterID)
 		if err != nil {
 			return nil, fmt.Errorf("質問テンプレートの取得に失敗しました: %w", err)
 		}
@@ -230,8 +594,17 @@ func (s *correctResultsService) GetCorrectResults(userID string, req *model.GetC
 			ExampleCorrection:        correctResult.ExampleCorrection,
 			CorrectRate:              correctResult.CorrectRate,
 			Advice:                   correctResult.Advice,
+			PromptVersion:            correctResult.PromptVersion,
+			LLMModel:                 correctResult.LLMModel,
+			LLMProvider:              correctResult.LLMProvider,
+			PreCheck:                 correctResult.PreCheck,
+			Consensus:                correctResult.Consensus,
+			RubricScores:             rubricScoreSummaries(rubricScores[correctResult.ID]),
+			Errors:                   correctionErrorSummaries(correctionErrors[correctResult.ID]),
+			Diff:                     correctionDiff(questionAnswer.UserAnswer, correctResult.ExampleCorrection),
 			Status:                   correctResult.Status,
 			ChallengeCount:           correctResult.ChallengeCount,
+			GradingVersion:           correctResult.GradingVersion,
 			QuestionAnswer: model.QuestionAnswersSummary{
 				ID:                       correctResult.QuestionAnswerID,
 				ProjectID:                correctResult.ProjectID,
@@ -283,70 +656,258 @@ func (s *correctResultsService) GetCorrectResultsVersionList(userID string, req
 	}, nil
 }
 
-// Claudeの出力テキストから最初の完全なJSONオブジェクトを抽出する
-// - コードブロック( ```json ... ``` )が含まれていても取り除いて抽出する
-func extractFirstJSONObject(input string) (string, error) {
-	trimmed := strings.TrimSpace(input)
-	fmt.Printf("extractFirstJSONObject input: %s\n", trimmed)
-
-	// コードフェンスがある場合は除去
-	if strings.HasPrefix(trimmed, "```") {
-		fmt.Println("Removing code fences...")
-		// 先頭のフェンスとオプションの言語指定を取り除く
-		trimmed = strings.TrimPrefix(trimmed, "```json")
-		trimmed = strings.TrimPrefix(trimmed, "```JSON")
-		trimmed = strings.TrimPrefix(trimmed, "```")
-		trimmed = strings.TrimSpace(trimmed)
-		// 末尾のフェンスを取り除く
-		if idx := strings.LastIndex(trimmed, "```"); idx != -1 {
-			trimmed = trimmed[:idx]
+// 同じ問題の2回の挑戦の解答を単語単位で比較
+func (s *correctResultsService) CompareAnswers(userID string, req *model.CompareAnswersRequest) (*model.CompareAnswersResponse, error) {
+	from, err := s.answerAttempt(userID, req.ProjectID, req.QuestionTemplateMasterID, req.FromChallengeCount)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.answerAttempt(userID, req.ProjectID, req.QuestionTemplateMasterID, req.ToChallengeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CompareAnswersResponse{
+		From: *from,
+		To:   *to,
+		Diff: diffAnswers(from.UserAnswer, to.UserAnswer),
+	}, nil
+}
+
+// answerAttempt 挑戦回数を指定して問題の解答と採点結果を取得する（本人の解答のみ）
+func (s *correctResultsService) answerAttempt(userID string, projectID string, questionTemplateMasterID string, challengeCount int) (*model.AnswerAttempt, error) {
+	correctionResult, err := s.repo.GetCorrectionResultByChallenge(projectID, questionTemplateMasterID, challengeCount)
+	if err != nil {
+		return nil, err
+	}
+	if correctionResult == nil {
+		return nil, fmt.Errorf("%w（挑戦回数: %d）", ErrCorrectionResultNotFound, challengeCount)
+	}
+
+	questionAnswer, err := s.questionAnswersRepo.GetQuestionAnswerById(correctionResult.QuestionAnswerID)
+	if err != nil {
+		return nil, fmt.Errorf("解答データの取得に失敗しました: %w", err)
+	}
+	if questionAnswer.UserID != userID {
+		return nil, fmt.Errorf("%w（挑戦回数: %d）", ErrCorrectionResultNotFound, challengeCount)
+	}
+
+	return &model.AnswerAttempt{
+		CorrectionResultID: correctionResult.ID,
+		ChallengeCount:     correctionResult.ChallengeCount,
+		UserAnswer:         questionAnswer.UserAnswer,
+		GetPoints:          correctionResult.GetPoints,
+		CorrectRate:        correctionResult.CorrectRate,
+		Status:             correctionResult.Status,
+	}, nil
+}
+
+// correctionDiff 解答から模範解答への差分（採点前で模範解答がない場合は空）
+func correctionDiff(userAnswer string, exampleCorrection string) []model.AnswerDiffOp {
+	if exampleCorrection == "" {
+		return []model.AnswerDiffOp{}
+	}
+	return diffAnswers(userAnswer, exampleCorrection)
+}
+
+// 採点結果に納得できない解答を再採点する
+// 同じ解答に新しいバージョンの添削結果を作成してワーカーで採点し、完了したら正式な採点結果にする
+func (s *correctResultsService) RegradeCorrectionResult(ctx context.Context, userID string, req *model.RegradeCorrectionResultRequest) (*model.RegradeCorrectionResultResponse, error) {
+	// LLMの利用上限を超えている場合は受け付けない
+	if err := s.quota.CheckQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	source, err := s.ownedCorrectionResult(userID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	gradingMode := model.GradingModeStandard
+	if req.SecondOpinion {
+		gradingMode = model.GradingModeSecondOpinion
+	}
+	// 採点中の添削結果がある場合は、バージョンの採番と同じロックの中で確認してErrGradingInProgressを返す
+	regrade, err := s.repo.CreateRegrade(source, gradingMode, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.EnqueueGrading(userID, regrade.ID); err != nil {
+		return nil, err
+	}
+
+	return &model.RegradeCorrectionResultResponse{
+		ID:                       regrade.ID,
+		QuestionAnswerID:         regrade.QuestionAnswerID,
+		QuestionTemplateMasterID: regrade.QuestionTemplateMasterID,
+		ProjectID:                regrade.ProjectID,
+		GradingVersion:           regrade.GradingVersion,
+		GradingMode:              regrade.GradingMode,
+		RegradeReason:            regrade.RegradeReason,
+		Status:                   regrade.Status,
+		ChallengeCount:           regrade.ChallengeCount,
+	}, nil
+}
+
+// 添削結果と同じ解答の採点履歴を取得（再採点を含む、バージョンの古い順）
+func (s *correctResultsService) GetGradingHistory(userID string, correctionResultID string) (*model.GetGradingHistoryResponse, error) {
+	correctionResult, err := s.ownedCorrectionResult(userID, correctionResultID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.GetGradingHistory(correctionResult.QuestionAnswerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 評価観点ごとの得点と解答中の誤りをまとめて取得
+	ids := make([]string, 0, len(history))
+	for _, grading := range history {
+		ids = append(ids, grading.ID)
+	}
+	rubricScores, err := s.repo.GetRubricScores(ids)
+	if err != nil {
+		return nil, err
+	}
+	correctionErrors, err := s.repo.GetCorrectionErrors(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]model.GradingHistoryEntry, 0, len(history))
+	for _, grading := range history {
+		entries = append(entries, model.GradingHistoryEntry{
+			ID:                grading.ID,
+			GradingVersion:    grading.GradingVersion,
+			IsAuthoritative:   grading.IsAuthoritative,
+			GradingMode:       grading.GradingMode,
+			RegradeReason:     grading.RegradeReason,
+			GetPoints:         grading.GetPoints,
+			ExampleCorrection: grading.ExampleCorrection,
+			CorrectRate:       grading.CorrectRate,
+			Advice:            grading.Advice,
+			PromptVersion:     grading.PromptVersion,
+			LLMModel:          grading.LLMModel,
+			LLMProvider:       grading.LLMProvider,
+			PreCheck:          grading.PreCheck,
+			Consensus:         grading.Consensus(),
+			RubricScores:      rubricScoreSummaries(rubricScores[grading.ID]),
+			Errors:            correctionErrorSummaries(correctionErrors[grading.ID]),
+			Status:            grading.Status,
+			CreatedAt:         grading.CreatedAt,
+		})
+	}
+
+	return &model.GetGradingHistoryResponse{
+		QuestionAnswerID: correctionResult.QuestionAnswerID,
+		ChallengeCount:   correctionResult.ChallengeCount,
+		History:          entries,
+	}, nil
+}
+
+// ownedCorrectionResult 本人の解答に紐づく添削結果を取得する（存在しない場合・本人の解答でない場合はErrCorrectionResultNotFound）
+func (s *correctResultsService) ownedCorrectionResult(userID string, correctionResultID string) (*model.CorrectionResults, error) {
+	correctionResult, _, err := findOwnedCorrectionResult(s.repo, s.questionAnswersRepo, userID, correctionResultID)
+	return correctionResult, err
+}
+
+// findOwnedCorrectionResult 本人の解答に紐づく添削結果と解答を取得する（存在しない場合・本人の解答でない場合はErrCorrectionResultNotFound）
+func findOwnedCorrectionResult(repo repository.CorrectResultsRepository, questionAnswersRepo repository.QuestionAnswersRepository, userID string, correctionResultID string) (*model.CorrectionResults, *model.QuestionAnswers, error) {
+	correctionResult, err := repo.GetCorrectionResultById(correctionResultID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("%w（ID: %s）", ErrCorrectionResultNotFound, correctionResultID)
 		}
-		trimmed = strings.TrimSpace(trimmed)
-		fmt.Printf("After removing code fences: %s\n", trimmed)
-	}
-
-	// 最初の完全なJSONオブジェクトを括弧のネストで検出
-	inString := false
-	escape := false
-	depth := 0
-	start := -1
-	for i, r := range trimmed {
-		if inString {
-			if escape {
-				escape = false
-				continue
-			}
-			if r == '\\' {
-				escape = true
-				continue
-			}
-			if r == '"' {
-				inString = false
-			}
-			continue
+		return nil, nil, err
+	}
+
+	questionAnswer, err := questionAnswersRepo.GetQuestionAnswerById(correctionResult.QuestionAnswerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解答データの取得に失敗しました: %w", err)
+	}
+	if questionAnswer.UserID != userID {
+		return nil, nil, fmt.Errorf("%w（ID: %s）", ErrCorrectionResultNotFound, correctionResultID)
+	}
+
+	return correctionResult, questionAnswer, nil
+}
+
+// reviewListLimit 要確認の添削結果の一覧で返す最大件数
+const reviewListLimit = 100
+
+// 合議採点のばらつきが大きく人による確認が必要な添削結果を取得（管理者用）
+func (s *correctResultsService) GetReviewCorrectionResults() (*model.GetReviewCorrectionResultsResponse, error) {
+	correctionResults, err := s.repo.GetCorrectionResultsNeedingReview(reviewListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]model.ReviewCorrectionResult, 0, len(correctionResults))
+	for _, correctionResult := range correctionResults {
+		questionAnswer, err := s.questionAnswersRepo.GetQuestionAnswerById(correctionResult.QuestionAnswerID)
+		if err != nil {
+			return nil, fmt.Errorf("解答データの取得に失敗しました: %w", err)
 		}
-		if r == '"' {
-			inString = true
-			continue
+
+		reviews = append(reviews, model.ReviewCorrectionResult{
+			ID:                       correctionResult.ID,
+			QuestionAnswerID:         correctionResult.QuestionAnswerID,
+			QuestionTemplateMasterID: correctionResult.QuestionTemplateMasterID,
+			ProjectID:                correctionResult.ProjectID,
+			UserAnswer:               questionAnswer.UserAnswer,
+			GetPoints:                correctionResult.GetPoints,
+			CorrectRate:              correctionResult.CorrectRate,
+			ExampleCorrection:        correctionResult.ExampleCorrection,
+			LLMModel:                 correctionResult.LLMModel,
+			Consensus:                correctionResult.Consensus(),
+			CreatedAt:                correctionResult.CreatedAt,
+		})
+	}
+
+	return &model.GetReviewCorrectionResultsResponse{CorrectResults: reviews}, nil
+}
+
+// 人による確認が済んだ添削結果の要確認を解除する（管理者用）
+func (s *correctResultsService) ResolveReview(req *model.ResolveReviewRequest) error {
+	if _, err := s.repo.GetCorrectionResultById(req.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w（ID: %s）", ErrCorrectionResultNotFound, req.ID)
 		}
-		switch r {
-		case '{':
-			if depth == 0 {
-				start = i
-			}
-			depth++
-		case '}':
-			if depth > 0 {
-				depth--
-				if depth == 0 && start != -1 {
-					result := trimmed[start : i+1]
-					fmt.Printf("Extracted JSON: %s\n", result)
-					return result, nil
-				}
-			}
+		return err
+	}
+
+	return s.repo.ResolveReview(req.ID)
+}
+
+// gradingFlagListLimit 採点時の検出内容の一覧で返す最大件数
+const gradingFlagListLimit = 100
+
+// 採点時に検出したプロンプトインジェクションの疑いと矛盾する採点結果を取得（管理者用）
+func (s *correctResultsService) GetGradingFlags() (*model.GetGradingFlagsResponse, error) {
+	flags, err := s.gradingFlagsRepo.GetGradingFlags(gradingFlagListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]model.GradingFlagSummary, 0, len(flags))
+	for _, flag := range flags {
+		correctionResult, err := s.repo.GetCorrectionResultById(flag.CorrectionResultID)
+		if err != nil {
+			return nil, fmt.Errorf("添削結果の取得に失敗しました: %w", err)
 		}
+
+		summaries = append(summaries, model.GradingFlagSummary{
+			ID:                 flag.ID,
+			CorrectionResultID: flag.CorrectionResultID,
+			UserID:             flag.UserID,
+			FlagType:           flag.FlagType,
+			Details:            strings.Split(flag.Details, "\n"),
+			Status:             correctionResult.Status,
+			CreatedAt:          flag.CreatedAt,
+		})
 	}
 
-	fmt.Printf("Failed to extract JSON from: %s\n", trimmed)
-	return "", fmt.Errorf("valid JSON object not found in LLM output")
+	return &model.GetGradingFlagsResponse{Flags: summaries}, nil
 }
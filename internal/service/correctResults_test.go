@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
+	"github.com/Takanpon2512/english-app/internal/repository"
+)
+
+// stubCorrectResultsRepository 採点の保存を記録するCorrectResultsRepository（使用しないメソッドは未実装）
+type stubCorrectResultsRepository struct {
+	repository.CorrectResultsRepository
+
+	completed        *model.UpdateCorrectionResultRequest
+	rubricScores     []model.CorrectionRubricScores
+	correctionErrors []model.CorrectionErrors
+}
+
+func (r *stubCorrectResultsRepository) GetCorrectionResultById(id string) (*model.CorrectionResults, error) {
+	return &model.CorrectionResults{
+		ID:                       id,
+		QuestionAnswerID:         "answer-1",
+		QuestionTemplateMasterID: "template-1",
+		Status:                   "PROCESSING",
+		ChallengeCount:           1,
+		GradingVersion:           1,
+		IsAuthoritative:          true,
+		GradingMode:              model.GradingModeStandard,
+	}, nil
+}
+
+func (r *stubCorrectResultsRepository) SaveRubricScores(tx *gorm.DB, correctionResultID string, scores []model.CorrectionRubricScores) error {
+	r.rubricScores = scores
+	return nil
+}
+
+func (r *stubCorrectResultsRepository) SaveCorrectionErrors(tx *gorm.DB, correctionResultID string, correctionErrors []model.CorrectionErrors) error {
+	r.correctionErrors = correctionErrors
+	return nil
+}
+
+func (r *stubCorrectResultsRepository) CompleteCorrectionResult(tx *gorm.DB, req *model.UpdateCorrectionResultRequest) error {
+	r.completed = req
+	return nil
+}
+
+type stubQuestionAnswersRepository struct {
+	repository.QuestionAnswersRepository
+}
+
+func (r *stubQuestionAnswersRepository) GetQuestionAnswerById(id string) (*model.QuestionAnswers, error) {
+	return &model.QuestionAnswers{
+		ID:                       id,
+		UserID:                   "user-1",
+		QuestionTemplateMasterID: "template-1",
+		UserAnswer:               "I go to see a movie with my friends yesterday.",
+		ChallengeCount:           1,
+	}, nil
+}
+
+type stubQuestionTemplateMastersRepository struct {
+	repository.QuestionTemplateMastersRepository
+}
+
+func (r *stubQuestionTemplateMastersRepository) GetQuestionTemplateMasterLLMById(id string) (*model.GetQuestionTemplateMastersLLMResponse, error) {
+	return &model.GetQuestionTemplateMastersLLMResponse{
+		ID:           id,
+		QuestionType: "translate",
+		English:      "Translate the following Japanese sentence to English: 昨日、友達と映画を見に行きました。",
+		Japanese:     "次の日本語の文を英語に翻訳してください：昨日、友達と映画を見に行きました。",
+		Level:        "inter",
+		Points:       10,
+	}, nil
+}
+
+type stubReferenceAnswersRepository struct {
+	repository.QuestionReferenceAnswersRepository
+}
+
+func (r *stubReferenceAnswersRepository) GetReferenceAnswers(questionTemplateMasterID string) ([]model.QuestionReferenceAnswers, error) {
+	return nil, nil
+}
+
+type stubGradingFlagsRepository struct {
+	repository.GradingFlagsRepository
+}
+
+func (r *stubGradingFlagsRepository) CreateGradingFlags(flags []model.GradingFlags) error {
+	return nil
+}
+
+type noQuota struct{}
+
+func (noQuota) CheckQuota(ctx context.Context, userID string) error { return nil }
+
+// noopConnector トランザクションの開始・確定のみを受け付けるデータベース接続（リポジトリはスタブのためSQLは実行しない）
+type noopConnector struct{}
+
+func (noopConnector) Connect(ctx context.Context) (driver.Conn, error) { return noopConn{}, nil }
+func (noopConnector) Driver() driver.Driver                            { return noopDriver{} }
+
+type noopDriver struct{}
+
+func (noopDriver) Open(name string) (driver.Conn, error) { return noopConn{}, nil }
+
+type noopConn struct{}
+
+func (noopConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("テスト用の接続ではSQLを実行できません")
+}
+func (noopConn) Close() error              { return nil }
+func (noopConn) Begin() (driver.Tx, error) { return noopConn{}, nil }
+func (noopConn) Commit() error             { return nil }
+func (noopConn) Rollback() error           { return nil }
+
+// newTestCorrectResultsService スタブのリポジトリとLLMクライアントを使う採点サービスを作成する
+func newTestCorrectResultsService(t *testing.T, repo repository.CorrectResultsRepository, client llm.LLMClient) CorrectResultsService {
+	t.Helper()
+	return newTestCorrectResultsServiceWith(t, repo, &stubQuestionAnswersRepository{}, client, 1)
+}
+
+// newTestCorrectResultsServiceWith 解答のリポジトリと一括採点の並行数を指定して採点サービスを作成する
+func newTestCorrectResultsServiceWith(t *testing.T, repo repository.CorrectResultsRepository, answers repository.QuestionAnswersRepository, client llm.LLMClient, batchConcurrency int) CorrectResultsService {
+	t.Helper()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(noopConnector{}),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("テスト用のデータベース接続の作成に失敗しました: %v", err)
+	}
+	prompts, err := prompt.NewRegistry("")
+	if err != nil {
+		t.Fatalf("プロンプトの読み込みに失敗しました: %v", err)
+	}
+
+	return NewCorrectResultsService(
+		db,
+		repo,
+		&stubQuestionTemplateMastersRepository{},
+		answers,
+		nil,
+		&stubReferenceAnswersRepository{},
+		&stubGradingFlagsRepository{},
+		client,
+		llm.NewModelRouter(nil, nil),
+		noQuota{},
+		nil,
+		prompts,
+		ConsensusConfig{},
+		batchConcurrency,
+	)
+}
+
+func TestGrandCorrectResult(t *testing.T) {
+	fake := llm.NewFakeClient()
+	client := llm.NewFallbackClient([]llm.Provider{{Name: "fake", Client: fake}})
+	repo := &stubCorrectResultsRepository{}
+	s := newTestCorrectResultsService(t, repo, client)
+
+	res, err := s.GrandCorrectResult(context.Background(), "user-1", &model.GrandCorrectResultRequest{ID: "result-1"})
+	if err != nil {
+		t.Fatalf("採点に失敗しました: %v", err)
+	}
+
+	// フェイクの採点結果（文法70・語彙80・課題達成度90・自然さ70・スペル100）の重み付き平均は80%、10点満点で8点
+	if res.CorrectRate != 80 || res.GetPoints != 8 {
+		t.Errorf("正答率・得点 = %d, %d; want 80, 8", res.CorrectRate, res.GetPoints)
+	}
+	if res.Status != "COMPLETED" || res.LLMModel != llm.FakeModel || res.LLMProvider != "fake" {
+		t.Errorf("ステータス・モデル・プロバイダ = %s, %s, %s", res.Status, res.LLMModel, res.LLMProvider)
+	}
+	if len(res.RubricScores) != 5 {
+		t.Errorf("評価観点の数 = %d, want 5", len(res.RubricScores))
+	}
+
+	saved := repo.completed
+	if saved == nil {
+		t.Fatal("採点結果が保存されていません")
+	}
+	if saved.ID != res.ID || saved.GetPoints != res.GetPoints || saved.CorrectRate != res.CorrectRate ||
+		saved.Advice != res.Advice || saved.ExampleCorrection != res.ExampleCorrection || saved.Status != res.Status {
+		t.Errorf("保存した採点結果 = %+v, レスポンス = %+v", *saved, res)
+	}
+	if len(repo.rubricScores) != 5 {
+		t.Errorf("保存した評価観点の数 = %d, want 5", len(repo.rubricScores))
+	}
+	if calls := fake.Calls(); len(calls) != 1 || calls[0].UserID != "user-1" || calls[0].SubjectID != "result-1" {
+		t.Errorf("LLMへのリクエスト = %+v", calls)
+	}
+}
+
+func TestGrandCorrectResultInvalidOutput(t *testing.T) {
+	// 修正を依頼してもスキーマを満たさない出力を返し続ける
+	fake := llm.NewFakeClient()
+	fake.SetDefault(llm.FeatureGrading, `{"advice": "評価観点がありません"}`)
+	repo := &stubCorrectResultsRepository{}
+	s := newTestCorrectResultsService(t, repo, fake)
+
+	_, err := s.GrandCorrectResult(context.Background(), "user-1", &model.GrandCorrectResultRequest{ID: "result-1"})
+	if !llm.IsValidationError(err) {
+		t.Fatalf("エラー = %v, want ValidationError", err)
+	}
+	if got := len(fake.Calls()); got != llm.DefaultMaxRepairs+1 {
+		t.Errorf("LLMの呼び出し回数 = %d, want %d", got, llm.DefaultMaxRepairs+1)
+	}
+	if repo.completed != nil || repo.rubricScores != nil {
+		t.Error("検証を通らない採点結果が保存されています")
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+// maxConsensusSamples 合議採点で1つの解答を採点する最大回数
+const maxConsensusSamples = 9
+
+// ConsensusConfig 合議採点（同じ解答を複数回採点して結果をまとめる）の設定
+type ConsensusConfig struct {
+	Samples      map[string]int // 問題のレベル（basic / inter / adv）ごとの採点回数（1以下の場合は合議採点を行わない）
+	ReviewStdDev float64        // 採点ごとの正答率の標準偏差がこの値を超えた場合は要確認とする（0以下の場合は判定しない）
+}
+
+// samplesFor 問題のレベルに対する採点回数
+func (c ConsensusConfig) samplesFor(level string) int {
+	return min(max(c.Samples[level], 1), maxConsensusSamples)
+}
+
+// gradingSample 合議採点の1回分の採点
+type gradingSample struct {
+	Output      gradingOutput
+	Response    *llm.Response
+	CorrectRate int // 評価観点ごとの得点から算出した正答率（0-100）
+}
+
+// consensusGrading 合議採点の結果
+type consensusGrading struct {
+	Output    *gradingOutput
+	Summary   *model.ConsensusSummary
+	Models    []string // 採点に使用したモデル
+	Providers []string // 応答したプロバイダ
+}
+
+// sampleGradings 同じリクエストでn回の採点を並行して行い、成功した採点を返す
+// 一部の採点が失敗した場合は成功した採点のみを返し、すべて失敗した場合はすべての採点のエラーを返す
+func sampleGradings(ctx context.Context, client llm.LLMClient, req *llm.Request, n int, guard *answerGuard) ([]gradingSample, error) {
+	samples := make([]*gradingSample, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sampleReq := *req
+			var output gradingOutput
+			// 誤りの位置の補正と、解答の特徴と矛盾する採点結果の修正の依頼
+			checkOutput := func() []string {
+				return guard.check(&output)
+			}
+			res, err := llm.GenerateStructured(ctx, client, &sampleReq, &output, checkOutput)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			correctRate, _ := scoreRubric(output.Rubric, 100)
+			samples[i] = &gradingSample{Output: output, Response: res, CorrectRate: correctRate}
+		}()
+	}
+	wg.Wait()
+
+	succeeded := make([]gradingSample, 0, n)
+	for _, sample := range samples {
+		if sample != nil {
+			succeeded = append(succeeded, *sample)
+		}
+	}
+	if len(succeeded) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return succeeded, nil
+}
+
+// buildConsensus 複数回の採点を1つの採点結果にまとめる
+// 評価観点ごとの得点は中央値とし、模範解答は最も多く出力されたものを採用する
+// 誤りの指摘とアドバイスは、採用した模範解答を出力した採点のうち正答率が中央値に最も近い採点のものを使用する
+func buildConsensus(samples []gradingSample, reviewStdDev float64) *consensusGrading {
+	rates := make([]int, 0, len(samples))
+	for _, sample := range samples {
+		rates = append(rates, sample.CorrectRate)
+	}
+	medianRate := median(rates)
+
+	// 評価観点ごとの得点の中央値（根拠は中央値に最も近い得点の採点のもの）
+	rubric := make(map[string]rubricScoreOutput, len(gradingRubric))
+	for _, c := range gradingRubric {
+		scores := make([]int, 0, len(samples))
+		for _, sample := range samples {
+			scores = append(scores, sample.Output.Rubric[c.Name].Score)
+		}
+		score := int(math.Round(median(scores)))
+		rubric[c.Name] = rubricScoreOutput{Score: score, Rationale: nearestRubricScore(samples, c.Name, score).Rationale}
+	}
+
+	// 最も多く出力された模範解答（同数の場合は正答率が中央値に近い採点のもの）
+	counts := make(map[string]int, len(samples))
+	for _, sample := range samples {
+		counts[normalizeForMatch(sample.Output.ExampleCorrection)]++
+	}
+	order := make([]int, len(samples))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := samples[order[i]], samples[order[j]]
+		countA, countB := counts[normalizeForMatch(a.Output.ExampleCorrection)], counts[normalizeForMatch(b.Output.ExampleCorrection)]
+		if countA != countB {
+			return countA > countB
+		}
+		return math.Abs(float64(a.CorrectRate)-medianRate) < math.Abs(float64(b.CorrectRate)-medianRate)
+	})
+	representative := samples[order[0]]
+
+	variance := scoreVariance(rates)
+	consensus := &consensusGrading{
+		Output: &gradingOutput{
+			Rubric:            rubric,
+			Errors:            representative.Output.Errors,
+			ExampleCorrection: representative.Output.ExampleCorrection,
+			Advice:            representative.Output.Advice,
+		},
+		Summary: &model.ConsensusSummary{
+			Samples:       len(samples),
+			ScoreVariance: math.Round(variance*100) / 100,
+			NeedsReview:   reviewStdDev > 0 && math.Sqrt(variance) > reviewStdDev,
+		},
+	}
+	for _, sample := range samples {
+		consensus.Models = append(consensus.Models, sample.Response.Model)
+		consensus.Providers = append(consensus.Providers, sample.Response.Provider)
+	}
+	return consensus
+}
+
+// nearestRubricScore 評価観点の得点がscoreに最も近い採点の得点と根拠
+func nearestRubricScore(samples []gradingSample, criterion string, score int) rubricScoreOutput {
+	nearest := samples[0].Output.Rubric[criterion]
+	for _, sample := range samples[1:] {
+		if candidate := sample.Output.Rubric[criterion]; abs(candidate.Score-score) < abs(nearest.Score-score) {
+			nearest = candidate
+		}
+	}
+	return nearest
+}
+
+// median 中央値（要素数が偶数の場合は中央の2つの平均）
+func median(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return float64(sorted[mid])
+	}
+	return float64(sorted[mid-1]+sorted[mid]) / 2
+}
+
+// scoreVariance 分散（母分散）
+func scoreVariance(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (float64(v) - mean) * (float64(v) - mean)
+	}
+	return squares / float64(len(values))
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+// consensusSample すべての評価観点が同じ得点の採点（正答率は得点と同じ）
+func consensusSample(score int, example string) gradingSample {
+	return gradingSample{
+		Output: gradingOutput{
+			Rubric:            uniformRubric(score, fmt.Sprintf("根拠%d", score)),
+			Errors:            []correctionErrorOutput{},
+			ExampleCorrection: example,
+			Advice:            fmt.Sprintf("アドバイス%d", score),
+		},
+		Response:    &llm.Response{Model: llm.FakeModel, Provider: "fake"},
+		CorrectRate: score,
+	}
+}
+
+func TestBuildConsensus(t *testing.T) {
+	tests := []struct {
+		name          string
+		samples       []gradingSample
+		reviewStdDev  float64
+		wantScore     int    // 評価観点ごとの得点（中央値）
+		wantRationale string // 中央値に最も近い得点の採点の根拠
+		wantAdvice    string // 採用した模範解答を出力した採点のうち正答率が中央値に最も近い採点のアドバイス
+		wantExample   string
+		want          model.ConsensusSummary
+	}{
+		{
+			name:          "奇数回の採点",
+			samples:       []gradingSample{consensusSample(60, "A"), consensusSample(80, "A"), consensusSample(70, "A")},
+			reviewStdDev:  10,
+			wantScore:     70,
+			wantRationale: "根拠70",
+			wantAdvice:    "アドバイス70",
+			wantExample:   "A",
+			want:          model.ConsensusSummary{Samples: 3, ScoreVariance: 66.67},
+		},
+		{
+			name:          "偶数回の採点は中央の2つの平均",
+			samples:       []gradingSample{consensusSample(80, "A"), consensusSample(60, "A")},
+			reviewStdDev:  10,
+			wantScore:     70,
+			wantRationale: "根拠80",
+			wantAdvice:    "アドバイス80",
+			wantExample:   "A",
+			// 標準偏差が閾値ちょうどの場合は要確認としない
+			want: model.ConsensusSummary{Samples: 2, ScoreVariance: 100},
+		},
+		{
+			name:          "偶数回の採点の中央値は四捨五入する",
+			samples:       []gradingSample{consensusSample(60, "A"), consensusSample(81, "A")},
+			reviewStdDev:  10,
+			wantScore:     71,
+			wantRationale: "根拠81",
+			wantAdvice:    "アドバイス60",
+			wantExample:   "A",
+			want:          model.ConsensusSummary{Samples: 2, ScoreVariance: 110.25, NeedsReview: true},
+		},
+		{
+			name:          "閾値が0の場合は要確認としない",
+			samples:       []gradingSample{consensusSample(20, "A"), consensusSample(90, "A"), consensusSample(100, "A")},
+			wantScore:     90,
+			wantRationale: "根拠90",
+			wantAdvice:    "アドバイス90",
+			wantExample:   "A",
+			want:          model.ConsensusSummary{Samples: 3, ScoreVariance: 1266.67},
+		},
+		{
+			name:          "失敗した採点を除いた1回の採点",
+			samples:       []gradingSample{consensusSample(75, "A")},
+			reviewStdDev:  5,
+			wantScore:     75,
+			wantRationale: "根拠75",
+			wantAdvice:    "アドバイス75",
+			wantExample:   "A",
+			want:          model.ConsensusSummary{Samples: 1},
+		},
+		{
+			name:          "最も多く出力された模範解答を採用する",
+			samples:       []gradingSample{consensusSample(70, "A"), consensusSample(90, "B."), consensusSample(60, "b"), consensusSample(50, "C")},
+			reviewStdDev:  15,
+			wantScore:     65,
+			wantRationale: "根拠70",
+			wantAdvice:    "アドバイス60",
+			wantExample:   "b",
+			want:          model.ConsensusSummary{Samples: 4, ScoreVariance: 218.75},
+		},
+		{
+			name:          "模範解答が同数の場合は正答率が中央値に近い採点",
+			samples:       []gradingSample{consensusSample(40, "A"), consensusSample(95, "B"), consensusSample(72, "C")},
+			reviewStdDev:  25,
+			wantScore:     72,
+			wantRationale: "根拠72",
+			wantAdvice:    "アドバイス72",
+			wantExample:   "C",
+			want:          model.ConsensusSummary{Samples: 3, ScoreVariance: 508.67},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consensus := buildConsensus(tt.samples, tt.reviewStdDev)
+
+			for _, c := range gradingRubric {
+				if got := consensus.Output.Rubric[c.Name]; got.Score != tt.wantScore || got.Rationale != tt.wantRationale {
+					t.Errorf("%s の得点 = %+v, want %d（%s）", c.Name, got, tt.wantScore, tt.wantRationale)
+				}
+			}
+			if consensus.Output.ExampleCorrection != tt.wantExample || consensus.Output.Advice != tt.wantAdvice {
+				t.Errorf("模範解答・アドバイス = %q, %q; want %q, %q", consensus.Output.ExampleCorrection, consensus.Output.Advice, tt.wantExample, tt.wantAdvice)
+			}
+			if *consensus.Summary != tt.want {
+				t.Errorf("集計 = %+v, want %+v", *consensus.Summary, tt.want)
+			}
+			if len(consensus.Models) != len(tt.samples) || len(consensus.Providers) != len(tt.samples) {
+				t.Errorf("モデル・プロバイダ = %v, %v", consensus.Models, consensus.Providers)
+			}
+		})
+	}
+}
+
+// 評価観点ごとに得点の中央値をとる
+func TestBuildConsensusPerCriterion(t *testing.T) {
+	samples := []gradingSample{consensusSample(60, "A"), consensusSample(80, "A"), consensusSample(70, "A")}
+	samples[0].Output.Rubric[model.RubricCriterionGrammar] = rubricScoreOutput{Score: 100, Rationale: "文法の誤りはありません"}
+	samples[1].Output.Rubric[model.RubricCriterionSpelling] = rubricScoreOutput{Score: 0, Rationale: "綴りの誤りが多くあります"}
+
+	consensus := buildConsensus(samples, 0)
+	want := map[string]int{
+		model.RubricCriterionGrammar:        80,
+		model.RubricCriterionVocabulary:     70,
+		model.RubricCriterionTaskFulfilment: 70,
+		model.RubricCriterionNaturalness:    70,
+		model.RubricCriterionSpelling:       60,
+	}
+	for criterion, score := range want {
+		if got := consensus.Output.Rubric[criterion].Score; got != score {
+			t.Errorf("%s の得点 = %d, want %d", criterion, got, score)
+		}
+	}
+}
+
+func TestMedianAndScoreVariance(t *testing.T) {
+	tests := []struct {
+		values       []int
+		wantMedian   float64
+		wantVariance float64
+	}{
+		{values: nil},
+		{values: []int{70}, wantMedian: 70},
+		{values: []int{90, 50, 70}, wantMedian: 70, wantVariance: 800.0 / 3},
+		{values: []int{90, 50, 70, 60}, wantMedian: 65, wantVariance: 218.75},
+		{values: []int{80, 80}, wantMedian: 80},
+	}
+
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.wantMedian {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.wantMedian)
+		}
+		if got := scoreVariance(tt.values); got != tt.wantVariance {
+			t.Errorf("scoreVariance(%v) = %v, want %v", tt.values, got, tt.wantVariance)
+		}
+	}
+
+	// 元の値の順序は変更しない
+	values := []int{90, 50, 70}
+	median(values)
+	if values[0] != 90 || values[1] != 50 {
+		t.Errorf("median が引数を並べ替えています: %v", values)
+	}
+}
+
+// failingSamplesClient 最初のfailures回の呼び出しを失敗させるLLMClient
+type failingSamplesClient struct {
+	next llm.LLMClient
+
+	mu       sync.Mutex
+	failures int
+}
+
+func (c *failingSamplesClient) Generate(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+	c.mu.Lock()
+	fail := c.failures > 0
+	c.failures--
+	c.mu.Unlock()
+
+	if fail {
+		return nil, errors.New("採点できませんでした")
+	}
+	return c.next.Generate(ctx, req)
+}
+
+// 失敗した採点を除いて結果をまとめ、すべて失敗した場合はエラーを返す
+func TestSampleGradingsSkipsFailedSamples(t *testing.T) {
+	req := llm.NewUserRequest(llm.FeatureGrading, llm.FakeModel, 1000, "採点してください")
+	req.Output = gradingOutputSchema()
+	answer := "I went to see a movie with my friends yesterday."
+
+	client := &failingSamplesClient{next: llm.NewFakeClient(), failures: 2}
+	samples, err := sampleGradings(context.Background(), client, req, 5, newAnswerGuard(answer, "", "translate"))
+	if err != nil {
+		t.Fatalf("合議採点に失敗しました: %v", err)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("成功した採点の数 = %d, want 3", len(samples))
+	}
+	for _, sample := range samples {
+		if sample.CorrectRate != 80 || sample.Response == nil {
+			t.Errorf("採点 = %+v, want 正答率80%%", sample)
+		}
+	}
+	if consensus := buildConsensus(samples, 5); consensus.Summary.Samples != 3 || consensus.Summary.NeedsReview {
+		t.Errorf("集計 = %+v, want 3回の採点・要確認なし", *consensus.Summary)
+	}
+
+	client = &failingSamplesClient{next: llm.NewFakeClient(), failures: 3}
+	if _, err := sampleGradings(context.Background(), client, req, 3, newAnswerGuard(answer, "", "translate")); err == nil {
+		t.Error("すべての採点が失敗した場合にエラーを返していません")
+	}
+}
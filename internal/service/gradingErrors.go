@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+// correctionErrorOutput LLMが指摘した解答中の誤り
+type correctionErrorOutput struct {
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Original    string `json:"original"`
+	Suggestion  string `json:"suggestion"`
+	ErrorType   string `json:"error_type"`
+	Explanation string `json:"explanation"`
+}
+
+// correctionErrorsOutputSchema 解答中の誤りの一覧の出力スキーマ（誤りがない場合は空配列）
+func correctionErrorsOutputSchema() *llm.Schema {
+	return llm.ArraySchema("解答中の誤りの一覧（誤りがない場合は空配列）", llm.ObjectSchema(map[string]*llm.Schema{
+		"start":       llm.IntegerSchema("誤りの開始位置（解答の先頭からの文字数、0始まり）", 0, maxAnswerRunes),
+		"end":         llm.IntegerSchema("誤りの終了位置（この位置の文字を含まない）", 0, maxAnswerRunes),
+		"original":    llm.StringSchema("解答中の誤りのある文字列（解答の start から end までと一致させる）"),
+		"suggestion":  {Type: "string", Description: "修正案（削除すべき場合は空文字）"},
+		"error_type":  llm.EnumSchema("誤りの種類", model.CorrectionErrorTypes...),
+		"explanation": llm.StringSchema("誤りの説明（日本語）"),
+	}))
+}
+
+// maxAnswerRunes 誤りの位置として許容する最大値
+const maxAnswerRunes = 100000
+
+// alignCorrectionErrors 誤りの位置が解答の文字列と一致しているかを確認する
+// 位置がずれている場合は解答中の同じ文字列のうち指定位置に最も近いものに補正し、見つからない誤りは違反として返す
+func alignCorrectionErrors(annotations []correctionErrorOutput, answer string) []string {
+	runes := []rune(answer)
+
+	var violations []string
+	for i := range annotations {
+		e := &annotations[i]
+		if e.Start >= 0 && e.Start <= e.End && e.End <= len(runes) && string(runes[e.Start:e.End]) == e.Original {
+			continue
+		}
+
+		start := nearestOccurrence(runes, []rune(e.Original), e.Start)
+		if start < 0 {
+			violations = append(violations, fmt.Sprintf("$.errors[%d].original: %q が解答中に見つかりません（解答の文字列をそのまま指定してください）", i, e.Original))
+			continue
+		}
+		e.Start = start
+		e.End = start + len([]rune(e.Original))
+	}
+
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].Start < annotations[j].Start
+	})
+	return violations
+}
+
+// nearestOccurrence textの中でpatternが出現する位置のうちhintに最も近いものを返す（見つからない場合は-1）
+func nearestOccurrence(text []rune, pattern []rune, hint int) int {
+	if len(pattern) == 0 || len(pattern) > len(text) {
+		return -1
+	}
+
+	best := -1
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if string(text[i:i+len(pattern)]) != string(pattern) {
+			continue
+		}
+		if best < 0 || abs(i-hint) < abs(best-hint) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// correctionErrorRecords 保存する解答中の誤り
+func correctionErrorRecords(annotations []correctionErrorOutput) []model.CorrectionErrors {
+	records := make([]model.CorrectionErrors, 0, len(annotations))
+	for _, e := range annotations {
+		records = append(records, model.CorrectionErrors{
+			StartOffset:  e.Start,
+			EndOffset:    e.End,
+			OriginalText: e.Original,
+			Suggestion:   e.Suggestion,
+			ErrorType:    e.ErrorType,
+			Explanation:  e.Explanation,
+		})
+	}
+	return records
+}
+
+// correctionErrorSummaries レスポンスに含める解答中の誤り（解答中の位置順）
+func correctionErrorSummaries(records []model.CorrectionErrors) []model.CorrectionErrorSummary {
+	summaries := make([]model.CorrectionErrorSummary, 0, len(records))
+	for _, r := range records {
+		summaries = append(summaries, model.CorrectionErrorSummary{
+			StartOffset:  r.StartOffset,
+			EndOffset:    r.EndOffset,
+			OriginalText: r.OriginalText,
+			Suggestion:   r.Suggestion,
+			ErrorType:    r.ErrorType,
+			Explanation:  r.Explanation,
+		})
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].StartOffset < summaries[j].StartOffset
+	})
+	return summaries
+}
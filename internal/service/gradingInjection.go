@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+)
+
+// injectionPattern 解答中の指示のような記述を検出するパターン
+type injectionPattern struct {
+	Description string
+	Pattern     *regexp.Regexp
+}
+
+// injectionPatterns 採点への指示・役割の変更・出力形式の偽装などを検出するパターン
+var injectionPatterns = []injectionPattern{
+	// 採点者への命令（「ignore all previous instructions」など）のみを対象とし、「ignore my previous mistake」のような文は除く
+	{Description: "指示の無視を求める記述", Pattern: regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+((the|all|any|your)\s+){1,2}((above|previous|prior|earlier|system)\s+)?(instructions?|prompts?)\b`)},
+	{Description: "得点を指定する記述", Pattern: regexp.MustCompile(`(?i)\b(give|award|assign|grade|score|rate|mark)\b.{0,30}\b(full|perfect|max(imum)?|100|10|top)\b.{0,20}\b(points?|marks?|scores?|grades?)\b`)},
+	{Description: "採点者の役割を変更する記述", Pattern: regexp.MustCompile(`(?i)\b(you are now|act as|pretend to be|as an ai|system prompt|developer mode|jailbreak)\b`)},
+	{Description: "会話の発話者を装う記述", Pattern: regexp.MustCompile(`(?im)^\s*(system|assistant|user)\s*:`)},
+	{Description: "採点結果のJSONを装う記述", Pattern: regexp.MustCompile(`(?i)"(rubric|score|get_points|correct_rate|advice|example_correction)"\s*:`)},
+	{Description: "プロンプトの区切りを装う記述", Pattern: promptDelimiterPattern},
+	{Description: "採点への指示のような日本語の記述", Pattern: regexp.MustCompile(`無視して|指示に従|満点|100点|採点者|プロンプト|点数を(つけ|付け|上げ)`)},
+}
+
+// promptDelimiterPattern プロンプトで学習者の入力を囲むタグ
+var promptDelimiterPattern = regexp.MustCompile(`(?i)<\s*/?\s*(learner_answer|appeal_reason|learner_message)\s*>`)
+
+// maxSignalExcerptRunes 検出した記述として記録する最大文字数
+const maxSignalExcerptRunes = 60
+
+// injectionSignal 学習者の入力から検出した指示のような記述
+type injectionSignal struct {
+	Description string // 検出したパターンの説明
+	Excerpt     string // 該当箇所（記録用。プロンプトには含めない）
+}
+
+func (s injectionSignal) String() string {
+	return fmt.Sprintf("%s: %q", s.Description, s.Excerpt)
+}
+
+// detectInjection 学習者の入力から採点への指示のような記述を検出する
+func detectInjection(text string) []injectionSignal {
+	var signals []injectionSignal
+	for _, p := range injectionPatterns {
+		match := p.Pattern.FindString(text)
+		if match == "" {
+			continue
+		}
+		excerpt := []rune(strings.TrimSpace(match))
+		if len(excerpt) > maxSignalExcerptRunes {
+			excerpt = append(excerpt[:maxSignalExcerptRunes], '…')
+		}
+		signals = append(signals, injectionSignal{Description: p.Description, Excerpt: string(excerpt)})
+	}
+	return signals
+}
+
+// neutralizeDelimiters 学習者の入力中のプロンプトの区切りタグを全角の山括弧に置き換え、区切りを閉じられないようにする
+// 文字数は変わらないため、誤りの位置は元の解答の位置のまま使える
+func neutralizeDelimiters(text string) string {
+	return promptDelimiterPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		return strings.NewReplacer("<", "＜", ">", "＞").Replace(tag)
+	})
+}
+
+// 採点結果と解答の特徴の矛盾を判定するしきい値
+const (
+	highScoreRate           = 80  // 高得点として扱う正答率
+	minWordsForHighScore    = 3   // 高得点に必要な解答の語数（穴埋め問題を除く）
+	minErrorsAgainstPerfect = 3   // 満点と矛盾する誤りの指摘の件数
+	minCorrectionOverlap    = 0.3 // 高得点の解答に必要な模範解答との語の一致率（穴埋め問題を除く）
+	minSuspiciousOverlap    = 0.6 // 指示のような記述を含む解答で高得点に必要な模範解答との語の一致率
+)
+
+// answerGuard 学習者の解答によるプロンプトインジェクションへの対策
+// 解答中の指示のような記述を検出し、採点結果が解答の決定的な特徴（語数・誤りの件数・模範解答との一致度）と矛盾していないかを確認する
+type answerGuard struct {
+	answer       string            // 区切りタグを無効にした解答（プロンプトに含め、誤りの位置の確認に使う）
+	questionType string            // 問題の種類（essay / translate / fill）
+	signals      []injectionSignal // 解答・再採点の理由中の指示のような記述
+
+	mu              sync.Mutex
+	inconsistencies []string // 検出した採点結果の矛盾（重複を除く）
+}
+
+// newAnswerGuard 解答（と再採点の理由）から指示のような記述を検出する
+func newAnswerGuard(answer string, appealReason string, questionType string) *answerGuard {
+	signals := detectInjection(answer)
+	for _, signal := range detectInjection(appealReason) {
+		signal.Description = "再採点の理由中の" + signal.Description
+		signals = append(signals, signal)
+	}
+	return &answerGuard{
+		answer:       neutralizeDelimiters(answer),
+		questionType: questionType,
+		signals:      signals,
+	}
+}
+
+// signalDescriptions プロンプトに含める検出した記述の種類（学習者の入力そのものは含めない）
+func (g *answerGuard) signalDescriptions() []string {
+	var descriptions []string
+	for _, signal := range g.signals {
+		if !slices.Contains(descriptions, signal.Description) {
+			descriptions = append(descriptions, signal.Description)
+		}
+	}
+	return descriptions
+}
+
+// check 採点結果を確認し、LLMに修正を依頼する違反を返す
+// 誤りの位置は解答と一致するように補正し、解答の特徴と矛盾する採点結果は採点のやり直しを依頼する
+func (g *answerGuard) check(output *gradingOutput) []string {
+	violations := alignCorrectionErrors(output.Errors, g.answer)
+
+	inconsistencies := g.gradeInconsistencies(output)
+	g.mu.Lock()
+	for _, inconsistency := range inconsistencies {
+		if !slices.Contains(g.inconsistencies, inconsistency) {
+			g.inconsistencies = append(g.inconsistencies, inconsistency)
+		}
+	}
+	g.mu.Unlock()
+
+	return append(violations, inconsistencies...)
+}
+
+// gradeInconsistencies 採点結果と解答の特徴の矛盾
+func (g *answerGuard) gradeInconsistencies(output *gradingOutput) []string {
+	correctRate, _ := scoreRubric(output.Rubric, 100)
+	if correctRate < highScoreRate {
+		return nil
+	}
+
+	var inconsistencies []string
+	words := answerWords(g.answer)
+	if g.questionType != "fill" && len(words) < minWordsForHighScore {
+		inconsistencies = append(inconsistencies, fmt.Sprintf("$.rubric: 解答が%d語しかないにもかかわらず正答率が%dになっています。解答の内容だけに基づいて採点し直してください", len(words), correctRate))
+	}
+	if correctRate == 100 && len(output.Errors) >= minErrorsAgainstPerfect {
+		inconsistencies = append(inconsistencies, fmt.Sprintf("$.rubric: 誤りを%d件指摘しているにもかかわらず正答率が100になっています。指摘した誤りに応じて採点し直してください", len(output.Errors)))
+	}
+
+	minOverlap := minCorrectionOverlap
+	if len(g.signals) > 0 {
+		minOverlap = minSuspiciousOverlap
+	}
+	if g.questionType != "fill" && output.ExampleCorrection != "" && wordOverlap(words, answerWords(output.ExampleCorrection)) < minOverlap {
+		inconsistencies = append(inconsistencies, fmt.Sprintf("$.rubric: 解答の大部分が模範解答と異なるにもかかわらず正答率が%dになっています。解答の内容だけに基づいて採点し直してください", correctRate))
+	}
+	return inconsistencies
+}
+
+// flags 採点時に検出した内容を記録用に返す
+func (g *answerGuard) flags(correctionResultID string, userID string) []model.GradingFlags {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var flags []model.GradingFlags
+	if len(g.signals) > 0 {
+		details := make([]string, 0, len(g.signals))
+		for _, signal := range g.signals {
+			details = append(details, signal.String())
+		}
+		flags = append(flags, model.GradingFlags{
+			CorrectionResultID: correctionResultID,
+			UserID:             userID,
+			FlagType:           model.GradingFlagInjectionSuspected,
+			Details:            strings.Join(details, "\n"),
+		})
+	}
+	if len(g.inconsistencies) > 0 {
+		flags = append(flags, model.GradingFlags{
+			CorrectionResultID: correctionResultID,
+			UserID:             userID,
+			FlagType:           model.GradingFlagInconsistentGrade,
+			Details:            strings.Join(g.inconsistencies, "\n"),
+		})
+	}
+	return flags
+}
+
+// answerWords 解答の語（句読点を除き小文字にしたもの）
+func answerWords(text string) []string {
+	var words []string
+	for _, t := range tokenizeForDiff(text) {
+		if !t.Punct {
+			words = append(words, strings.ToLower(t.Text))
+		}
+	}
+	return words
+}
+
+// wordOverlap 2つの語の並びで共通する語の割合（多い方の語数に対する割合、順序は考慮しない）
+func wordOverlap(a []string, b []string) float64 {
+	total := max(len(a), len(b))
+	if total == 0 {
+		return 1
+	}
+
+	counts := make(map[string]int, len(a))
+	for _, w := range a {
+		counts[w]++
+	}
+	common := 0
+	for _, w := range b {
+		if counts[w] > 0 {
+			counts[w]--
+			common++
+		}
+	}
+	return float64(common) / float64(total)
+}
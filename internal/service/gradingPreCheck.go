@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/spelling"
+)
+
+const (
+	// minAnswerLetters 解答に必要な英字の最小数（穴埋め問題を除く）
+	minAnswerLetters = 2
+	// minLatinRatio 解答の文字のうち英字（ラテン文字）が占める割合の下限
+	minLatinRatio = 0.5
+	// minKnownWordRatio 解答の語のうち英単語の一覧に含まれる語が占める割合の下限（ラテン文字で書かれた英語以外の解答の判定）
+	// 綴りの誤りや固有名詞を含む解答を英語以外としないよう、半分を下限とする
+	minKnownWordRatio = 0.5
+	// minWordsForLanguageCheck 英単語の一覧に含まれる語の割合を確認する解答の最小の語数
+	minWordsForLanguageCheck = 3
+)
+
+// preCheckResult LLMを呼ぶ前の事前チェックの結果
+type preCheckResult struct {
+	Outcome  string         // LLMを呼ばずに採点した理由（model.PreCheck*、LLMで採点する場合は空）
+	Output   *gradingOutput // LLMを呼ばずに採点した結果
+	Findings []string       // LLMで採点する場合にプロンプトに含める指摘
+}
+
+// preCheckAnswer 空・短すぎる・英語以外・問題文の丸写し・解答例との一致を判定し、該当する場合はLLMを呼ばずに採点する
+// 該当しない場合は綴りの誤りの候補をプロンプトに含める指摘として返す
+func preCheckAnswer(checker *spelling.Checker, answer string, question *model.GetQuestionTemplateMastersLLMResponse, referenceAnswers []model.QuestionReferenceAnswers) *preCheckResult {
+	letters, latin := countLetters(answer)
+	switch {
+	case letters == 0:
+		return rejectedAnswer(model.PreCheckEmpty, "解答が入力されていないため採点できません。", "英語で解答を入力してください。", referenceAnswers)
+	case float64(latin)/float64(letters) < minLatinRatio:
+		return rejectedAnswer(model.PreCheckNonEnglish, "解答の大部分が英語以外で書かれているため採点できません。", "日本語ではなく英語で解答してください。", referenceAnswers)
+	case latin < minAnswerLetters && question.QuestionType != "fill":
+		return rejectedAnswer(model.PreCheckTooShort, "解答が短すぎるため採点できません。", "問題の内容を英語の文で表現してみましょう。", referenceAnswers)
+	case !mostlyKnownWords(checker, answer):
+		return rejectedAnswer(model.PreCheckNonEnglish, "解答の大部分が英語以外で書かれているため採点できません。", "英語で解答してください。", referenceAnswers)
+	}
+
+	normalized := normalizeForMatch(answer)
+	for _, r := range referenceAnswers {
+		if normalized == normalizeForMatch(r.Answer) {
+			return matchedReferenceAnswer(answer)
+		}
+	}
+	if normalized == normalizeForMatch(question.English) {
+		return rejectedAnswer(model.PreCheckCopiedQuestion, "問題文をそのまま書き写しているため採点できません。", "問題文を写すのではなく、自分の言葉で解答してみましょう。", referenceAnswers)
+	}
+
+	var findings []string
+	for _, m := range checker.Check(answer) {
+		findings = append(findings, fmt.Sprintf("「%s」（位置: %d-%d）は「%s」の綴りの誤りの可能性があります", m.Word, m.Start, m.End, m.Suggestion))
+	}
+	return &preCheckResult{Findings: findings}
+}
+
+// rejectedAnswer 採点できない解答を0点とする（模範解答には解答例があれば最初のものを示す）
+func rejectedAnswer(outcome string, rationale string, advice string, referenceAnswers []model.QuestionReferenceAnswers) *preCheckResult {
+	output := &gradingOutput{
+		Rubric: uniformRubric(0, rationale),
+		Errors: []correctionErrorOutput{},
+		Advice: rationale + advice,
+	}
+	if len(referenceAnswers) > 0 {
+		output.ExampleCorrection = referenceAnswers[0].Answer
+	}
+	return &preCheckResult{Outcome: outcome, Output: output}
+}
+
+// matchedReferenceAnswer 解答例と一致する解答を満点とする
+func matchedReferenceAnswer(answer string) *preCheckResult {
+	return &preCheckResult{
+		Outcome: model.PreCheckReferenceMatch,
+		Output: &gradingOutput{
+			Rubric:            uniformRubric(100, "解答例と一致しています。"),
+			Errors:            []correctionErrorOutput{},
+			ExampleCorrection: strings.TrimSpace(answer),
+			Advice:            "解答例と一致する正確な解答です。この調子で続けましょう。",
+		},
+	}
+}
+
+// uniformRubric すべての評価観点を同じ得点・根拠にする
+func uniformRubric(score int, rationale string) map[string]rubricScoreOutput {
+	rubric := make(map[string]rubricScoreOutput, len(gradingRubric))
+	for _, c := range gradingRubric {
+		rubric[c.Name] = rubricScoreOutput{Score: score, Rationale: rationale}
+	}
+	return rubric
+}
+
+// mostlyKnownWords ラテン文字で書かれた解答の語の多くが英単語かどうか（ドイツ語・フランス語などの解答を除く）
+// 綴りの誤りの候補（1文字の修正で英単語になる語）は英単語として数える
+func mostlyKnownWords(checker *spelling.Checker, answer string) bool {
+	known, total := checker.KnownWords(answer)
+	if total < minWordsForLanguageCheck {
+		return true
+	}
+	known += len(checker.Check(answer))
+	return float64(known)/float64(total) >= minKnownWordRatio
+}
+
+// countLetters 文字（letter）の数と、そのうちラテン文字の数を数える
+func countLetters(text string) (letters int, latin int) {
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+		}
+	}
+	return letters, latin
+}
+
+// normalizeForMatch 解答の一致判定用に、空白の違いと文末の句読点を無視した文字列にする
+// 大文字小文字と文中の句読点は区別する（文頭の大文字小文字のみ区別しない）
+func normalizeForMatch(text string) string {
+	tokens := tokenizeForDiff(text)
+	for len(tokens) > 0 && tokens[len(tokens)-1].Punct {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	texts := make([]string, 0, len(tokens))
+	for i, t := range tokens {
+		if i == 0 {
+			texts = append(texts, strings.ToLower(t.Text))
+			continue
+		}
+		texts = append(texts, t.Text)
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/spelling"
+)
+
+func TestPreCheckAnswer(t *testing.T) {
+	checker := spelling.NewChecker()
+	question := &model.GetQuestionTemplateMastersLLMResponse{English: "I drink coffee every morning.", QuestionType: "translation"}
+	referenceAnswers := []model.QuestionReferenceAnswers{
+		{Answer: "I have coffee every morning."},
+		{Answer: "Every morning I drink a cup of coffee."},
+	}
+
+	tests := []struct {
+		name         string
+		answer       string
+		questionType string
+		wantOutcome  string
+		wantScore    int      // LLMを呼ばずに採点した場合の各評価観点の得点
+		wantFindings []string // LLMで採点する場合の指摘
+	}{
+		{name: "空の解答", answer: "", wantOutcome: model.PreCheckEmpty},
+		{name: "空白・記号のみの解答", answer: "  ... !? ", wantOutcome: model.PreCheckEmpty},
+		{name: "日本語の解答", answer: "私は毎朝コーヒーを飲みます。", wantOutcome: model.PreCheckNonEnglish},
+		{name: "英字が半分未満の解答", answer: "私は毎朝 coffee を飲みます。", wantOutcome: model.PreCheckNonEnglish},
+		{name: "ラテン文字で書かれた英語以外の解答", answer: "Ich trinke jeden Morgen Kaffee.", wantOutcome: model.PreCheckNonEnglish},
+		{name: "ラテン文字で書かれたフランス語の解答", answer: "Je bois du café chaque matin.", wantOutcome: model.PreCheckNonEnglish},
+		{name: "英字が1文字の解答", answer: "I.", wantOutcome: model.PreCheckTooShort},
+		{name: "穴埋め問題は1文字の解答も採点する", answer: "a", questionType: "fill"},
+		{name: "解答例と一致する解答", answer: "i have coffee  every morning", wantOutcome: model.PreCheckReferenceMatch, wantScore: 100},
+		{name: "2つ目の解答例と一致する解答", answer: "Every morning I drink a cup of coffee!", wantOutcome: model.PreCheckReferenceMatch, wantScore: 100},
+		{name: "問題文の丸写し", answer: "I drink coffee every morning", wantOutcome: model.PreCheckCopiedQuestion},
+		{
+			name:         "綴りの誤りを指摘してLLMで採点する",
+			answer:       "I drink cofee evry morning.",
+			wantFindings: []string{"「cofee」（位置: 8-13）は「coffee」の綴りの誤りの可能性があります", "「evry」（位置: 14-18）は「every」の綴りの誤りの可能性があります"},
+		},
+		{name: "固有名詞と綴りの誤りを含む英語の解答は英語以外としない", answer: "Tanaka drinks cofee in Shibuya evry morning.", wantFindings: []string{
+			"「cofee」（位置: 14-19）は「coffee」の綴りの誤りの可能性があります",
+			"「evry」（位置: 31-35）は「every」の綴りの誤りの可能性があります",
+		}},
+		{name: "2語以下の解答は英単語の一覧で判定しない", answer: "Kaffee trinken", wantFindings: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := *question
+			if tt.questionType != "" {
+				q.QuestionType = tt.questionType
+			}
+			result := preCheckAnswer(checker, tt.answer, &q, referenceAnswers)
+
+			if result.Outcome != tt.wantOutcome {
+				t.Fatalf("事前チェックの結果 = %q, want %q", result.Outcome, tt.wantOutcome)
+			}
+			if tt.wantOutcome == "" {
+				if result.Output != nil {
+					t.Errorf("LLMで採点する解答の採点結果 = %+v, want nil", result.Output)
+				}
+				if !reflect.DeepEqual(result.Findings, tt.wantFindings) {
+					t.Errorf("指摘 = %q, want %q", result.Findings, tt.wantFindings)
+				}
+				return
+			}
+
+			if result.Output == nil {
+				t.Fatal("LLMを呼ばずに採点した結果がありません")
+			}
+			if !reflect.DeepEqual(result.Output.Rubric, uniformRubric(tt.wantScore, result.Output.Rubric[gradingRubric[0].Name].Rationale)) {
+				t.Errorf("評価観点の得点 = %+v, want すべて%d", result.Output.Rubric, tt.wantScore)
+			}
+			if len(result.Findings) != 0 {
+				t.Errorf("LLMを呼ばずに採点した解答の指摘 = %q", result.Findings)
+			}
+			wantExample := referenceAnswers[0].Answer
+			if tt.wantOutcome == model.PreCheckReferenceMatch {
+				wantExample = tt.answer
+			}
+			if result.Output.ExampleCorrection != wantExample {
+				t.Errorf("模範解答 = %q, want %q", result.Output.ExampleCorrection, wantExample)
+			}
+		})
+	}
+}
+
+// 解答例がない問題で採点できない解答は模範解答を空にする
+func TestPreCheckAnswerWithoutReferenceAnswers(t *testing.T) {
+	question := &model.GetQuestionTemplateMastersLLMResponse{English: "I drink coffee every morning.", QuestionType: "translation"}
+
+	result := preCheckAnswer(spelling.NewChecker(), "", question, nil)
+	if result.Outcome != model.PreCheckEmpty || result.Output == nil || result.Output.ExampleCorrection != "" {
+		t.Errorf("事前チェックの結果 = %+v, want 模範解答なしで empty", result)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
+)
+
+// rubricCriterion 採点ルーブリックの評価観点
+type rubricCriterion struct {
+	Name        string // 評価観点（model.RubricCriterion* 定数）
+	Description string // LLMに伝える評価観点の説明
+	Weight      int    // 得点に対する重み（%、合計100）
+}
+
+// gradingRubric 採点ルーブリック（重みの合計は100）
+var gradingRubric = []rubricCriterion{
+	{Name: model.RubricCriterionGrammar, Description: "文法（時制・冠詞・前置詞・語順などの正確さ）", Weight: 30},
+	{Name: model.RubricCriterionVocabulary, Description: "語彙（語の選択の適切さと幅）", Weight: 20},
+	{Name: model.RubricCriterionTaskFulfilment, Description: "課題達成度（問題の意図・日本語の内容を過不足なく伝えているか）", Weight: 25},
+	{Name: model.RubricCriterionNaturalness, Description: "自然さ（ネイティブにとって自然な表現か）", Weight: 15},
+	{Name: model.RubricCriterionSpelling, Description: "スペル（綴り・大文字小文字・句読点）", Weight: 10},
+}
+
+// rubricScoreOutput 評価観点ごとのLLMの採点結果
+type rubricScoreOutput struct {
+	Score     int    `json:"score"`
+	Rationale string `json:"rationale"`
+}
+
+// rubricPromptCriteria プロンプトに埋め込む評価観点の一覧
+func rubricPromptCriteria() []prompt.GradingCriterion {
+	criteria := make([]prompt.GradingCriterion, 0, len(gradingRubric))
+	for _, c := range gradingRubric {
+		criteria = append(criteria, prompt.GradingCriterion{Name: c.Name, Description: c.Description, Weight: c.Weight})
+	}
+	return criteria
+}
+
+// rubricOutputSchema 評価観点ごとの得点（0-100）と根拠の出力スキーマ
+func rubricOutputSchema() *llm.Schema {
+	properties := make(map[string]*llm.Schema, len(gradingRubric))
+	for _, c := range gradingRubric {
+		properties[c.Name] = llm.ObjectSchema(map[string]*llm.Schema{
+			"score":     llm.IntegerSchema(fmt.Sprintf("%sの得点（0-100の整数）", c.Description), 0, 100),
+			"rationale": llm.StringSchema("得点の根拠（日本語で簡潔に）"),
+		})
+	}
+	return llm.ObjectSchema(properties)
+}
+
+// scoreRubric 評価観点ごとの得点を重みで加重平均し、正答率（0-100）と得点（配点満点）を算出する
+func scoreRubric(rubric map[string]rubricScoreOutput, maxPoints int) (correctRate int, points int) {
+	var weighted, totalWeight int
+	for _, c := range gradingRubric {
+		weighted += rubric[c.Name].Score * c.Weight
+		totalWeight += c.Weight
+	}
+	if totalWeight == 0 {
+		return 0, 0
+	}
+
+	rate := float64(weighted) / float64(totalWeight)
+	return int(math.Round(rate)), int(math.Round(rate * float64(maxPoints) / 100))
+}
+
+// rubricScoreRecords 保存する評価観点ごとの得点（ルーブリックの定義順）
+func rubricScoreRecords(rubric map[string]rubricScoreOutput) []model.CorrectionRubricScores {
+	records := make([]model.CorrectionRubricScores, 0, len(gradingRubric))
+	for _, c := range gradingRubric {
+		records = append(records, model.CorrectionRubricScores{
+			Criterion: c.Name,
+			Score:     rubric[c.Name].Score,
+			Weight:    c.Weight,
+			Rationale: rubric[c.Name].Rationale,
+		})
+	}
+	return records
+}
+
+// rubricScoreSummaries レスポンスに含める評価観点ごとの得点（ルーブリックの定義順）
+func rubricScoreSummaries(records []model.CorrectionRubricScores) []model.RubricScoreSummary {
+	order := make(map[string]int, len(gradingRubric))
+	for i, c := range gradingRubric {
+		order[c.Name] = i
+	}
+	sorted := append([]model.CorrectionRubricScores(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		oi, okI := order[sorted[i].Criterion]
+		oj, okJ := order[sorted[j].Criterion]
+		if okI != okJ {
+			return okI
+		}
+		return oi < oj
+	})
+
+	summaries := make([]model.RubricScoreSummary, 0, len(sorted))
+	for _, r := range sorted {
+		summaries = append(summaries, model.RubricScoreSummary{
+			Criterion: r.Criterion,
+			Score:     r.Score,
+			Weight:    r.Weight,
+			Rationale: r.Rationale,
+		})
+	}
+	return summaries
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// GradingStreamObserver 採点中のLLMの出力を受け取る
+type GradingStreamObserver interface {
+	Reset()              // LLMが出力を生成し直す（それまでに受け取ったアドバイスは破棄する）
+	Advice(delta string) // 生成中のアドバイスの断片
+}
+
+// adviceStream LLMが生成中の採点結果（JSON）からアドバイスを取り出して通知するllm.StreamObserver
+type adviceStream struct {
+	observer GradingStreamObserver
+	buf      strings.Builder
+	emitted  int // 通知済みのアドバイスのバイト数
+}
+
+func newAdviceStream(observer GradingStreamObserver) *adviceStream {
+	return &adviceStream{observer: observer}
+}
+
+// Begin 生成の開始（修正依頼などで生成し直す場合は、通知済みのアドバイスを破棄させる）
+func (a *adviceStream) Begin() {
+	if a.emitted > 0 {
+		a.observer.Reset()
+	}
+	a.buf.Reset()
+	a.emitted = 0
+}
+
+func (a *adviceStream) Delta(text string) {
+	a.buf.WriteString(text)
+	advice, ok := partialJSONStringField(a.buf.String(), "advice")
+	if !ok || len(advice) <= a.emitted {
+		return
+	}
+	a.observer.Advice(advice[a.emitted:])
+	a.emitted = len(advice)
+}
+
+// partialJSONStringField 生成途中のJSONオブジェクトから、最上位のキーの文字列の値をデコードできた部分まで返す
+// キーがまだ現れていない場合はfalseを返す
+func partialJSONStringField(raw string, key string) (string, bool) {
+	depth := 0
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case '"':
+			str, end, complete := scanJSONString(raw, i)
+			if !complete {
+				return "", false
+			}
+			i = end
+			if depth != 1 || str != key {
+				continue
+			}
+
+			// キーの後の「:」と値の開始の「"」を読み飛ばす
+			j := skipJSONSpace(raw, end+1)
+			if j >= len(raw) || raw[j] != ':' {
+				continue
+			}
+			j = skipJSONSpace(raw, j+1)
+			if j >= len(raw) || raw[j] != '"' {
+				return "", false
+			}
+			value, _, _ := scanJSONString(raw, j)
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// scanJSONString startの「"」から始まるJSON文字列をデコードする
+// 文字列が閉じていない場合は、デコードできた部分と complete=false を返す
+func scanJSONString(raw string, start int) (value string, end int, complete bool) {
+	var b strings.Builder
+	for i := start + 1; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			return b.String(), i, true
+		case c == '\\':
+			if i+1 >= len(raw) {
+				return b.String(), i, false
+			}
+			switch esc := raw[i+1]; esc {
+			case 'u':
+				if i+6 > len(raw) {
+					return b.String(), i, false
+				}
+				code, err := strconv.ParseUint(raw[i+2:i+6], 16, 32)
+				if err != nil {
+					return b.String(), i, false
+				}
+				r := rune(code)
+				if !utf8.ValidRune(r) {
+					r = utf8.RuneError
+				}
+				b.WriteRune(r)
+				i += 5
+			default:
+				b.WriteByte(unescapeJSON(esc))
+				i++
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), len(raw), false
+}
+
+// unescapeJSON エスケープシーケンス（\n など）の文字を返す
+func unescapeJSON(esc byte) byte {
+	switch esc {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	default:
+		return esc
+	}
+}
+
+func skipJSONSpace(raw string, i int) int {
+	for i < len(raw) && (raw[i] == ' ' || raw[i] == '\n' || raw[i] == '\r' || raw[i] == '\t') {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+// recordingObserver 通知されたアドバイスとリセットを記録するGradingStreamObserver
+type recordingObserver struct {
+	advice strings.Builder
+	resets int
+}
+
+func (o *recordingObserver) Reset() {
+	o.resets++
+	o.advice.Reset()
+}
+
+func (o *recordingObserver) Advice(delta string) {
+	o.advice.WriteString(delta)
+}
+
+func TestPartialJSONStringField(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{name: "キーがまだない", raw: `{"rubric": {"grammar": {"score": 70}}, "adv`, wantOK: false},
+		{name: "値の開始前", raw: `{"advice": `, wantOK: false},
+		{name: "値の途中", raw: `{"advice": "よく書け`, want: "よく書け", wantOK: true},
+		{name: "値が閉じている", raw: `{"advice": "よく書けています。"}`, want: "よく書けています。", wantOK: true},
+		{name: "エスケープの途中で分割", raw: `{"advice": "改行\`, want: "改行", wantOK: true},
+		{name: "エスケープを含む", raw: `{"advice": "改行\n\"引用\""`, want: "改行\n\"引用\"", wantOK: true},
+		{name: "\\uXXXXの途中で分割", raw: `{"advice": "a\u00`, want: "a", wantOK: true},
+		{name: "\\uXXXXを含む", raw: `{"advice": "a\u00e9b"`, want: "aéb", wantOK: true},
+		{name: "入れ子のオブジェクトの同名キーは使わない", raw: `{"rubric": {"advice": "入れ子"}, "advice": "最上位"`, want: "最上位", wantOK: true},
+		{name: "文字列の値に含まれるキー名は使わない", raw: `{"example_correction": "\"advice\": \"x\"", "advice": "本物"`, want: "本物", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := partialJSONStringField(tt.raw, "advice")
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("partialJSONStringField(%q) = %q, %v; want %q, %v", tt.raw, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// 出力をどの位置で分割しても、通知したアドバイスをつなげると完全なアドバイスになる
+func TestAdviceStreamSplitDeltas(t *testing.T) {
+	output := `{"errors": [], "advice": "冠詞に注意\n\"the\"と\u00e9\\の使い分け"}`
+	want := "冠詞に注意\n\"the\"とé\\の使い分け"
+
+	for split := 1; split < len(output); split++ {
+		observer := &recordingObserver{}
+		stream := newAdviceStream(observer)
+		stream.Begin()
+		stream.Delta(output[:split])
+		stream.Delta(output[split:])
+
+		if got := observer.advice.String(); got != want {
+			t.Errorf("分割位置 %d: アドバイス = %q, want %q", split, got, want)
+		}
+	}
+
+	// 1バイトずつ通知された場合も同じ
+	observer := &recordingObserver{}
+	stream := newAdviceStream(observer)
+	stream.Begin()
+	for i := 0; i < len(output); i++ {
+		stream.Delta(output[i : i+1])
+	}
+	if got := observer.advice.String(); got != want {
+		t.Errorf("1バイトずつ: アドバイス = %q, want %q", got, want)
+	}
+}
+
+func TestAdviceStreamReset(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempts   []string
+		wantResets int
+		wantAdvice string
+	}{
+		{
+			name:       "1回で生成",
+			attempts:   []string{`{"advice": "初回"}`},
+			wantResets: 0,
+			wantAdvice: "初回",
+		},
+		{
+			name:       "修正依頼で生成し直す",
+			attempts:   []string{`{"advice": "初回"}`, `{"advice": "修正後"}`},
+			wantResets: 1,
+			wantAdvice: "修正後",
+		},
+		{
+			name:       "アドバイスを通知する前に生成し直す",
+			attempts:   []string{`{"rubric": {}}`, `{"advice": "修正後"}`},
+			wantResets: 0,
+			wantAdvice: "修正後",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observer := &recordingObserver{}
+			stream := newAdviceStream(observer)
+			for _, attempt := range tt.attempts {
+				stream.Begin()
+				stream.Delta(attempt)
+			}
+
+			if observer.resets != tt.wantResets {
+				t.Errorf("リセット回数 = %d, want %d", observer.resets, tt.wantResets)
+			}
+			if got := observer.advice.String(); got != tt.wantAdvice {
+				t.Errorf("アドバイス = %q, want %q", got, tt.wantAdvice)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/repository"
+)
+
+// ErrInvalidLLMCallQuery LLM呼び出しの記録の検索条件が不正
+var ErrInvalidLLMCallQuery = errors.New("検索条件が不正です")
+
+// LLM呼び出しの記録の一覧で返す件数
+const (
+	defaultLLMCallListLimit = 50
+	maxLLMCallListLimit     = 200
+)
+
+// llmCallOutcomes 検索条件に指定できる出力の解析結果
+var llmCallOutcomes = []string{
+	llm.CallOutcomeParsed,
+	llm.CallOutcomeInvalidJSON,
+	llm.CallOutcomeSchemaViolation,
+	llm.CallOutcomeText,
+	llm.CallOutcomeError,
+}
+
+type LLMCallsService interface {
+	GetLLMCalls(req *model.GetLLMCallsRequest) (*model.GetLLMCallsResponse, error)
+	PruneLLMCalls() (int64, error)
+	WatchRetention(interval time.Duration) (stop func())
+}
+
+type llmCallsService struct {
+	repo      repository.LLMCallsRepository
+	retention time.Duration
+}
+
+// NewLLMCallsService 記録の保存期間を指定して作成する
+func NewLLMCallsService(repo repository.LLMCallsRepository, retention time.Duration) LLMCallsService {
+	return &llmCallsService{repo: repo, retention: retention}
+}
+
+// GetLLMCalls 条件に一致するLLM呼び出しの記録を新しい順に取得する
+func (s *llmCallsService) GetLLMCalls(req *model.GetLLMCallsRequest) (*model.GetLLMCallsResponse, error) {
+	if req.Outcome != "" && !slices.Contains(llmCallOutcomes, req.Outcome) {
+		return nil, fmt.Errorf("%w: outcomeには %v のいずれかを指定してください", ErrInvalidLLMCallQuery, llmCallOutcomes)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLLMCallListLimit
+	}
+	limit = min(limit, maxLLMCallListLimit)
+
+	calls, err := s.repo.GetLLMCalls(req, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GetLLMCallsResponse{
+		Calls:         calls,
+		RetentionDays: int(s.retention / (24 * time.Hour)),
+	}, nil
+}
+
+// PruneLLMCalls 保存期間を過ぎたLLM呼び出しの記録を削除する
+func (s *llmCallsService) PruneLLMCalls() (int64, error) {
+	return s.repo.DeleteLLMCallsBefore(time.Now().Add(-s.retention))
+}
+
+// WatchRetention 起動時と指定した間隔で保存期間を過ぎた記録を削除する。返り値の関数で停止する
+func (s *llmCallsService) WatchRetention(interval time.Duration) (stop func()) {
+	prune := func() {
+		deleted, err := s.PruneLLMCalls()
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			return
+		}
+		if deleted > 0 {
+			log.Printf("保存期間を過ぎたLLM呼び出しの記録を %d 件削除しました", deleted)
+		}
+	}
+
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		prune()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				prune()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/repository"
+)
+
+// ErrInvalidUsageQuery LLM利用量の集計条件が不正
+var ErrInvalidUsageQuery = errors.New("集計条件が不正です")
+
+// 集計単位ごとの集計対象カラム
+var llmUsageGroupColumns = map[string]string{
+	model.LLMUsageGroupByUser:    "user_id",
+	model.LLMUsageGroupByFeature: "feature",
+	model.LLMUsageGroupByModel:   "llm_model",
+}
+
+type LLMUsageService interface {
+	GetLLMUsageSummary(req *model.GetLLMUsageSummaryRequest) (*model.GetLLMUsageSummaryResponse, error)
+}
+
+type llmUsageService struct {
+	repo repository.LLMUsageRepository
+}
+
+func NewLLMUsageService(repo repository.LLMUsageRepository) LLMUsageService {
+	return &llmUsageService{repo: repo}
+}
+
+// GetLLMUsageSummary 期間内のLLM利用量をユーザー・機能・モデルのいずれかの単位で集計する
+func (s *llmUsageService) GetLLMUsageSummary(req *model.GetLLMUsageSummaryRequest) (*model.GetLLMUsageSummaryResponse, error) {
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = model.LLMUsageGroupByUser
+	}
+	groupColumn, ok := llmUsageGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: group_byには user / feature / model のいずれかを指定してください", ErrInvalidUsageQuery)
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if req.From != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.From, now.Location())
+		if err != nil {
+			return nil, fmt.Errorf("%w: fromはYYYY-MM-DD形式で指定してください", ErrInvalidUsageQuery)
+		}
+		from = parsed
+	}
+	if req.To != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.To, now.Location())
+		if err != nil {
+			return nil, fmt.Errorf("%w: toはYYYY-MM-DD形式で指定してください", ErrInvalidUsageQuery)
+		}
+		to = parsed
+	}
+	// 終了日を含めるため翌日0時までを集計対象とする
+	to = to.AddDate(0, 0, 1)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: fromにはto以前の日付を指定してください", ErrInvalidUsageQuery)
+	}
+
+	rows, err := s.repo.GetLLMUsageSummary(from, to, groupColumn, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	total := model.LLMUsageSummaryRow{Key: "total"}
+	for _, row := range rows {
+		total.Calls += row.Calls
+		total.InputTokens += row.InputTokens
+		total.OutputTokens += row.OutputTokens
+		total.CostUSD += row.CostUSD
+	}
+	if rows == nil {
+		rows = []model.LLMUsageSummaryRow{}
+	}
+
+	return &model.GetLLMUsageSummaryResponse{
+		From:    from,
+		To:      to,
+		GroupBy: groupBy,
+		Rows:    rows,
+		Total:   total,
+	}, nil
+}
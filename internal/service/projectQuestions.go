@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -35,6 +36,9 @@ func (s *projectQuestionsService) CreateProjectQuestions(userID string, req *mod
 	// プロジェクト質問を作成
 	response, err := s.repo.CreateProjectQuestions(req)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %w", ErrQuestionTemplateNotFound, err)
+		}
 		return nil, err
 	}
 
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
+	"github.com/Takanpon2512/english-app/internal/repository"
+)
+
+var (
+	// ErrCategoryNotFound 指定したカテゴリが存在しない
+	ErrCategoryNotFound = errors.New("カテゴリが見つかりません")
+	// ErrQuestionTemplateNotDraft 承認待ち（下書き）ではない質問テンプレートは承認・却下できない
+	ErrQuestionTemplateNotDraft = errors.New("承認待ちの質問テンプレートではありません")
+)
+
+const (
+	// defaultGenerateQuestionCount 作成する問題数が指定されていない場合の問題数
+	defaultGenerateQuestionCount = 3
+	// maxExistingQuestionsInPrompt 重複を避けるためにプロンプトに含める既存の問題文の最大数
+	maxExistingQuestionsInPrompt = 20
+	// maxDraftQuestionTemplates 承認待ちの一覧で返す質問テンプレートの最大数
+	maxDraftQuestionTemplates = 100
+)
+
+// generatedQuestion LLMが作成した問題
+type generatedQuestion struct {
+	English         string `json:"english"`
+	Japanese        string `json:"japanese"`
+	ReferenceAnswer string `json:"reference_answer"`
+	Register        string `json:"register"`
+	Variety         string `json:"variety"`
+	Points          int    `json:"points"`
+	EstimatedTime   int    `json:"estimated_time"`
+}
+
+// questionGenerationOutput 問題作成のLLMの出力
+type questionGenerationOutput struct {
+	Questions []generatedQuestion `json:"questions"`
+}
+
+// questionGenerationOutputSchema 問題作成の出力スキーマ（問題数は指定した数と一致させる）
+func questionGenerationOutputSchema(count int) *llm.OutputSchema {
+	questions := llm.ArraySchema("作成した問題", llm.ObjectSchema(map[string]*llm.Schema{
+		"english":          llm.StringSchema("英語の問題文"),
+		"japanese":         llm.StringSchema("日本語の問題文"),
+		"reference_answer": llm.StringSchema("模範解答"),
+		"register":         llm.EnumSchema("模範解答の文体", model.ReferenceRegisterFormal, model.ReferenceRegisterCasual),
+		"variety":          llm.EnumSchema("模範解答の英語の種類", model.ReferenceVarietyUS, model.ReferenceVarietyUK),
+		"points":           llm.IntegerSchema("配点", 1, 30),
+		"estimated_time":   llm.IntegerSchema("解答にかかる目安の時間（分）", 1, 60),
+	}))
+	questions.MinItems = &count
+	questions.MaxItems = &count
+
+	return &llm.OutputSchema{
+		Name:        "submit_questions",
+		Description: "作成した英作文の問題を登録する",
+		Schema: llm.ObjectSchema(map[string]*llm.Schema{
+			"questions": questions,
+		}),
+	}
+}
+
+type QuestionGenerationService interface {
+	GenerateQuestionTemplates(ctx context.Context, userID string, req *model.GenerateQuestionTemplatesRequest) (*model.GenerateQuestionTemplatesResponse, error)
+	GetDraftQuestionTemplates() (*model.GetDraftQuestionTemplatesResponse, error)
+	ApproveQuestionTemplate(userID string, req *model.ReviewQuestionTemplateRequest) (*model.QuestionTemplateMastersSummary, error)
+	RejectQuestionTemplate(userID string, req *model.ReviewQuestionTemplateRequest) (*model.QuestionTemplateMastersSummary, error)
+}
+
+type questionGenerationService struct {
+	questionTemplateMastersRepo repository.QuestionTemplateMastersRepository
+	referenceAnswersRepo        repository.QuestionReferenceAnswersRepository
+	categoryMastersRepo         repository.CategoryMastersRepository
+	llmClient                   llm.LLMClient
+	router                      *llm.ModelRouter
+	quota                       llm.QuotaChecker
+	prompts                     *prompt.Registry
+}
+
+func NewQuestionGenerationService(
+	questionTemplateMastersRepo repository.QuestionTemplateMastersRepository,
+	referenceAnswersRepo repository.QuestionReferenceAnswersRepository,
+	categoryMastersRepo repository.CategoryMastersRepository,
+	llmClient llm.LLMClient,
+	router *llm.ModelRouter,
+	quota llm.QuotaChecker,
+	prompts *prompt.Registry,
+) QuestionGenerationService {
+	return &questionGenerationService{
+		questionTemplateMastersRepo: questionTemplateMastersRepo,
+		referenceAnswersRepo:        referenceAnswersRepo,
+		categoryMastersRepo:         categoryMastersRepo,
+		llmClient:                   llmClient,
+		router:                      router,
+		quota:                       quota,
+		prompts:                     prompts,
+	}
+}
+
+// GenerateQuestionTemplates カテゴリ・レベル・問題の種類を指定してLLMで質問テンプレートを作成する
+// 作成した問題は下書き（DRAFT）として解答例と合わせて保存し、管理者が承認するまで出題しない
+func (s *questionGenerationService) GenerateQuestionTemplates(ctx context.Context, userID string, req *model.GenerateQuestionTemplatesRequest) (*model.GenerateQuestionTemplatesResponse, error) {
+	count := req.Count
+	if count <= 0 {
+		count = defaultGenerateQuestionCount
+	}
+
+	category, err := s.categoryMastersRepo.GetCategoryMastersByID(req.CategoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w（ID: %s）", ErrCategoryNotFound, req.CategoryID)
+		}
+		return nil, err
+	}
+
+	if err := s.quota.CheckQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// 同じカテゴリ・種類の既存の問題（承認待ちを含む）と重複しないよう、新しいものからプロンプトに含める
+	existingTexts, err := s.questionTemplateMastersRepo.GetQuestionTemplateTexts(req.CategoryID, req.QuestionType)
+	if err != nil {
+		return nil, err
+	}
+	generationPrompt, err := s.prompts.Render(prompt.NameQuestionGeneration, prompt.QuestionGenerationData{
+		CategoryName:      category.CategoryMasters.Name,
+		Level:             req.Level,
+		QuestionType:      req.QuestionType,
+		Count:             count,
+		ExistingQuestions: existingTexts[:min(len(existingTexts), maxExistingQuestionsInPrompt)],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	llmReq := s.router.NewRequest(llm.FeatureQuestionGeneration, llm.RouteInput{
+		Level:        req.Level,
+		QuestionType: req.QuestionType,
+	}, generationPrompt.Text)
+	llmReq.UserID = userID
+	llmReq.SubjectID = req.CategoryID
+	llmReq.PromptVersion = generationPrompt.Version
+	llmReq.Output = questionGenerationOutputSchema(count)
+	// 同じ条件で繰り返し作成した場合も、キャッシュした応答ではなく新しい問題を作成する
+	llmReq.NoCache = true
+
+	var output questionGenerationOutput
+	// 既存の問題・作成した問題どうしの重複や、問題の種類の形式に沿っていない問題は作り直しを依頼する
+	checkOutput := func() []string {
+		return checkGeneratedQuestions(output.Questions, req.QuestionType, existingTexts)
+	}
+	llmRes, err := llm.GenerateStructured(ctx, s.llmClient, llmReq, &output, checkOutput)
+	if err != nil {
+		return nil, fmt.Errorf("LLMによる問題の作成に失敗しました: %w", err)
+	}
+
+	now := time.Now()
+	questionTemplates := make([]model.QuestionTemplateMasters, 0, len(output.Questions))
+	referenceAnswers := make([]model.QuestionReferenceAnswers, 0, len(output.Questions))
+	for _, question := range output.Questions {
+		questionTemplate := model.QuestionTemplateMasters{
+			ID:            uuid.New().String(),
+			CategoryID:    req.CategoryID,
+			QuestionType:  req.QuestionType,
+			English:       strings.TrimSpace(question.English),
+			Japanese:      strings.TrimSpace(question.Japanese),
+			Status:        model.QuestionTemplateStatusDraft,
+			Level:         req.Level,
+			EstimatedTime: question.EstimatedTime,
+			Points:        question.Points,
+			CreatedBy:     userID,
+			UpdatedBy:     userID,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		questionTemplates = append(questionTemplates, questionTemplate)
+		referenceAnswers = append(referenceAnswers, model.QuestionReferenceAnswers{
+			ID:                       uuid.New().String(),
+			QuestionTemplateMasterID: questionTemplate.ID,
+			Answer:                   strings.TrimSpace(question.ReferenceAnswer),
+			Register:                 question.Register,
+			Variety:                  question.Variety,
+			CreatedBy:                userID,
+			UpdatedBy:                userID,
+			CreatedAt:                now,
+			UpdatedAt:                now,
+		})
+	}
+	if err := s.questionTemplateMastersRepo.CreateQuestionTemplateDrafts(questionTemplates, referenceAnswers); err != nil {
+		return nil, err
+	}
+	log.Printf("カテゴリ %s の質問テンプレートを%d件作成しました（承認待ち, 作成者: %s）", req.CategoryID, len(questionTemplates), userID)
+
+	categoryInfo := model.CategoryInfo{ID: category.CategoryMasters.ID, Name: category.CategoryMasters.Name}
+	summaries := make([]model.QuestionTemplateDraftSummary, 0, len(questionTemplates))
+	for i := range questionTemplates {
+		summaries = append(summaries, questionTemplateDraftSummary(&questionTemplates[i], categoryInfo, referenceAnswers[i:i+1]))
+	}
+
+	return &model.GenerateQuestionTemplatesResponse{
+		QuestionTemplates: summaries,
+		PromptVersion:     generationPrompt.Version,
+		LLMModel:          llmRes.Model,
+	}, nil
+}
+
+// GetDraftQuestionTemplates 承認待ちの質問テンプレートを解答例と合わせて取得する（新しい順）
+func (s *questionGenerationService) GetDraftQuestionTemplates() (*model.GetDraftQuestionTemplatesResponse, error) {
+	questionTemplates, err := s.questionTemplateMastersRepo.GetQuestionTemplateMastersByStatus(model.QuestionTemplateStatusDraft, maxDraftQuestionTemplates)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make(map[string]model.CategoryInfo)
+	summaries := make([]model.QuestionTemplateDraftSummary, 0, len(questionTemplates))
+	for i := range questionTemplates {
+		questionTemplate := &questionTemplates[i]
+
+		categoryInfo, ok := categories[questionTemplate.CategoryID]
+		if !ok {
+			category, err := s.categoryMastersRepo.GetCategoryMastersByID(questionTemplate.CategoryID)
+			if err != nil {
+				return nil, err
+			}
+			categoryInfo = model.CategoryInfo{ID: category.CategoryMasters.ID, Name: category.CategoryMasters.Name}
+			categories[questionTemplate.CategoryID] = categoryInfo
+		}
+
+		referenceAnswers, err := s.referenceAnswersRepo.GetReferenceAnswers(questionTemplate.ID)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, questionTemplateDraftSummary(questionTemplate, categoryInfo, referenceAnswers))
+	}
+
+	return &model.GetDraftQuestionTemplatesResponse{QuestionTemplates: summaries}, nil
+}
+
+// ApproveQuestionTemplate 承認待ちの質問テンプレートを承認し、出題できるようにする
+func (s *questionGenerationService) ApproveQuestionTemplate(userID string, req *model.ReviewQuestionTemplateRequest) (*model.QuestionTemplateMastersSummary, error) {
+	return s.reviewQuestionTemplate(userID, req.ID, model.QuestionTemplateStatusActive)
+}
+
+// RejectQuestionTemplate 承認待ちの質問テンプレートを却下する（出題せず、以降の問題作成では重複の確認にも使わない）
+func (s *questionGenerationService) RejectQuestionTemplate(userID string, req *model.ReviewQuestionTemplateRequest) (*model.QuestionTemplateMastersSummary, error) {
+	return s.reviewQuestionTemplate(userID, req.ID, model.QuestionTemplateStatusRejected)
+}
+
+// reviewQuestionTemplate 承認待ちの質問テンプレートのステータスを変更する
+func (s *questionGenerationService) reviewQuestionTemplate(userID string, id string, status string) (*model.QuestionTemplateMastersSummary, error) {
+	updated, err := s.questionTemplateMastersRepo.UpdateQuestionTemplateStatus(id, model.QuestionTemplateStatusDraft, status, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	questionTemplate, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByIDAnyStatus(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w（ID: %s）", ErrQuestionTemplateNotFound, id)
+		}
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w（ステータス: %s）", ErrQuestionTemplateNotDraft, questionTemplate.Status)
+	}
+	log.Printf("質問テンプレート %s のステータスを %s に変更しました（更新者: %s）", id, status, userID)
+
+	return questionTemplate, nil
+}
+
+// checkGeneratedQuestions 作成した問題が既存の問題・作成した問題どうしで重複していないか、問題の種類の形式に沿っているかを検証する
+func checkGeneratedQuestions(questions []generatedQuestion, questionType string, existingTexts []string) []string {
+	seen := make(map[string]bool, len(existingTexts)+len(questions))
+	for _, text := range existingTexts {
+		seen[normalizeForMatch(text)] = true
+	}
+
+	var violations []string
+	for i, question := range questions {
+		key := normalizeForMatch(question.English)
+		if seen[key] {
+			violations = append(violations, fmt.Sprintf("questions[%d]: 既存の問題または他の作成した問題と同じ問題です。別の問題を作成してください", i))
+		}
+		seen[key] = true
+
+		if questionType == "fill" && !strings.Contains(question.English, "_____") {
+			violations = append(violations, fmt.Sprintf("questions[%d]: 穴埋め問題の english には空欄を \"_____\" で示してください", i))
+		}
+	}
+	return violations
+}
+
+// questionTemplateDraftSummary 質問テンプレートと解答例を承認待ちの一覧の形式に変換する
+func questionTemplateDraftSummary(questionTemplate *model.QuestionTemplateMasters, categoryInfo model.CategoryInfo, referenceAnswers []model.QuestionReferenceAnswers) model.QuestionTemplateDraftSummary {
+	summary := model.QuestionTemplateDraftSummary{
+		QuestionTemplateMastersSummary: model.QuestionTemplateMastersSummary{
+			ID:            questionTemplate.ID,
+			CategoryID:    questionTemplate.CategoryID,
+			QuestionType:  questionTemplate.QuestionType,
+			English:       questionTemplate.English,
+			Japanese:      questionTemplate.Japanese,
+			Status:        questionTemplate.Status,
+			Level:         questionTemplate.Level,
+			EstimatedTime: questionTemplate.EstimatedTime,
+			Points:        questionTemplate.Points,
+			Category:      categoryInfo,
+		},
+		ReferenceAnswers: make([]model.QuestionReferenceAnswersSummary, 0, len(referenceAnswers)),
+		CreatedBy:        questionTemplate.CreatedBy,
+		CreatedAt:        questionTemplate.CreatedAt,
+	}
+	for i := range referenceAnswers {
+		summary.ReferenceAnswers = append(summary.ReferenceAnswers, *referenceAnswerSummary(&referenceAnswers[i]))
+	}
+	return summary
+}
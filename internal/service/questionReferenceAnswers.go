@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
+	"github.com/Takanpon2512/english-app/internal/repository"
+)
+
+var (
+	// ErrQuestionTemplateNotFound 指定した質問テンプレートが存在しない
+	ErrQuestionTemplateNotFound = errors.New("質問テンプレートが見つかりません")
+	// ErrReferenceAnswerNotFound 指定した解答例が存在しない
+	ErrReferenceAnswerNotFound = errors.New("解答例が見つかりません")
+)
+
+type QuestionReferenceAnswersService interface {
+	CreateReferenceAnswer(userID string, req *model.CreateQuestionReferenceAnswerRequest) (*model.QuestionReferenceAnswersSummary, error)
+	GetReferenceAnswers(questionTemplateMasterID string) (*model.GetQuestionReferenceAnswersResponse, error)
+	UpdateReferenceAnswer(userID string, req *model.UpdateQuestionReferenceAnswerRequest) (*model.QuestionReferenceAnswersSummary, error)
+	DeleteReferenceAnswer(userID string, req *model.DeleteQuestionReferenceAnswerRequest) (*model.DeleteQuestionReferenceAnswerResponse, error)
+}
+
+type questionReferenceAnswersService struct {
+	repo                        repository.QuestionReferenceAnswersRepository
+	questionTemplateMastersRepo repository.QuestionTemplateMastersRepository
+}
+
+func NewQuestionReferenceAnswersService(repo repository.QuestionReferenceAnswersRepository, questionTemplateMastersRepo repository.QuestionTemplateMastersRepository) QuestionReferenceAnswersService {
+	return &questionReferenceAnswersService{
+		repo:                        repo,
+		questionTemplateMastersRepo: questionTemplateMastersRepo,
+	}
+}
+
+// CreateReferenceAnswer 質問テンプレートに解答例を追加する
+func (s *questionReferenceAnswersService) CreateReferenceAnswer(userID string, req *model.CreateQuestionReferenceAnswerRequest) (*model.QuestionReferenceAnswersSummary, error) {
+	if _, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByIDAnyStatus(req.QuestionTemplateMasterID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w（ID: %s）", ErrQuestionTemplateNotFound, req.QuestionTemplateMasterID)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	referenceAnswer := &model.QuestionReferenceAnswers{
+		QuestionTemplateMasterID: req.QuestionTemplateMasterID,
+		Answer:                   strings.TrimSpace(req.Answer),
+		Register:                 req.Register,
+		Variety:                  req.Variety,
+		CreatedBy:                userID,
+		UpdatedBy:                userID,
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+	if err := s.repo.CreateReferenceAnswer(referenceAnswer); err != nil {
+		return nil, err
+	}
+
+	return referenceAnswerSummary(referenceAnswer), nil
+}
+
+// GetReferenceAnswers 質問テンプレートの解答例の一覧を取得する
+func (s *questionReferenceAnswersService) GetReferenceAnswers(questionTemplateMasterID string) (*model.GetQuestionReferenceAnswersResponse, error) {
+	referenceAnswers, err := s.repo.GetReferenceAnswers(questionTemplateMasterID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]model.QuestionReferenceAnswersSummary, 0, len(referenceAnswers))
+	for i := range referenceAnswers {
+		summaries = append(summaries, *referenceAnswerSummary(&referenceAnswers[i]))
+	}
+	return &model.GetQuestionReferenceAnswersResponse{ReferenceAnswers: summaries}, nil
+}
+
+// UpdateReferenceAnswer 解答例を更新する
+func (s *questionReferenceAnswersService) UpdateReferenceAnswer(userID string, req *model.UpdateQuestionReferenceAnswerRequest) (*model.QuestionReferenceAnswersSummary, error) {
+	referenceAnswer, err := s.getReferenceAnswer(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	referenceAnswer.Answer = strings.TrimSpace(req.Answer)
+	referenceAnswer.Register = req.Register
+	referenceAnswer.Variety = req.Variety
+	referenceAnswer.UpdatedBy = userID
+	referenceAnswer.UpdatedAt = time.Now()
+	if err := s.repo.UpdateReferenceAnswer(referenceAnswer); err != nil {
+		return nil, err
+	}
+
+	return referenceAnswerSummary(referenceAnswer), nil
+}
+
+// DeleteReferenceAnswer 解答例を削除する
+func (s *questionReferenceAnswersService) DeleteReferenceAnswer(userID string, req *model.DeleteQuestionReferenceAnswerRequest) (*model.DeleteQuestionReferenceAnswerResponse, error) {
+	referenceAnswer, err := s.getReferenceAnswer(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	referenceAnswer.DeletedBy = userID
+	if err := s.repo.DeleteReferenceAnswer(referenceAnswer); err != nil {
+		return nil, err
+	}
+
+	return &model.DeleteQuestionReferenceAnswerResponse{ID: referenceAnswer.ID}, nil
+}
+
+// getReferenceAnswer IDを指定して解答例を取得する（存在しない場合はErrReferenceAnswerNotFound）
+func (s *questionReferenceAnswersService) getReferenceAnswer(id string) (*model.QuestionReferenceAnswers, error) {
+	referenceAnswer, err := s.repo.GetReferenceAnswerByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w（ID: %s）", ErrReferenceAnswerNotFound, id)
+		}
+		return nil, err
+	}
+	return referenceAnswer, nil
+}
+
+func referenceAnswerSummary(referenceAnswer *model.QuestionReferenceAnswers) *model.QuestionReferenceAnswersSummary {
+	return &model.QuestionReferenceAnswersSummary{
+		ID:                       referenceAnswer.ID,
+		QuestionTemplateMasterID: referenceAnswer.QuestionTemplateMasterID,
+		Answer:                   referenceAnswer.Answer,
+		Register:                 referenceAnswer.Register,
+		Variety:                  referenceAnswer.Variety,
+		CreatedAt:                referenceAnswer.CreatedAt,
+		UpdatedAt:                referenceAnswer.UpdatedAt,
+	}
+}
+
+// referencePromptAnswers 採点プロンプトに埋め込む解答例
+func referencePromptAnswers(referenceAnswers []model.QuestionReferenceAnswers) []prompt.GradingReferenceAnswer {
+	answers := make([]prompt.GradingReferenceAnswer, 0, len(referenceAnswers))
+	for _, r := range referenceAnswers {
+		answers = append(answers, prompt.GradingReferenceAnswer{Answer: r.Answer, Register: r.Register, Variety: r.Variety})
+	}
+	return answers
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+
 	"gorm.io/gorm"
 
 	"github.com/Takanpon2512/english-app/internal/model"
@@ -26,5 +29,12 @@ func (s *questionTemplateMastersService) GetQuestionTemplateMasters(userID strin
 }
 
 func (s *questionTemplateMastersService) GetQuestionTemplateMasterByID(userID string, id string) (*model.QuestionTemplateMastersSummary, error) {
-	return s.repo.GetQuestionTemplateMasterByID(id)
+	questionTemplateMaster, err := s.repo.GetQuestionTemplateMasterByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w（ID: %s）", ErrQuestionTemplateNotFound, id)
+		}
+		return nil, err
+	}
+	return questionTemplateMaster, nil
 }
\ No newline at end of file
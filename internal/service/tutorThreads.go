@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
+	"github.com/Takanpon2512/english-app/internal/repository"
+)
+
+var (
+	// ErrInvalidTutorQuestion 質問が空・長すぎる
+	ErrInvalidTutorQuestion = errors.New("質問の内容が不正です")
+	// ErrTutorNotReady 採点が完了していない添削結果には質問できない
+	ErrTutorNotReady = errors.New("採点が完了していないため質問できません")
+	// ErrTutorLimitExceeded チューターへの質問数が上限に達している
+	ErrTutorLimitExceeded = errors.New("チューターへの質問数の上限に達しました")
+)
+
+// TutorLimits チューターへの質問数・質問の長さの上限（0以下の場合は無制限）
+type TutorLimits struct {
+	DailyQuestions   int // ユーザーごとの1日あたりの質問数
+	ThreadQuestions  int // 1つのスレッド（添削結果）あたりの質問数
+	MaxQuestionRunes int // 1つの質問の最大文字数
+}
+
+// remainingQuestions 上限に対する残り回数（無制限の場合は-1）
+func remainingQuestions(limit int, used int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return max(limit-used, 0)
+}
+
+type TutorThreadsService interface {
+	PostTutorMessage(ctx context.Context, userID string, req *model.PostTutorMessageRequest) (*model.PostTutorMessageResponse, error)
+	GetTutorThread(userID string, correctionResultID string) (*model.GetTutorThreadResponse, error)
+}
+
+type tutorThreadsService struct {
+	repo                        repository.TutorThreadsRepository
+	correctResultsRepo          repository.CorrectResultsRepository
+	questionAnswersRepo         repository.QuestionAnswersRepository
+	questionTemplateMastersRepo repository.QuestionTemplateMastersRepository
+	llmClient                   llm.LLMClient
+	router                      *llm.ModelRouter
+	quota                       llm.QuotaChecker
+	prompts                     *prompt.Registry
+	limits                      TutorLimits
+}
+
+func NewTutorThreadsService(
+	repo repository.TutorThreadsRepository,
+	correctResultsRepo repository.CorrectResultsRepository,
+	questionAnswersRepo repository.QuestionAnswersRepository,
+	questionTemplateMastersRepo repository.QuestionTemplateMastersRepository,
+	llmClient llm.LLMClient,
+	router *llm.ModelRouter,
+	quota llm.QuotaChecker,
+	prompts *prompt.Registry,
+	limits TutorLimits,
+) TutorThreadsService {
+	return &tutorThreadsService{
+		repo:                        repo,
+		correctResultsRepo:          correctResultsRepo,
+		questionAnswersRepo:         questionAnswersRepo,
+		questionTemplateMastersRepo: questionTemplateMastersRepo,
+		llmClient:                   llmClient,
+		router:                      router,
+		quota:                       quota,
+		prompts:                     prompts,
+		limits:                      limits,
+	}
+}
+
+// PostTutorMessage 添削結果についてチューターに質問し、回答を返す
+// 問題・解答・添削結果をシステムプロンプトに含め、スレッドのそれまでの対話を会話履歴として送る
+// 質問と回答は回答を生成できた場合のみ保存する（失敗した質問は質問数に数えない）
+func (s *tutorThreadsService) PostTutorMessage(ctx context.Context, userID string, req *model.PostTutorMessageRequest) (*model.PostTutorMessageResponse, error) {
+	question := strings.TrimSpace(req.Content)
+	if question == "" {
+		return nil, fmt.Errorf("%w（質問が空です）", ErrInvalidTutorQuestion)
+	}
+	if s.limits.MaxQuestionRunes > 0 && utf8.RuneCountInString(question) > s.limits.MaxQuestionRunes {
+		return nil, fmt.Errorf("%w（質問は%d文字以内で入力してください）", ErrInvalidTutorQuestion, s.limits.MaxQuestionRunes)
+	}
+
+	correctionResult, questionAnswer, err := findOwnedCorrectionResult(s.correctResultsRepo, s.questionAnswersRepo, userID, req.CorrectionResultID)
+	if err != nil {
+		return nil, err
+	}
+	if correctionResult.Status != "COMPLETED" {
+		return nil, fmt.Errorf("%w（ステータス: %s）", ErrTutorNotReady, correctionResult.Status)
+	}
+
+	history, err := s.threadMessages(correctionResult.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// スレッド・1日あたりの質問数の上限と、LLMの利用上限を確認する
+	// 同時に質問された場合に上限を超えないよう、保存するときにも質問数を確認する
+	dailyQuestions, err := s.dailyQuestions(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLimits(countTutorQuestions(history), dailyQuestions); err != nil {
+		return nil, err
+	}
+	if err := s.quota.CheckQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	tutorPrompt, questionTemplateMaster, err := s.renderTutorPrompt(correctionResult, questionAnswer)
+	if err != nil {
+		return nil, err
+	}
+
+	// 学習者の質問は区切りタグを無効にしてタグで囲み、それまでの対話に続けて送る
+	askedAt := time.Now()
+	llmReq := s.router.NewRequest(llm.FeatureTutor, llm.RouteInput{
+		Level:        questionTemplateMaster.Level,
+		QuestionType: questionTemplateMaster.QuestionType,
+	}, tutorQuestionMessage(question))
+	llmReq.UserID = userID
+	llmReq.SubjectID = correctionResult.ID
+	llmReq.PromptVersion = tutorPrompt.Version
+	llmReq.System = tutorPrompt.Text
+	llmReq.Messages = append(tutorHistoryMessages(history), llmReq.Messages...)
+
+	llmRes, err := s.llmClient.Generate(ctx, llmReq)
+	if err != nil {
+		return nil, fmt.Errorf("LLMによる回答の生成に失敗しました: %w", err)
+	}
+	answer := strings.TrimSpace(llmRes.Text)
+	if answer == "" {
+		return nil, fmt.Errorf("LLMによる回答の生成に失敗しました: 回答が空です")
+	}
+
+	var threadQuestions int
+	checkLimits := func(savedThreadQuestions int, savedDailyQuestions int) error {
+		threadQuestions, dailyQuestions = savedThreadQuestions, savedDailyQuestions
+		return s.checkLimits(threadQuestions, dailyQuestions)
+	}
+	thread, saved, err := s.repo.AppendTutorMessages(correctionResult.ID, userID, []model.TutorMessages{
+		{Role: model.TutorRoleUser, Content: question, CreatedAt: askedAt},
+		{
+			Role:          model.TutorRoleAssistant,
+			Content:       answer,
+			LLMModel:      llmRes.Model,
+			PromptVersion: tutorPrompt.Version,
+			InputTokens:   llmRes.InputTokens,
+			OutputTokens:  llmRes.OutputTokens,
+		},
+	}, dayStart(askedAt), checkLimits)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("添削結果 %s についてチューターが回答しました（スレッド: %s, 質問: %d件目）", correctionResult.ID, thread.ID, threadQuestions+1)
+
+	return &model.PostTutorMessageResponse{
+		ThreadID: thread.ID,
+		Question: tutorMessageSummary(saved[0]),
+		Answer:   tutorMessageSummary(saved[1]),
+		Limits:   s.limitSummary(threadQuestions+1, dailyQuestions+1),
+	}, nil
+}
+
+// GetTutorThread 添削結果に対するチューターとの対話の履歴を取得（古い順）
+func (s *tutorThreadsService) GetTutorThread(userID string, correctionResultID string) (*model.GetTutorThreadResponse, error) {
+	correctionResult, _, err := findOwnedCorrectionResult(s.correctResultsRepo, s.questionAnswersRepo, userID, correctionResultID)
+	if err != nil {
+		return nil, err
+	}
+
+	thread, err := s.repo.GetTutorThread(correctionResult.ID)
+	if err != nil {
+		return nil, err
+	}
+	response := &model.GetTutorThreadResponse{
+		CorrectionResultID: correctionResult.ID,
+		Messages:           []model.TutorMessageSummary{},
+	}
+
+	var history []model.TutorMessages
+	if thread != nil {
+		response.ThreadID = thread.ID
+		if history, err = s.repo.GetTutorMessages(thread.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, message := range history {
+		response.Messages = append(response.Messages, tutorMessageSummary(message))
+	}
+
+	dailyQuestions, err := s.dailyQuestions(userID)
+	if err != nil {
+		return nil, err
+	}
+	response.Limits = s.limitSummary(countTutorQuestions(history), dailyQuestions)
+
+	return response, nil
+}
+
+// threadMessages 添削結果のスレッドのメッセージ（まだ質問していない場合は空）
+func (s *tutorThreadsService) threadMessages(correctionResultID string) ([]model.TutorMessages, error) {
+	thread, err := s.repo.GetTutorThread(correctionResultID)
+	if err != nil || thread == nil {
+		return nil, err
+	}
+	return s.repo.GetTutorMessages(thread.ID)
+}
+
+// dailyQuestions ユーザーの今日のチューターへの質問数
+func (s *tutorThreadsService) dailyQuestions(userID string) (int, error) {
+	return s.repo.CountTutorQuestionsSince(userID, dayStart(time.Now()))
+}
+
+// checkLimits スレッド・1日あたりの質問数が上限に達している場合はErrTutorLimitExceeded
+func (s *tutorThreadsService) checkLimits(threadQuestions int, dailyQuestions int) error {
+	if s.limits.ThreadQuestions > 0 && threadQuestions >= s.limits.ThreadQuestions {
+		return fmt.Errorf("%w（1つの添削結果につき%d件まで）", ErrTutorLimitExceeded, s.limits.ThreadQuestions)
+	}
+	if s.limits.DailyQuestions > 0 && dailyQuestions >= s.limits.DailyQuestions {
+		return fmt.Errorf("%w（1日あたり%d件まで）", ErrTutorLimitExceeded, s.limits.DailyQuestions)
+	}
+	return nil
+}
+
+// dayStart 指定日時の日の始まり
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// limitSummary 質問数の上限と残り回数
+func (s *tutorThreadsService) limitSummary(threadQuestions int, dailyQuestions int) model.TutorLimitSummary {
+	return model.TutorLimitSummary{
+		DailyLimit:      max(s.limits.DailyQuestions, 0),
+		DailyRemaining:  remainingQuestions(s.limits.DailyQuestions, dailyQuestions),
+		ThreadLimit:     max(s.limits.ThreadQuestions, 0),
+		ThreadRemaining: remainingQuestions(s.limits.ThreadQuestions, threadQuestions),
+	}
+}
+
+// renderTutorPrompt 問題・解答・添削結果（評価観点ごとの得点と誤りの指摘を含む）を埋め込んだシステムプロンプトを作成する
+func (s *tutorThreadsService) renderTutorPrompt(correctionResult *model.CorrectionResults, questionAnswer *model.QuestionAnswers) (*prompt.Rendered, *model.GetQuestionTemplateMastersLLMResponse, error) {
+	questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterLLMById(correctionResult.QuestionTemplateMasterID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("質問テンプレートマスターの取得に失敗しました: %w", err)
+	}
+	rubricScores, err := s.correctResultsRepo.GetRubricScores([]string{correctionResult.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+	correctionErrors, err := s.correctResultsRepo.GetCorrectionErrors([]string{correctionResult.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := prompt.TutorData{
+		English:           questionTemplateMaster.English,
+		Japanese:          questionTemplateMaster.Japanese,
+		UserAnswer:        neutralizeDelimiters(questionAnswer.UserAnswer),
+		MaxPoints:         questionTemplateMaster.Points,
+		GetPoints:         correctionResult.GetPoints,
+		ExampleCorrection: correctionResult.ExampleCorrection,
+		Advice:            correctionResult.Advice,
+	}
+	for _, score := range rubricScoreSummaries(rubricScores[correctionResult.ID]) {
+		data.Rubric = append(data.Rubric, prompt.TutorRubricScore{
+			Name:      score.Criterion,
+			Score:     score.Score,
+			Rationale: score.Rationale,
+		})
+	}
+	for _, correctionError := range correctionErrorSummaries(correctionErrors[correctionResult.ID]) {
+		data.Errors = append(data.Errors, prompt.TutorCorrectionError{
+			Original:    correctionError.OriginalText,
+			Suggestion:  correctionError.Suggestion,
+			ErrorType:   correctionError.ErrorType,
+			Explanation: correctionError.Explanation,
+		})
+	}
+
+	rendered, err := s.prompts.Render(prompt.NameTutor, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rendered, questionTemplateMaster, nil
+}
+
+// tutorQuestionMessage 学習者の質問をプロンプトの区切りタグで囲んだメッセージ
+func tutorQuestionMessage(question string) string {
+	return "<learner_message>\n" + neutralizeDelimiters(question) + "\n</learner_message>"
+}
+
+// tutorHistoryMessages スレッドのそれまでの対話をLLMに送る会話履歴にする
+func tutorHistoryMessages(history []model.TutorMessages) []llm.Message {
+	messages := make([]llm.Message, 0, len(history))
+	for _, message := range history {
+		if message.Role == model.TutorRoleAssistant {
+			messages = append(messages, llm.Message{Role: llm.RoleAssistant, Content: message.Content})
+			continue
+		}
+		messages = append(messages, llm.Message{Role: llm.RoleUser, Content: tutorQuestionMessage(message.Content)})
+	}
+	return messages
+}
+
+// countTutorQuestions スレッドの学習者の質問数
+func countTutorQuestions(history []model.TutorMessages) int {
+	count := 0
+	for _, message := range history {
+		if message.Role == model.TutorRoleUser {
+			count++
+		}
+	}
+	return count
+}
+
+// tutorMessageSummary レスポンスに含めるメッセージ
+func tutorMessageSummary(message model.TutorMessages) model.TutorMessageSummary {
+	return model.TutorMessageSummary{
+		ID:        message.ID,
+		Role:      message.Role,
+		Content:   message.Content,
+		CreatedAt: message.CreatedAt,
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Takanpon2512/english-app/internal/llm"
+	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
+)
+
+// stubTutorThreadsRepository 1つのスレッドのメッセージをメモリで管理するTutorThreadsRepository
+type stubTutorThreadsRepository struct {
+	messages       []model.TutorMessages
+	otherQuestions int // 今日ほかのスレッドでした質問数
+	racing         int // 回答の生成中に同じスレッドで追加された質問数（同時の質問）
+}
+
+func (r *stubTutorThreadsRepository) GetTutorThread(correctionResultID string) (*model.TutorThreads, error) {
+	if len(r.messages) == 0 {
+		return nil, nil
+	}
+	return &model.TutorThreads{ID: "thread-1", CorrectionResultID: correctionResultID, UserID: "user-1"}, nil
+}
+
+func (r *stubTutorThreadsRepository) GetTutorMessages(threadID string) ([]model.TutorMessages, error) {
+	return r.messages, nil
+}
+
+func (r *stubTutorThreadsRepository) CountTutorQuestionsSince(userID string, since time.Time) (int, error) {
+	return r.otherQuestions + countTutorQuestions(r.messages), nil
+}
+
+func (r *stubTutorThreadsRepository) AppendTutorMessages(correctionResultID string, userID string, messages []model.TutorMessages, dailySince time.Time, check func(threadQuestions int, dailyQuestions int) error) (*model.TutorThreads, []model.TutorMessages, error) {
+	threadQuestions := countTutorQuestions(r.messages) + r.racing
+	if err := check(threadQuestions, r.otherQuestions+threadQuestions); err != nil {
+		return nil, nil, err
+	}
+	for i := range messages {
+		messages[i].ID = fmt.Sprintf("message-%d", len(r.messages)+1)
+		r.messages = append(r.messages, messages[i])
+	}
+	return &model.TutorThreads{ID: "thread-1", CorrectionResultID: correctionResultID, UserID: userID}, messages, nil
+}
+
+// stubTutorCorrectResultsRepository 採点が完了した添削結果を返すCorrectResultsRepository
+type stubTutorCorrectResultsRepository struct {
+	stubCorrectResultsRepository
+
+	status string
+}
+
+func (r *stubTutorCorrectResultsRepository) GetCorrectionResultById(id string) (*model.CorrectionResults, error) {
+	result, _ := r.stubCorrectResultsRepository.GetCorrectionResultById(id)
+	result.Status = r.status
+	result.GetPoints = 8
+	result.ExampleCorrection = "I went to see a movie with my friends yesterday."
+	return result, nil
+}
+
+func (r *stubTutorCorrectResultsRepository) GetRubricScores(correctionResultIDs []string) (map[string][]model.CorrectionRubricScores, error) {
+	return map[string][]model.CorrectionRubricScores{}, nil
+}
+
+func (r *stubTutorCorrectResultsRepository) GetCorrectionErrors(correctionResultIDs []string) (map[string][]model.CorrectionErrors, error) {
+	return map[string][]model.CorrectionErrors{}, nil
+}
+
+// exceededQuota 常にLLMの利用上限を超えているQuotaChecker
+type exceededQuota struct{}
+
+func (exceededQuota) CheckQuota(ctx context.Context, userID string) error {
+	return &llm.QuotaExceededError{Period: llm.QuotaPeriodDaily, Limit: 100, Used: 100}
+}
+
+// tutorHistory 指定した数の質問と回答の対話
+func tutorHistory(questions int) []model.TutorMessages {
+	var messages []model.TutorMessages
+	for range questions {
+		messages = append(messages,
+			model.TutorMessages{Role: model.TutorRoleUser, Content: "なぜ went なのですか？"},
+			model.TutorMessages{Role: model.TutorRoleAssistant, Content: "過去の出来事だからです。"},
+		)
+	}
+	return messages
+}
+
+func newTestTutorThreadsService(t *testing.T, repo *stubTutorThreadsRepository, status string, client llm.LLMClient, quota llm.QuotaChecker, limits TutorLimits) TutorThreadsService {
+	t.Helper()
+	prompts, err := prompt.NewRegistry("")
+	if err != nil {
+		t.Fatalf("プロンプトの読み込みに失敗しました: %v", err)
+	}
+	return NewTutorThreadsService(
+		repo,
+		&stubTutorCorrectResultsRepository{status: status},
+		&stubQuestionAnswersRepository{},
+		&stubQuestionTemplateMastersRepository{},
+		client,
+		llm.NewModelRouter(nil, nil),
+		quota,
+		prompts,
+		limits,
+	)
+}
+
+func TestPostTutorMessage(t *testing.T) {
+	fake := llm.NewFakeClient()
+	repo := &stubTutorThreadsRepository{messages: tutorHistory(1), otherQuestions: 2}
+	s := newTestTutorThreadsService(t, repo, "COMPLETED", fake, noQuota{}, TutorLimits{DailyQuestions: 5, ThreadQuestions: 3, MaxQuestionRunes: 100})
+
+	res, err := s.PostTutorMessage(context.Background(), "user-1", &model.PostTutorMessageRequest{CorrectionResultID: "result-1", Content: "  yesterday があると過去形になりますか？ "})
+	if err != nil {
+		t.Fatalf("チューターへの質問に失敗しました: %v", err)
+	}
+	if res.ThreadID != "thread-1" || res.Question.Content != "yesterday があると過去形になりますか？" || !strings.Contains(res.Answer.Content, "went") {
+		t.Errorf("回答 = %+v", res)
+	}
+	// スレッドは2件目、今日は4件目の質問
+	want := model.TutorLimitSummary{DailyLimit: 5, DailyRemaining: 1, ThreadLimit: 3, ThreadRemaining: 1}
+	if res.Limits != want {
+		t.Errorf("上限 = %+v, want %+v", res.Limits, want)
+	}
+
+	// それまでの対話を会話履歴として送り、質問は区切りタグで囲む
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("LLMの呼び出し回数 = %d, want 1", len(calls))
+	}
+	messages := calls[0].Messages
+	if len(messages) != 3 || messages[1].Role != llm.RoleAssistant ||
+		messages[2].Content != "<learner_message>\nyesterday があると過去形になりますか？\n</learner_message>" {
+		t.Errorf("会話履歴 = %+v", messages)
+	}
+	if calls[0].UserID != "user-1" || calls[0].SubjectID != "result-1" || !strings.HasPrefix(calls[0].PromptVersion, "tutor@") {
+		t.Errorf("リクエスト = %+v", calls[0])
+	}
+	if len(repo.messages) != 4 || repo.messages[3].Role != model.TutorRoleAssistant || repo.messages[3].LLMModel != llm.FakeModel {
+		t.Errorf("保存したメッセージ = %+v", repo.messages)
+	}
+}
+
+// スレッド・1日あたりの質問数が上限に達している場合はLLMを呼び出さない
+func TestPostTutorMessageLimits(t *testing.T) {
+	tests := []struct {
+		name           string
+		limits         TutorLimits
+		history        int // スレッドの質問数
+		otherQuestions int // 今日ほかのスレッドでした質問数
+		racing         int // 回答の生成中に同じスレッドで追加された質問数
+		wantErr        string
+		wantCalls      int
+	}{
+		{name: "上限未満", limits: TutorLimits{DailyQuestions: 5, ThreadQuestions: 3}, history: 2, otherQuestions: 2, wantCalls: 1},
+		{name: "無制限", history: 50, otherQuestions: 100, wantCalls: 1},
+		{name: "スレッドの上限", limits: TutorLimits{DailyQuestions: 10, ThreadQuestions: 3}, history: 3, wantErr: "1つの添削結果につき3件まで"},
+		{name: "1日の上限", limits: TutorLimits{DailyQuestions: 5, ThreadQuestions: 3}, history: 1, otherQuestions: 4, wantErr: "1日あたり5件まで"},
+		{name: "スレッドの上限を先に確認する", limits: TutorLimits{DailyQuestions: 3, ThreadQuestions: 3}, history: 3, wantErr: "1つの添削結果につき3件まで"},
+		// 同時に質問された場合は保存するときに上限を超えた質問を保存しない
+		{name: "保存時のスレッドの上限", limits: TutorLimits{DailyQuestions: 10, ThreadQuestions: 3}, history: 2, racing: 1, wantErr: "1つの添削結果につき3件まで", wantCalls: 1},
+		{name: "保存時の1日の上限", limits: TutorLimits{DailyQuestions: 5}, history: 2, otherQuestions: 2, racing: 1, wantErr: "1日あたり5件まで", wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llm.NewFakeClient()
+			repo := &stubTutorThreadsRepository{messages: tutorHistory(tt.history), otherQuestions: tt.otherQuestions, racing: tt.racing}
+			s := newTestTutorThreadsService(t, repo, "COMPLETED", fake, noQuota{}, tt.limits)
+
+			_, err := s.PostTutorMessage(context.Background(), "user-1", &model.PostTutorMessageRequest{CorrectionResultID: "result-1", Content: "なぜ went なのですか？"})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("チューターへの質問に失敗しました: %v", err)
+				}
+			} else if !errors.Is(err, ErrTutorLimitExceeded) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("エラー = %v, want %s", err, tt.wantErr)
+			}
+			if calls := fake.Calls(); len(calls) != tt.wantCalls {
+				t.Errorf("LLMの呼び出し回数 = %d, want %d", len(calls), tt.wantCalls)
+			}
+			wantSaved := tt.history * 2
+			if tt.wantErr == "" {
+				wantSaved += 2
+			}
+			if len(repo.messages) != wantSaved {
+				t.Errorf("保存したメッセージの数 = %d, want %d", len(repo.messages), wantSaved)
+			}
+		})
+	}
+}
+
+// 質問の長さは前後の空白を除いた文字数（バイト数ではない）で確認する
+func TestPostTutorMessageQuestionLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "空の質問", content: "", wantErr: true},
+		{name: "空白のみの質問", content: " \n\t ", wantErr: true},
+		{name: "上限ちょうどの質問", content: strings.Repeat("あ", 10)},
+		{name: "前後の空白は数えない", content: "  " + strings.Repeat("あ", 10) + "\n"},
+		{name: "上限を超える質問", content: strings.Repeat("あ", 11), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llm.NewFakeClient()
+			s := newTestTutorThreadsService(t, &stubTutorThreadsRepository{}, "COMPLETED", fake, noQuota{}, TutorLimits{MaxQuestionRunes: 10})
+
+			_, err := s.PostTutorMessage(context.Background(), "user-1", &model.PostTutorMessageRequest{CorrectionResultID: "result-1", Content: tt.content})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTutorQuestion) {
+					t.Errorf("エラー = %v, want ErrInvalidTutorQuestion", err)
+				}
+				if len(fake.Calls()) != 0 {
+					t.Error("不正な質問でLLMを呼び出しています")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("チューターへの質問に失敗しました: %v", err)
+			}
+		})
+	}
+}
+
+// 採点が完了していない・本人のものでない添削結果、LLMの利用上限を超えている場合は質問できない
+func TestPostTutorMessageRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		status  string
+		quota   llm.QuotaChecker
+		wantErr error
+	}{
+		{name: "採点中", userID: "user-1", status: "PROCESSING", quota: noQuota{}, wantErr: ErrTutorNotReady},
+		{name: "本人のものでない添削結果", userID: "user-2", status: "COMPLETED", quota: noQuota{}, wantErr: ErrCorrectionResultNotFound},
+		{name: "LLMの利用上限", userID: "user-1", status: "COMPLETED", quota: exceededQuota{}, wantErr: llm.ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llm.NewFakeClient()
+			repo := &stubTutorThreadsRepository{}
+			s := newTestTutorThreadsService(t, repo, tt.status, fake, tt.quota, TutorLimits{})
+
+			_, err := s.PostTutorMessage(context.Background(), tt.userID, &model.PostTutorMessageRequest{CorrectionResultID: "result-1", Content: "なぜ went なのですか？"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("エラー = %v, want %v", err, tt.wantErr)
+			}
+			if len(fake.Calls()) != 0 || len(repo.messages) != 0 {
+				t.Errorf("LLMの呼び出し回数 = %d, 保存したメッセージの数 = %d; want 0, 0", len(fake.Calls()), len(repo.messages))
+			}
+		})
+	}
+}
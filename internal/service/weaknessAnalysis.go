@@ -3,18 +3,18 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"strings"
 
 	"gorm.io/gorm"
 
-	"github.com/Takanpon2512/english-app/internal/config"
+	"github.com/Takanpon2512/english-app/internal/llm"
 	"github.com/Takanpon2512/english-app/internal/model"
+	"github.com/Takanpon2512/english-app/internal/prompt"
 	"github.com/Takanpon2512/english-app/internal/repository"
-	"github.com/Takanpon2512/english-app/internal/utils"
-	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/Takanpon2512/english-app/internal/worker"
 )
 
 // LLMからのカテゴリ分析レスポンス用構造体
@@ -26,18 +26,76 @@ type CategoryAnalysisResult struct {
 	Examples   []string `json:"examples"`
 }
 
+// validate スキーマで表現できない項目間の整合性を検証する
+func (r *CategoryAnalysisResult) validate() []string {
+	if r.IsWeakness && r.IsStrength {
+		return []string{"is_weakness と is_strength を同時にtrueにすることはできません"}
+	}
+	return nil
+}
+
+// カテゴリ分析の出力スキーマ
+var categoryAnalysisOutputSchema = &llm.OutputSchema{
+	Name:        "submit_category_analysis",
+	Description: "カテゴリ別の強み・弱みの分析結果を登録する",
+	Schema: llm.ObjectSchema(map[string]*llm.Schema{
+		"is_weakness": llm.BooleanSchema("このカテゴリが弱みかどうか"),
+		"is_strength": llm.BooleanSchema("このカテゴリが強みかどうか"),
+		"issues":      llm.StringArraySchema("問題点"),
+		"strengths":   llm.StringArraySchema("強み"),
+		"examples":    llm.StringArraySchema("具体例"),
+	}),
+}
+
+// 詳細分析の出力スキーマ（各領域のスコアは0-100）
+var detailedAnalysisOutputSchema = &llm.OutputSchema{
+	Name:        "submit_detailed_analysis",
+	Description: "文法・語彙・表現・構成の4領域の詳細分析結果を登録する",
+	Schema: llm.ObjectSchema(map[string]*llm.Schema{
+		"grammar":    analysisDetailSchema("文法面の分析"),
+		"vocabulary": analysisDetailSchema("語彙面の分析"),
+		"expression": analysisDetailSchema("表現面の分析"),
+		"structure":  analysisDetailSchema("構成面の分析"),
+	}),
+}
+
+// 学習アドバイスの出力スキーマ
+var learningAdviceOutputSchema = &llm.OutputSchema{
+	Name:        "submit_learning_advice",
+	Description: "個別化された学習アドバイスを登録する",
+	Schema: llm.ObjectSchema(map[string]*llm.Schema{
+		"learning_advice":      llm.StringSchema("個別学習アドバイス"),
+		"recommended_actions":  llm.StringArraySchema("推奨する具体的な学習行動"),
+		"next_goals":           llm.StringArraySchema("次に設定すべき学習目標"),
+		"study_plan":           llm.StringSchema("詳細な個別学習プラン"),
+		"motivational_message": llm.StringSchema("学習者を励ますメッセージ"),
+	}),
+}
+
+// analysisDetailSchema 領域ごとの分析結果のスキーマ
+func analysisDetailSchema(description string) *llm.Schema {
+	schema := llm.ObjectSchema(map[string]*llm.Schema{
+		"score":       llm.IntegerSchema("スコア（0-100の整数）", 0, 100),
+		"description": llm.StringSchema("詳細な分析説明"),
+		"examples":    llm.StringArraySchema("具体例"),
+	})
+	schema.Description = description
+	return schema
+}
+
 type WeaknessAnalysisService interface {
-	CreateWeaknessAnalysis(userId string, req *model.CreateWeaknessAnalysisRequest) (*model.CreateWeaknessAnalysisResponse, error)
+	CreateWeaknessAnalysis(ctx context.Context, userId string, req *model.CreateWeaknessAnalysisRequest) (*model.CreateWeaknessAnalysisResponse, error)
 	GetWeaknessAnalysis(userId string, req *model.GetWeaknessAnalysisRequest) (*model.GetWeaknessAnalysisResponse, error)
 
 	// LLMによる分析処理
-	WeaknessCategoryAnalysis(userId string, projectId string) (map[string]*CategoryAnalysisResult, error)
-	WeaknessDetailedAnalysis(userId string, projectId string) (*model.DetailedAnalysisResult, error)
-	WeaknessLearningAdvice(userId string, projectId string, detailedAnalysis *model.DetailedAnalysisResult) (*model.PersonalizedAdvice, error)
+	WeaknessCategoryAnalysis(ctx context.Context, userId string, projectId string) (map[string]*CategoryAnalysisResult, error)
+	WeaknessDetailedAnalysis(ctx context.Context, userId string, projectId string) (*model.DetailedAnalysisResult, error)
+	WeaknessLearningAdvice(ctx context.Context, userId string, projectId string, detailedAnalysis *model.DetailedAnalysisResult) (*model.PersonalizedAdvice, error)
 
 	GetWeaknessAnalysisAllSummary(userId string, projectId string) (*model.WeaknessAnalysisAllSummary, error)
-	UpdateWeaknessAnalysis(userId string, req *model.UpdateWeaknessAnalysisRequestService) (*model.WeaknessAnalysisAllSummary, error)
+	UpdateWeaknessAnalysis(ctx context.Context, userId string, req *model.UpdateWeaknessAnalysisRequestService) (*model.WeaknessAnalysisStatusSummary, error)
 	GetWeaknessAnalysisStatusSummary(userId string, analysisId string) (*model.WeaknessAnalysisStatusSummary, error)
+	ResumePendingAnalyses(ctx context.Context) (int, error)
 }
 
 type weaknessAnalysisService struct {
@@ -50,8 +108,11 @@ type weaknessAnalysisService struct {
 	weaknessCategoryAnalysisRepo repository.WeaknessCategoryAnalysisRepository
 	weaknessDetailedAnalysisRepo repository.WeaknessDetailedAnalysisRepository
 	weaknessLearningAdviceRepo   repository.WeaknessLearningAdviceRepository
-	claudeClient                 anthropic.Client
-	prompts                      *config.WeaknessAnalysisPrompts
+	llmClient                    llm.LLMClient
+	router                       *llm.ModelRouter
+	quota                        llm.QuotaChecker
+	analysisPool                 *worker.Pool
+	prompts                      *prompt.Registry
 }
 
 func NewWeaknessAnalysisService(
@@ -64,14 +125,12 @@ func NewWeaknessAnalysisService(
 	weaknessCategoryAnalysisRepo repository.WeaknessCategoryAnalysisRepository,
 	weaknessDetailedAnalysisRepo repository.WeaknessDetailedAnalysisRepository,
 	weaknessLearningAdviceRepo repository.WeaknessLearningAdviceRepository,
+	llmClient llm.LLMClient,
+	router *llm.ModelRouter,
+	quota llm.QuotaChecker,
+	analysisPool *worker.Pool,
+	prompts *prompt.Registry,
 ) WeaknessAnalysisService {
-	apiKey := os.Getenv("CLAUDE_API_KEY")
-	if apiKey == "" {
-		log.Fatal("CLAUDE_API_KEY environment variable is not set")
-	}
-	claudeClient := anthropic.NewClient(
-		option.WithAPIKey(apiKey),
-	)
 	return &weaknessAnalysisService{
 		db:                           db,
 		repo:                         repo,
@@ -82,247 +141,258 @@ func NewWeaknessAnalysisService(
 		weaknessCategoryAnalysisRepo: weaknessCategoryAnalysisRepo,
 		weaknessDetailedAnalysisRepo: weaknessDetailedAnalysisRepo,
 		weaknessLearningAdviceRepo:   weaknessLearningAdviceRepo,
-		claudeClient:                 claudeClient,
-		prompts:                      config.NewWeaknessAnalysisPrompts(),
+		llmClient:                    llmClient,
+		router:                       router,
+		quota:                        quota,
+		analysisPool:                 analysisPool,
+		prompts:                      prompts,
 	}
 }
 
 // CreateWeaknessAnalysis 学習弱点分析を作成する
-// この時、学習カテゴリ分析、詳細分析、学習アドバイスを作成する
-func (s *weaknessAnalysisService) CreateWeaknessAnalysis(userId string, req *model.CreateWeaknessAnalysisRequest) (*model.CreateWeaknessAnalysisResponse, error) {
-	// トランザクション開始
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		return nil, fmt.Errorf("トランザクション開始に失敗しました: %w", tx.Error)
-	}
-
-	// エラー時のロールバック処理
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r)
-		}
-	}()
+// 分析レコードを作成した後、カテゴリ分析・詳細分析・学習アドバイスの作成はバックグラウンドで行う
+func (s *weaknessAnalysisService) CreateWeaknessAnalysis(ctx context.Context, userId string, req *model.CreateWeaknessAnalysisRequest) (*model.CreateWeaknessAnalysisResponse, error) {
+	// LLMの利用上限を超えている場合は受け付けない
+	if err := s.quota.CheckQuota(ctx, userId); err != nil {
+		return nil, err
+	}
 
 	// 作成前に同じプロジェクトの分析が存在するか確認
 	existingAnalysis, err := s.repo.GetWeaknessAnalysis(userId, &model.GetWeaknessAnalysisRequest{ProjectID: req.ProjectID})
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 	if existingAnalysis != nil {
-		tx.Rollback()
 		return nil, fmt.Errorf("同じプロジェクトの分析が既に存在します")
 	}
 
 	// 弱点分析レコードを作成
 	weaknessAnalysis, err := s.repo.CreateWeaknessAnalysis(userId, req)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
-	// 学習カテゴリ分析を作成する
-	categoryAnalysisResults, err := s.WeaknessCategoryAnalysis(userId, req.ProjectID)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("カテゴリ分析の実行に失敗しました: %w", err)
+	// 分析ジョブを投入
+	if err := s.enqueueAnalysis(userId, weaknessAnalysis.ID, req.ProjectID); err != nil {
+		return nil, err
 	}
 
-	// カテゴリ分析結果をパースしてデータベースに保存
-	err = s.saveCategoryAnalysisResults(userId, weaknessAnalysis.ID, req.ProjectID, categoryAnalysisResults)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("カテゴリ分析結果の保存に失敗しました: %w", err)
-	}
+	return weaknessAnalysis, nil
+}
 
-	// 詳細分析を作成する
-	detailedAnalysisResult, err := s.WeaknessDetailedAnalysis(userId, req.ProjectID)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("詳細分析の実行に失敗しました: %w", err)
+// UpdateWeaknessAnalysis 学習弱点分析を再分析して更新する
+// 再分析はバックグラウンドで行い、受付時点の分析状況を返す
+func (s *weaknessAnalysisService) UpdateWeaknessAnalysis(ctx context.Context, userId string, req *model.UpdateWeaknessAnalysisRequestService) (*model.WeaknessAnalysisStatusSummary, error) {
+	// LLMの利用上限を超えている場合は受け付けない
+	if err := s.quota.CheckQuota(ctx, userId); err != nil {
+		return nil, err
 	}
 
-	// 詳細分析結果をデータベースに保存
-	err = s.saveDetailedAnalysisResult(userId, weaknessAnalysis.ID, detailedAnalysisResult)
+	// 既存の分析結果を取得して存在確認
+	existingAnalysis, err := s.repo.GetWeaknessAnalysis(userId, &model.GetWeaknessAnalysisRequest{ProjectID: req.ProjectID})
 	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("詳細分析結果の保存に失敗しました: %w", err)
+		return nil, fmt.Errorf("既存の分析結果の取得に失敗しました: %w", err)
 	}
-
-	// 学習アドバイスを作成する
-	learningAdviceResult, err := s.WeaknessLearningAdvice(userId, req.ProjectID, detailedAnalysisResult)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("学習アドバイスの実行に失敗しました: %w", err)
+	if existingAnalysis == nil {
+		return nil, fmt.Errorf("更新対象の分析結果が見つかりません")
 	}
-
-	// 学習アドバイス結果をデータベースに保存
-	err = s.saveLearningAdviceResult(userId, weaknessAnalysis.ID, learningAdviceResult)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("学習アドバイス結果の保存に失敗しました: %w", err)
+	if existingAnalysis.Analysis.AnalysisStatus == "PROCESSING" {
+		return nil, fmt.Errorf("分析は既に実行中です")
 	}
 
-	// 詳細分析結果から総合スコアを計算して更新
-	overallScore := s.calculateOverallScore(detailedAnalysisResult)
-	err = s.repo.UpdateOverallScore(weaknessAnalysis.ID, overallScore)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("総合スコアの更新に失敗しました: %w", err)
-	}
+	analysisId := existingAnalysis.Analysis.ID
 
-	// 全ての分析が完了したので、ステータスをCOMPLETEDに更新
-	err = s.repo.UpdateAnalysisStatus(weaknessAnalysis.ID, "COMPLETED")
-	if err != nil {
-		tx.Rollback()
+	// 分析ステータスをPROCESSINGに更新し、進捗をリセット
+	if err := s.repo.UpdateAnalysisStatus(analysisId, "PROCESSING"); err != nil {
 		return nil, fmt.Errorf("分析ステータスの更新に失敗しました: %w", err)
 	}
-
-	// トランザクションをコミット
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+	if err := s.repo.UpdateAnalysisProgress(analysisId, model.AnalysisStageQueued, 0); err != nil {
+		return nil, fmt.Errorf("分析進捗の更新に失敗しました: %w", err)
 	}
 
-	// レスポンスのステータスと総合スコアも更新
-	weaknessAnalysis.AnalysisStatus = "COMPLETED"
-	weaknessAnalysis.OverallScore = overallScore
+	// 分析ジョブを投入
+	if err := s.enqueueAnalysis(userId, analysisId, req.ProjectID); err != nil {
+		return nil, err
+	}
 
-	return weaknessAnalysis, nil
+	return s.repo.GetWeaknessAnalysisStatusSummary(userId, analysisId)
 }
 
-// UpdateWeaknessAnalysis 学習弱点分析を再分析して更新する
-func (s *weaknessAnalysisService) UpdateWeaknessAnalysis(userId string, req *model.UpdateWeaknessAnalysisRequestService) (*model.WeaknessAnalysisAllSummary, error) {
-	// トランザクション開始
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		return nil, fmt.Errorf("トランザクション開始に失敗しました: %w", tx.Error)
-	}
-
-	// エラー時のロールバック処理
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r)
-		}
-	}()
-
-	// 既存の分析結果を取得して存在確認
-	existingAnalysis, err := s.repo.GetWeaknessAnalysis(userId, &model.GetWeaknessAnalysisRequest{ProjectID: req.ProjectID})
+// ResumePendingAnalyses 起動時に処理中のまま残っている分析を再度キューに投入する
+// （単一プロセスでの運用を前提としている）
+// キューの長さを超える場合はワーカーに空きができるまで待って投入し、投入できなかった分析は次回起動時に再開するためPROCESSINGのまま残す
+func (s *weaknessAnalysisService) ResumePendingAnalyses(ctx context.Context) (int, error) {
+	pendingAnalyses, err := s.repo.GetWeaknessAnalysesByStatus("PROCESSING")
 	if err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("既存の分析結果の取得に失敗しました: %w", err)
+		return 0, fmt.Errorf("未処理の分析の取得に失敗しました: %w", err)
 	}
-	if existingAnalysis == nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("更新対象の分析結果が見つかりません")
+
+	resumed := 0
+	for _, pendingAnalysis := range pendingAnalyses {
+		userId, analysisId, projectId := pendingAnalysis.UserID, pendingAnalysis.ID, pendingAnalysis.ProjectID
+		if err := s.analysisPool.SubmitWait(ctx, func(ctx context.Context) {
+			s.runWeaknessAnalysis(ctx, userId, analysisId, projectId)
+		}); err != nil {
+			log.Printf("分析 %s の再投入を中断しました（次回起動時に再開します）: %v", pendingAnalysis.ID, err)
+			return resumed, nil
+		}
+		resumed++
 	}
 
-	// 分析ステータスをPROCESSINGに更新
-	err = s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "PROCESSING")
+	return resumed, nil
+}
+
+// enqueueAnalysis 分析ジョブをワーカープールに投入する
+// キューに投入できなかった場合は分析をFAILEDにする
+func (s *weaknessAnalysisService) enqueueAnalysis(userId string, analysisId string, projectId string) error {
+	err := s.analysisPool.Submit(func(ctx context.Context) {
+		s.runWeaknessAnalysis(ctx, userId, analysisId, projectId)
+	})
 	if err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("分析ステータスの更新に失敗しました: %w", err)
+		if updateErr := s.repo.UpdateAnalysisStatus(analysisId, "FAILED"); updateErr != nil {
+			log.Printf("分析 %s のステータス更新に失敗しました: %v", analysisId, updateErr)
+		}
+		return fmt.Errorf("分析ジョブの登録に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// 各ステージの開始時点の進捗率
+const (
+	progressCategoryStart = 0
+	progressDetailedStart = 40
+	progressAdviceStart   = 70
+	progressScoringStart  = 90
+	progressDone          = 100
+)
+
+// runWeaknessAnalysis カテゴリ分析 → 詳細分析 → 学習アドバイス → 総合スコア算出の順に分析を実行する
+// 各ステージの開始時に処理ステージと進捗率を記録し、失敗した場合はステージを残したままFAILEDにする
+func (s *weaknessAnalysisService) runWeaknessAnalysis(ctx context.Context, userId string, analysisId string, projectId string) {
+	err := s.executeWeaknessAnalysis(ctx, userId, analysisId, projectId)
+	if err == nil {
+		return
 	}
 
-	// 1. カテゴリ分析を再実行
-	categoryAnalysisResults, err := s.WeaknessCategoryAnalysis(userId, req.ProjectID)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("カテゴリ分析の再実行に失敗しました: %w", err)
+	// シャットダウンによる中断の場合は次回起動時に再開するため、PROCESSINGのまま残す
+	if ctx.Err() != nil {
+		log.Printf("分析 %s を中断しました: %v", analysisId, err)
+		return
 	}
 
-	// カテゴリ分析結果を更新
-	err = s.updateCategoryAnalysisResults(userId, existingAnalysis.Analysis.ID, req.ProjectID, categoryAnalysisResults)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("カテゴリ分析結果の更新に失敗しました: %w", err)
+	log.Printf("分析 %s に失敗しました: %v", analysisId, err)
+	if err := s.repo.UpdateAnalysisStatus(analysisId, "FAILED"); err != nil {
+		log.Printf("分析 %s のステータス更新に失敗しました: %v", analysisId, err)
 	}
+}
 
-	// 2. 詳細分析を再実行
-	detailedAnalysisResult, err := s.WeaknessDetailedAnalysis(userId, req.ProjectID)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("詳細分析の再実行に失敗しました: %w", err)
+func (s *weaknessAnalysisService) executeWeaknessAnalysis(ctx context.Context, userId string, analysisId string, projectId string) error {
+	// 使用するプロンプトのバージョンを記録
+	if err := s.repo.UpdateAnalysisVersion(analysisId, s.analysisPromptVersion()); err != nil {
+		return err
 	}
 
-	// 詳細分析結果を更新
-	err = s.updateDetailedAnalysisResult(userId, existingAnalysis.Analysis.ID, detailedAnalysisResult)
+	// 各段階で実際に使用したモデルとプロバイダを記録する
+	record := &analysisLLMRecord{analysisID: analysisId}
+
+	// 1. カテゴリ分析
+	if err := s.repo.UpdateAnalysisProgress(analysisId, model.AnalysisStageCategory, progressCategoryStart); err != nil {
+		return err
+	}
+	categoryAnalysisResults, err := s.weaknessCategoryAnalysis(ctx, userId, projectId, record, func(done, total int) {
+		// カテゴリごとの完了数に応じて進捗を進める
+		progress := progressCategoryStart + (progressDetailedStart-progressCategoryStart)*done/total
+		if err := s.repo.UpdateAnalysisProgress(analysisId, model.AnalysisStageCategory, progress); err != nil {
+			log.Printf("分析 %s の進捗更新に失敗しました: %v", analysisId, err)
+		}
+	})
 	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("詳細分析結果の更新に失敗しました: %w", err)
+		return fmt.Errorf("カテゴリ分析の実行に失敗しました: %w", err)
+	}
+	if err := s.updateCategoryAnalysisResults(userId, analysisId, projectId, categoryAnalysisResults); err != nil {
+		return fmt.Errorf("カテゴリ分析結果の保存に失敗しました: %w", err)
 	}
 
-	// 3. 学習アドバイスを再実行
-	learningAdviceResult, err := s.WeaknessLearningAdvice(userId, req.ProjectID, detailedAnalysisResult)
+	// 2. 詳細分析
+	if err := s.repo.UpdateAnalysisProgress(analysisId, model.AnalysisStageDetailed, progressDetailedStart); err != nil {
+		return err
+	}
+	detailedAnalysisResult, err := s.weaknessDetailedAnalysis(ctx, userId, projectId, record)
 	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("学習アドバイスの再実行に失敗しました: %w", err)
+		return fmt.Errorf("詳細分析の実行に失敗しました: %w", err)
+	}
+	if err := s.updateDetailedAnalysisResult(userId, analysisId, detailedAnalysisResult); err != nil {
+		return fmt.Errorf("詳細分析結果の保存に失敗しました: %w", err)
 	}
 
-	// 学習アドバイス結果を更新
-	err = s.updateLearningAdviceResult(userId, existingAnalysis.Analysis.ID, learningAdviceResult)
+	// 3. 学習アドバイス
+	if err := s.repo.UpdateAnalysisProgress(analysisId, model.AnalysisStageAdvice, progressAdviceStart); err != nil {
+		return err
+	}
+	learningAdviceResult, err := s.weaknessLearningAdvice(ctx, userId, detailedAnalysisResult, record)
 	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("学習アドバイス結果の更新に失敗しました: %w", err)
+		return fmt.Errorf("学習アドバイスの実行に失敗しました: %w", err)
+	}
+	if err := s.updateLearningAdviceResult(userId, analysisId, learningAdviceResult); err != nil {
+		return fmt.Errorf("学習アドバイス結果の保存に失敗しました: %w", err)
 	}
 
-	// 4. 詳細分析結果から総合スコアを再計算して更新
+	// 4. 詳細分析結果から総合スコアを計算して更新
+	if err := s.repo.UpdateAnalysisProgress(analysisId, model.AnalysisStageScoring, progressScoringStart); err != nil {
+		return err
+	}
 	overallScore := s.calculateOverallScore(detailedAnalysisResult)
-	err = s.repo.UpdateOverallScore(existingAnalysis.Analysis.ID, overallScore)
-	if err != nil {
-		// エラー時はステータスをFAILEDに更新
-		s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "FAILED")
-		tx.Rollback()
-		return nil, fmt.Errorf("総合スコアの更新に失敗しました: %w", err)
+	if err := s.repo.UpdateOverallScore(analysisId, overallScore); err != nil {
+		return fmt.Errorf("総合スコアの更新に失敗しました: %w", err)
 	}
 
-	// 全ての再分析が完了したので、ステータスをCOMPLETEDに更新
-	err = s.repo.UpdateAnalysisStatus(existingAnalysis.Analysis.ID, "COMPLETED")
-	if err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("分析ステータスの最終更新に失敗しました: %w", err)
+	// 使用したモデルとプロバイダを記録
+	if err := s.repo.UpdateLLMModel(analysisId, llm.JoinUnique(record.models), llm.JoinUnique(record.providers)); err != nil {
+		return fmt.Errorf("使用したモデルの記録に失敗しました: %w", err)
 	}
 
-	// トランザクションをコミット
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("トランザクションのコミットに失敗しました: %w", err)
+	// 全ての分析が完了したので、ステータスをCOMPLETEDに更新
+	if err := s.repo.UpdateAnalysisProgress(analysisId, model.AnalysisStageDone, progressDone); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateAnalysisStatus(analysisId, "COMPLETED"); err != nil {
+		return fmt.Errorf("分析ステータスの更新に失敗しました: %w", err)
 	}
 
-	// 更新された分析結果を取得して返す
-	updatedAnalysis, err := s.GetWeaknessAnalysisAllSummary(userId, req.ProjectID)
-	if err != nil {
-		return nil, fmt.Errorf("更新された分析結果の取得に失敗しました: %w", err)
+	return nil
+}
+
+// analysisLLMRecord 分析の各段階で使用したモデルとプロバイダ
+type analysisLLMRecord struct {
+	analysisID string // LLM呼び出しの記録に関連付ける弱点分析のID
+	models     []string
+	providers  []string
+}
+
+// subjectID LLM呼び出しの記録に関連付ける弱点分析のID（nilの場合は空）
+func (r *analysisLLMRecord) subjectID() string {
+	if r == nil {
+		return ""
+	}
+	return r.analysisID
+}
+
+// add 応答のモデルとプロバイダを追加する（nilの場合は何もしない）
+func (r *analysisLLMRecord) add(res *llm.Response) {
+	if r == nil {
+		return
 	}
+	r.models = append(r.models, res.Model)
+	r.providers = append(r.providers, res.Provider)
+}
 
-	return updatedAnalysis, nil
+// analysisPromptVersion 分析で使用するプロンプトのバージョン（<プロンプト名>@<バージョン>のカンマ区切り）
+func (s *weaknessAnalysisService) analysisPromptVersion() string {
+	return strings.Join([]string{
+		s.prompts.Version(prompt.NameCategoryAnalysis),
+		s.prompts.Version(prompt.NameDetailedAnalysis),
+		s.prompts.Version(prompt.NameLearningAdvice),
+	}, ",")
 }
 
 // GetWeaknessAnalysis 学習弱点分析を取得する
@@ -331,7 +401,13 @@ func (s *weaknessAnalysisService) GetWeaknessAnalysis(userId string, req *model.
 }
 
 // weaknessCategoryの分析をLLMにて行う
-func (s *weaknessAnalysisService) WeaknessCategoryAnalysis(userId string, projectId string) (map[string]*CategoryAnalysisResult, error) {
+func (s *weaknessAnalysisService) WeaknessCategoryAnalysis(ctx context.Context, userId string, projectId string) (map[string]*CategoryAnalysisResult, error) {
+	return s.weaknessCategoryAnalysis(ctx, userId, projectId, nil, nil)
+}
+
+// weaknessCategoryAnalysis カテゴリ分析を行い、カテゴリごとの完了時にonProgressを呼び出す
+// recordを指定した場合は使用したモデルとプロバイダを記録する
+func (s *weaknessAnalysisService) weaknessCategoryAnalysis(ctx context.Context, userId string, projectId string, record *analysisLLMRecord, onProgress func(done, total int)) (map[string]*CategoryAnalysisResult, error) {
 	// 解答データを取得
 	correctResults, err := s.correctResultsRepo.GetCorrectResults(&model.GetCorrectResultsRequest{ProjectID: projectId})
 	if err != nil {
@@ -353,7 +429,7 @@ func (s *weaknessAnalysisService) WeaknessCategoryAnalysis(userId string, projec
 		}
 
 		// 問題データ取得
-		questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByID(questionAnswer.QuestionTemplateMasterID)
+		questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByIDAnyStatus(questionAnswer.QuestionTemplateMasterID)
 		if err != nil {
 			return nil, err
 		}
@@ -384,14 +460,6 @@ func (s *weaknessAnalysisService) WeaknessCategoryAnalysis(userId string, projec
 		categoryMap[categoryMaster.CategoryMasters.Name] = categoryMaster.CategoryMasters.ID
 	}
 
-	// 学習データをJSON形式に変換
-	jsonData, err := json.MarshalIndent(llmRequests, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal learning data: %w", err)
-	}
-
-	fmt.Println("jsonData", string(jsonData))
-
 	// カテゴリごとにグループ化
 	categoryGroups := make(map[string][]model.LLMWeaknessCategoryAnalysisRequest)
 	for _, req := range llmRequests {
@@ -408,69 +476,46 @@ func (s *weaknessAnalysisService) WeaknessCategoryAnalysis(userId string, projec
 			return nil, fmt.Errorf("failed to marshal category learning data: %w", err)
 		}
 
-		fmt.Printf("Category %s jsonData %s\n", categoryName, string(categoryJsonData))
-
 		// プロンプト整形
-		prompt := s.prompts.GetCategoryAnalysisPrompt(categoryName, string(categoryJsonData))
-
-		// Claudeに分析リクエストを送信
-		msg, err := s.claudeClient.Messages.New(
-			context.Background(),
-			anthropic.MessageNewParams{
-				Model:     anthropic.ModelClaude3_7Sonnet20250219,
-				MaxTokens: 6000,
-				Messages: []anthropic.MessageParam{
-					anthropic.NewUserMessage(
-						anthropic.NewTextBlock(prompt),
-					),
-				},
-			},
-		)
+		categoryPrompt, err := s.prompts.Render(prompt.NameCategoryAnalysis, prompt.CategoryAnalysisData{
+			CategoryName: categoryName,
+			Data:         string(categoryJsonData),
+		})
 		if err != nil {
-			return nil, fmt.Errorf("Claudeによる分析に失敗しました（%s）: %w", categoryName, err)
-		}
-
-		// レスポンスをパース
-		var output string
-		for _, block := range msg.Content {
-			output += block.Text
+			return nil, err
 		}
 
-		fmt.Printf("Category %s raw Claude output: %s\n", categoryName, output)
-
-		// JSONオブジェクトを抽出
-		jsonOutput, err := utils.ExtractFirstJSONObject(output)
-		if err != nil {
-			fmt.Printf("Failed to extract JSON from Claude output（%s）: %s\n", categoryName, output)
-			return nil, fmt.Errorf("failed to extract JSON object from Claude output（%s）: %w", categoryName, err)
-		}
-		fmt.Printf("Category %s extracted jsonOutput: %s\n", categoryName, jsonOutput)
+		// LLMに分析リクエストを送信
+		llmReq := s.router.NewRequest(llm.FeatureCategoryAnalysis, llm.RouteInput{}, categoryPrompt.Text)
+		llmReq.UserID = userId
+		llmReq.SubjectID = record.subjectID()
+		llmReq.PromptVersion = categoryPrompt.Version
+		llmReq.Output = categoryAnalysisOutputSchema
 
-		// JSONをパース
 		var analysisResult CategoryAnalysisResult
-		if err := json.Unmarshal([]byte(jsonOutput), &analysisResult); err != nil {
-			fmt.Printf("JSON unmarshal error for category %s: %v\n", categoryName, err)
-			fmt.Printf("Problematic JSON: %s\n", jsonOutput)
-
-			// フォールバック：デフォルト値を設定
-			fmt.Printf("Using fallback default values for category %s\n", categoryName)
-			analysisResult = CategoryAnalysisResult{
-				IsWeakness: false,
-				IsStrength: false,
-				Issues:     []string{"分析中にエラーが発生しました"},
-				Strengths:  []string{},
-				Examples:   []string{},
-			}
+		llmRes, err := llm.GenerateStructured(ctx, s.llmClient, llmReq, &analysisResult, analysisResult.validate)
+		if err != nil {
+			return nil, fmt.Errorf("LLMによる分析に失敗しました（%s）: %w", categoryName, err)
 		}
+		record.add(llmRes)
 
 		results[categoryName] = &analysisResult
+
+		if onProgress != nil {
+			onProgress(len(results), len(categoryGroups))
+		}
 	}
 
 	return results, nil
 }
 
 // WeaknessDetailedAnalysis 詳細分析をLLMにて行う
-func (s *weaknessAnalysisService) WeaknessDetailedAnalysis(userId string, projectId string) (*model.DetailedAnalysisResult, error) {
+func (s *weaknessAnalysisService) WeaknessDetailedAnalysis(ctx context.Context, userId string, projectId string) (*model.DetailedAnalysisResult, error) {
+	return s.weaknessDetailedAnalysis(ctx, userId, projectId, nil)
+}
+
+// weaknessDetailedAnalysis 詳細分析を行う。recordを指定した場合は使用したモデルとプロバイダを記録する
+func (s *weaknessAnalysisService) weaknessDetailedAnalysis(ctx context.Context, userId string, projectId string, record *analysisLLMRecord) (*model.DetailedAnalysisResult, error) {
 	// 解答データを取得
 	correctResults, err := s.correctResultsRepo.GetCorrectResults(&model.GetCorrectResultsRequest{ProjectID: projectId})
 	if err != nil {
@@ -491,7 +536,7 @@ func (s *weaknessAnalysisService) WeaknessDetailedAnalysis(userId string, projec
 		}
 
 		// 問題データ取得
-		questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByID(questionAnswer.QuestionTemplateMasterID)
+		questionTemplateMaster, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByIDAnyStatus(questionAnswer.QuestionTemplateMasterID)
 		if err != nil {
 			return nil, err
 		}
@@ -516,75 +561,25 @@ func (s *weaknessAnalysisService) WeaknessDetailedAnalysis(userId string, projec
 		return nil, fmt.Errorf("failed to marshal detailed analysis data: %w", err)
 	}
 
-	fmt.Println("Detailed Analysis jsonData:", string(jsonData))
-
 	// プロンプト整形
-	prompt := s.prompts.GetDetailedAnalysisPrompt(string(jsonData))
-
-	// Claudeに分析リクエストを送信
-	msg, err := s.claudeClient.Messages.New(
-		context.Background(),
-		anthropic.MessageNewParams{
-			Model:     anthropic.ModelClaude3_7Sonnet20250219,
-			MaxTokens: 8000,
-			Messages: []anthropic.MessageParam{
-				anthropic.NewUserMessage(
-					anthropic.NewTextBlock(prompt),
-				),
-			},
-		},
-	)
+	detailedPrompt, err := s.prompts.Render(prompt.NameDetailedAnalysis, prompt.AnalysisData{Data: string(jsonData)})
 	if err != nil {
-		return nil, fmt.Errorf("Claudeによる詳細分析に失敗しました: %w", err)
-	}
-
-	// レスポンスをパース
-	var output string
-	for _, block := range msg.Content {
-		output += block.Text
+		return nil, err
 	}
 
-	fmt.Printf("Detailed Analysis raw Claude output: %s\n", output)
-
-	// JSONオブジェクトを抽出
-	jsonOutput, err := utils.ExtractFirstJSONObject(output)
-	if err != nil {
-		fmt.Printf("Failed to extract JSON from detailed analysis output: %s\n", output)
-		return nil, fmt.Errorf("failed to extract JSON object from detailed analysis output: %w", err)
-	}
-	fmt.Printf("Detailed Analysis extracted jsonOutput: %s\n", jsonOutput)
+	// LLMに分析リクエストを送信
+	llmReq := s.router.NewRequest(llm.FeatureDetailedAnalysis, llm.RouteInput{}, detailedPrompt.Text)
+	llmReq.UserID = userId
+	llmReq.SubjectID = record.subjectID()
+	llmReq.PromptVersion = detailedPrompt.Version
+	llmReq.Output = detailedAnalysisOutputSchema
 
-	// JSONをパース
 	var detailedAnalysisResult model.DetailedAnalysisResult
-	if err := json.Unmarshal([]byte(jsonOutput), &detailedAnalysisResult); err != nil {
-		fmt.Printf("JSON unmarshal error for detailed analysis: %v\n", err)
-		fmt.Printf("Problematic JSON: %s\n", jsonOutput)
-
-		// フォールバック：デフォルト値を設定
-		fmt.Println("Using fallback default values for detailed analysis")
-		detailedAnalysisResult = model.DetailedAnalysisResult{
-			Grammar: model.AnalysisDetail{
-				Score:       50,
-				Description: "分析中にエラーが発生しました",
-				Examples:    []string{},
-			},
-			Vocabulary: model.AnalysisDetail{
-				Score:       50,
-				Description: "分析中にエラーが発生しました",
-				Examples:    []string{},
-			},
-			Expression: model.AnalysisDetail{
-				Score:       50,
-				Description: "分析中にエラーが発生しました",
-				Examples:    []string{},
-			},
-			Structure: model.AnalysisDetail{
-				Score:       50,
-				Description: "分析中にエラーが発生しました",
-				Examples:    []string{},
-			},
-		}
+	llmRes, err := llm.GenerateStructured(ctx, s.llmClient, llmReq, &detailedAnalysisResult, nil)
+	if err != nil {
+		return nil, fmt.Errorf("LLMによる詳細分析に失敗しました: %w", err)
 	}
+	record.add(llmRes)
 
 	return &detailedAnalysisResult, nil
 }
@@ -610,67 +605,37 @@ func (s *weaknessAnalysisService) calculateOverallScore(detailedAnalysis *model.
 }
 
 // WeaknessLearningAdvice 学習アドバイスをLLMにて行う
-func (s *weaknessAnalysisService) WeaknessLearningAdvice(userId string, projectId string, detailedAnalysis *model.DetailedAnalysisResult) (*model.PersonalizedAdvice, error) {
+func (s *weaknessAnalysisService) WeaknessLearningAdvice(ctx context.Context, userId string, projectId string, detailedAnalysis *model.DetailedAnalysisResult) (*model.PersonalizedAdvice, error) {
+	return s.weaknessLearningAdvice(ctx, userId, detailedAnalysis, nil)
+}
+
+// weaknessLearningAdvice 学習アドバイスを作成する。recordを指定した場合は使用したモデルとプロバイダを記録する
+func (s *weaknessAnalysisService) weaknessLearningAdvice(ctx context.Context, userId string, detailedAnalysis *model.DetailedAnalysisResult, record *analysisLLMRecord) (*model.PersonalizedAdvice, error) {
 	// 詳細分析結果をJSON形式に変換
 	jsonData, err := json.MarshalIndent(detailedAnalysis, "", "  ")
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal detailed analysis data: %w", err)
 	}
 
-	fmt.Println("Learning Advice jsonData:", string(jsonData))
-
 	// プロンプト整形
-	prompt := s.prompts.GetLearningAdvicePrompt(string(jsonData))
-
-	// Claudeに分析リクエストを送信
-	msg, err := s.claudeClient.Messages.New(
-		context.Background(),
-		anthropic.MessageNewParams{
-			Model:     anthropic.ModelClaude3_7Sonnet20250219,
-			MaxTokens: 8000,
-			Messages: []anthropic.MessageParam{
-				anthropic.NewUserMessage(
-					anthropic.NewTextBlock(prompt),
-				),
-			},
-		},
-	)
+	advicePrompt, err := s.prompts.Render(prompt.NameLearningAdvice, prompt.AnalysisData{Data: string(jsonData)})
 	if err != nil {
-		return nil, fmt.Errorf("Claudeによる学習アドバイス生成に失敗しました: %w", err)
-	}
-
-	// レスポンスをパース
-	var output string
-	for _, block := range msg.Content {
-		output += block.Text
+		return nil, err
 	}
 
-	fmt.Printf("Learning Advice raw Claude output: %s\n", output)
-
-	// JSONオブジェクトを抽出
-	jsonOutput, err := utils.ExtractFirstJSONObject(output)
-	if err != nil {
-		fmt.Printf("Failed to extract JSON from learning advice output: %s\n", output)
-		return nil, fmt.Errorf("failed to extract JSON object from learning advice output: %w", err)
-	}
-	fmt.Printf("Learning Advice extracted jsonOutput: %s\n", jsonOutput)
+	// LLMに分析リクエストを送信
+	llmReq := s.router.NewRequest(llm.FeatureLearningAdvice, llm.RouteInput{}, advicePrompt.Text)
+	llmReq.UserID = userId
+	llmReq.SubjectID = record.subjectID()
+	llmReq.PromptVersion = advicePrompt.Version
+	llmReq.Output = learningAdviceOutputSchema
 
-	// JSONをパース
 	var learningAdviceResult model.PersonalizedAdvice
-	if err := json.Unmarshal([]byte(jsonOutput), &learningAdviceResult); err != nil {
-		fmt.Printf("JSON unmarshal error for learning advice: %v\n", err)
-		fmt.Printf("Problematic JSON: %s\n", jsonOutput)
-
-		// フォールバック：デフォルト値を設定
-		fmt.Println("Using fallback default values for learning advice")
-		learningAdviceResult = model.PersonalizedAdvice{
-			LearningAdvice:      "分析中にエラーが発生しました。基本的な英語学習を継続してください。",
-			RecommendedActions:  []string{"基本的な文法学習", "語彙力向上", "リーディング練習"},
-			NextGoals:           []string{"基礎力向上", "継続的な学習習慣の確立"},
-			StudyPlan:           "毎日30分の英語学習を継続し、基礎力を向上させましょう。",
-			MotivationalMessage: "継続は力なり。一歩ずつ着実に進歩していきましょう。",
-		}
+	llmRes, err := llm.GenerateStructured(ctx, s.llmClient, llmReq, &learningAdviceResult, nil)
+	if err != nil {
+		return nil, fmt.Errorf("LLMによる学習アドバイス生成に失敗しました: %w", err)
 	}
+	record.add(llmRes)
 
 	return &learningAdviceResult, nil
 }
@@ -699,65 +664,6 @@ func (s *weaknessAnalysisService) saveLearningAdviceResult(userId string, analys
 	return nil
 }
 
-// saveCategoryAnalysisResults カテゴリ分析結果をデータベースに保存する
-func (s *weaknessAnalysisService) saveCategoryAnalysisResults(userId string, analysisId string, projectId string, results map[string]*CategoryAnalysisResult) error {
-	for categoryName, result := range results {
-		// カテゴリIDを取得（カテゴリ名から検索）
-		categoryMaster, err := s.categoryMastersRepo.GetCategoryMastersByName(categoryName)
-		if err != nil {
-			return fmt.Errorf("カテゴリマスター取得エラー（%s）: %w", categoryName, err)
-		}
-		if categoryMaster == nil {
-			return fmt.Errorf("カテゴリマスターが見つかりません: %s", categoryName)
-		}
-
-		// カテゴリごとのスコアを計算（correction_resultsテーブルのデータに基づく）
-		score, err := s.calculateCategoryScore(projectId, categoryMaster.CategoryMasters.ID)
-		if err != nil {
-			fmt.Printf("カテゴリ %s のスコア計算でエラー: %v\n", categoryName, err)
-			// エラーの場合はデフォルト値を使用
-			score = 50
-		}
-
-		// スコアに基づいて強み・弱みの判定を調整
-		// LLMの分析結果とスコアが矛盾する場合はスコアを優先
-		if score >= 80 && !result.IsStrength {
-			fmt.Printf("カテゴリ %s: スコア %d に基づいて強みに調整\n", categoryName, score)
-			result.IsStrength = true
-			result.IsWeakness = false
-		} else if score <= 40 && !result.IsWeakness {
-			fmt.Printf("カテゴリ %s: スコア %d に基づいて弱みに調整\n", categoryName, score)
-			result.IsWeakness = true
-			result.IsStrength = false
-		}
-
-		// JSON配列を文字列に変換
-		issuesJSON, _ := json.Marshal(result.Issues)
-		strengthsJSON, _ := json.Marshal(result.Strengths)
-		examplesJSON, _ := json.Marshal(result.Examples)
-
-		// データベースに保存
-		req := &model.CreateWeaknessCategoryAnalysisRequest{
-			AnalysisID:   analysisId,
-			CategoryID:   categoryMaster.CategoryMasters.ID,
-			CategoryName: categoryName,
-			Score:        score,
-			IsWeakness:   result.IsWeakness,
-			IsStrength:   result.IsStrength,
-			Issues:       string(issuesJSON),
-			Strengths:    string(strengthsJSON),
-			Examples:     string(examplesJSON),
-		}
-
-		_, err = s.weaknessCategoryAnalysisRepo.CreateWeaknessCategoryAnalysis(userId, req)
-		if err != nil {
-			return fmt.Errorf("カテゴリ分析結果保存エラー（%s）: %w", categoryName, err)
-		}
-	}
-
-	return nil
-}
-
 // calculateCategoryScore カテゴリごとのスコアを計算する（correction_resultsテーブルのデータに基づく）
 func (s *weaknessAnalysisService) calculateCategoryScore(projectId string, categoryId string) (int, error) {
 	// プロジェクトの全correction_resultsを取得
@@ -776,7 +682,7 @@ func (s *weaknessAnalysisService) calculateCategoryScore(projectId string, categ
 
 	for _, correctResult := range correctResults.CorrectResults {
 		// 問題テンプレートマスターを取得してカテゴリIDを確認
-		questionTemplate, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByID(correctResult.QuestionTemplateMasterID)
+		questionTemplate, err := s.questionTemplateMastersRepo.GetQuestionTemplateMasterByIDAnyStatus(correctResult.QuestionTemplateMasterID)
 		if err != nil {
 			continue // エラーの場合はスキップ
 		}
@@ -885,7 +791,7 @@ func (s *weaknessAnalysisService) GetWeaknessAnalysisAllSummary(userId string, p
 	}, nil
 }
 
-// updateCategoryAnalysisResults カテゴリ分析結果を更新する
+// updateCategoryAnalysisResults カテゴリ分析結果を更新する（存在しないカテゴリは作成する）
 func (s *weaknessAnalysisService) updateCategoryAnalysisResults(userId string, analysisId string, projectId string, results map[string]*CategoryAnalysisResult) error {
 	// 既存のカテゴリ分析結果を全て取得
 	existingCategoryAnalyses, err := s.weaknessCategoryAnalysisRepo.GetWeaknessCategoryAnalysis(analysisId)
@@ -909,27 +815,21 @@ func (s *weaknessAnalysisService) updateCategoryAnalysisResults(userId string, a
 			return fmt.Errorf("カテゴリマスターが見つかりません: %s", categoryName)
 		}
 
-		// 既存の分析結果を取得
-		existingCategoryAnalysis, exists := existingCategoryMap[categoryName]
-		if !exists {
-			return fmt.Errorf("既存のカテゴリ分析結果が見つかりません: %s", categoryName)
-		}
-
 		// カテゴリごとのスコアを計算（correction_resultsテーブルのデータに基づく）
 		score, err := s.calculateCategoryScore(projectId, categoryMaster.CategoryMasters.ID)
 		if err != nil {
-			fmt.Printf("カテゴリ %s のスコア計算でエラー: %v\n", categoryName, err)
+			log.Printf("カテゴリ %s のスコア計算でエラー: %v", categoryName, err)
 			// エラーの場合はデフォルト値を使用
 			score = 50
 		}
 
 		// スコアに基づいて強み・弱みの判定を調整
 		if score >= 80 && !result.IsStrength {
-			fmt.Printf("カテゴリ %s: スコア %d に基づいて強みに調整\n", categoryName, score)
+			log.Printf("カテゴリ %s: スコア %d に基づいて強みに調整", categoryName, score)
 			result.IsStrength = true
 			result.IsWeakness = false
 		} else if score <= 40 && !result.IsWeakness {
-			fmt.Printf("カテゴリ %s: スコア %d に基づいて弱みに調整\n", categoryName, score)
+			log.Printf("カテゴリ %s: スコア %d に基づいて弱みに調整", categoryName, score)
 			result.IsWeakness = true
 			result.IsStrength = false
 		}
@@ -950,6 +850,26 @@ func (s *weaknessAnalysisService) updateCategoryAnalysisResults(userId string, a
 			return fmt.Errorf("Examples配列のJSON変換エラー: %w", err)
 		}
 
+		// 既存の分析結果がないカテゴリ（初回分析・新たに解答したカテゴリ）は作成する
+		existingCategoryAnalysis, exists := existingCategoryMap[categoryName]
+		if !exists {
+			createReq := &model.CreateWeaknessCategoryAnalysisRequest{
+				AnalysisID:   analysisId,
+				CategoryID:   categoryMaster.CategoryMasters.ID,
+				CategoryName: categoryName,
+				Score:        score,
+				IsWeakness:   result.IsWeakness,
+				IsStrength:   result.IsStrength,
+				Issues:       string(issuesJSON),
+				Strengths:    string(strengthsJSON),
+				Examples:     string(examplesJSON),
+			}
+			if _, err := s.weaknessCategoryAnalysisRepo.CreateWeaknessCategoryAnalysis(userId, createReq); err != nil {
+				return fmt.Errorf("カテゴリ分析結果保存エラー（%s）: %w", categoryName, err)
+			}
+			continue
+		}
+
 		// 更新リクエストを作成
 		updateReq := &model.UpdateWeaknessCategoryAnalysisRequest{
 			ID:           existingCategoryAnalysis.ID,
@@ -974,10 +894,14 @@ func (s *weaknessAnalysisService) updateCategoryAnalysisResults(userId string, a
 	return nil
 }
 
-// updateDetailedAnalysisResult 詳細分析結果を更新する
+// updateDetailedAnalysisResult 詳細分析結果を更新する（存在しない場合は作成する）
 func (s *weaknessAnalysisService) updateDetailedAnalysisResult(userId string, analysisId string, result *model.DetailedAnalysisResult) error {
 	// 既存の詳細分析結果を取得
 	existingDetailedAnalysis, err := s.weaknessDetailedAnalysisRepo.GetWeaknessDetailedAnalysis(analysisId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 初回分析時は作成する
+		return s.saveDetailedAnalysisResult(userId, analysisId, result)
+	}
 	if err != nil {
 		return fmt.Errorf("既存の詳細分析結果取得エラー: %w", err)
 	}
@@ -1030,10 +954,14 @@ func (s *weaknessAnalysisService) updateDetailedAnalysisResult(userId string, an
 	return nil
 }
 
-// updateLearningAdviceResult 学習アドバイス結果を更新する
+// updateLearningAdviceResult 学習アドバイス結果を更新する（存在しない場合は作成する）
 func (s *weaknessAnalysisService) updateLearningAdviceResult(userId string, analysisId string, result *model.PersonalizedAdvice) error {
 	// 既存の学習アドバイス結果を取得
 	existingLearningAdvice, err := s.weaknessLearningAdviceRepo.GetWeaknessLearningAdvice(analysisId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 初回分析時は作成する
+		return s.saveLearningAdviceResult(userId, analysisId, result)
+	}
 	if err != nil {
 		return fmt.Errorf("既存の学習アドバイス結果取得エラー: %w", err)
 	}
@@ -0,0 +1,202 @@
+package spelling
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+)
+
+// embeddedWords バイナリに組み込まれた英単語の一覧（1行1語、#で始まる行はコメント）
+//
+//go:embed words.txt
+var embeddedWords string
+
+// minWordLength 綴りを確認する単語の最小の文字数（これより短い語は確認しない）
+const minWordLength = 3
+
+// Misspelling 綴りの誤りの候補
+// 位置は文字列の先頭からの文字数（0始まり、終了位置の文字は含まない）
+type Misspelling struct {
+	Word       string
+	Start      int
+	End        int
+	Suggestion string
+}
+
+// Checker 単語の一覧に基づいて綴りを確認する
+// 一覧にない語のうち、1文字の追加・削除・置換・入れ替えで一覧の語になるものだけを誤りとして扱う
+// （固有名詞や一覧にない専門用語を誤りとしないため）
+type Checker struct {
+	rank map[string]int // 単語 → 一覧での順位（小さいほどよく使われる語）
+}
+
+// NewChecker 組み込みの単語の一覧でCheckerを作成する
+func NewChecker() *Checker {
+	return NewCheckerFromList(embeddedWords)
+}
+
+// NewCheckerFromList 1行1語の単語の一覧からCheckerを作成する
+func NewCheckerFromList(list string) *Checker {
+	c := &Checker{rank: make(map[string]int)}
+	for _, line := range strings.Split(list, "\n") {
+		word := strings.ToLower(strings.TrimSpace(line))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if _, ok := c.rank[word]; !ok {
+			c.rank[word] = len(c.rank)
+		}
+	}
+	return c
+}
+
+// Known 単語が一覧に含まれているかどうか（大文字小文字は区別しない）
+func (c *Checker) Known(word string) bool {
+	_, ok := c.rank[strings.ToLower(word)]
+	return ok
+}
+
+// KnownWords 文字列中のラテン文字の語の数と、そのうち一覧に含まれる語の数を返す（英語で書かれているかの判定に使用する）
+// 所有格（Tom's）は語幹で判定し、数字を含む語は数えない
+func (c *Checker) KnownWords(text string) (known int, total int) {
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !isLatinLetter(r) && !isApostrophe(r) && !unicode.IsDigit(r)
+	}) {
+		word = strings.Trim(strings.ReplaceAll(strings.ToLower(word), "’", "'"), "'")
+		if word == "" || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		total++
+		if c.Known(word) || c.Known(strings.TrimSuffix(word, "'s")) {
+			known++
+		}
+	}
+	return known, total
+}
+
+// Check 文字列中の綴りの誤りの候補を出現順に返す
+// 大文字だけの語（略語）と文頭以外の大文字で始まる語（固有名詞）は確認しない
+func (c *Checker) Check(text string) []Misspelling {
+	runes := []rune(text)
+
+	var misspellings []Misspelling
+	sentenceStart := true
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !isLatinLetter(r) {
+			if r == '.' || r == '!' || r == '?' {
+				sentenceStart = true
+			} else if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+				sentenceStart = false
+			}
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && (isLatinLetter(runes[i]) || (isApostrophe(runes[i]) && i+1 < len(runes) && isLatinLetter(runes[i+1]))) {
+			i++
+		}
+		// 数字を含む語（2nd など）は確認しない
+		if i < len(runes) && unicode.IsDigit(runes[i]) {
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			sentenceStart = false
+			continue
+		}
+
+		word := string(runes[start:i])
+		atSentenceStart := sentenceStart
+		sentenceStart = false
+		if suggestion, ok := c.suggest(word, atSentenceStart); ok {
+			misspellings = append(misspellings, Misspelling{Word: word, Start: start, End: i, Suggestion: suggestion})
+		}
+	}
+	return misspellings
+}
+
+// suggest 一覧にない語の修正候補を返す（誤りとして扱わない語の場合はfalse）
+func (c *Checker) suggest(word string, atSentenceStart bool) (string, bool) {
+	if len([]rune(word)) < minWordLength || isUpper(word) {
+		return "", false
+	}
+	if !atSentenceStart && unicode.IsUpper([]rune(word)[0]) {
+		return "", false
+	}
+
+	lower := strings.ReplaceAll(strings.ToLower(word), "’", "'")
+	// 所有格（Tom's）は語幹で確認する
+	stem := strings.TrimSuffix(lower, "'s")
+	if c.Known(lower) || c.Known(stem) {
+		return "", false
+	}
+
+	// 先頭の文字を誤ることは少ないため、先頭の文字が同じ候補を優先し、その中でよく使われる語を選ぶ（evry → every）
+	best, bestRank, bestSameFirst := "", -1, false
+	first := []rune(stem)[0]
+	for _, candidate := range edits(stem) {
+		rank, ok := c.rank[candidate]
+		if !ok {
+			continue
+		}
+		sameFirst := []rune(candidate)[0] == first
+		if bestRank < 0 || (sameFirst && !bestSameFirst) || (sameFirst == bestSameFirst && rank < bestRank) {
+			best, bestRank, bestSameFirst = candidate, rank, sameFirst
+		}
+	}
+	if bestRank < 0 {
+		return "", false
+	}
+
+	best += lower[len(stem):]
+	if unicode.IsUpper([]rune(word)[0]) {
+		runes := []rune(best)
+		runes[0] = unicode.ToUpper(runes[0])
+		best = string(runes)
+	}
+	return best, true
+}
+
+// edits 1文字の削除・入れ替え・置換・追加で作れる語の一覧
+func edits(word string) []string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	runes := []rune(word)
+
+	var candidates []string
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) {
+			candidates = append(candidates, string(runes[:i])+string(runes[i+1:]))
+		}
+		if i+1 < len(runes) {
+			swapped := append([]rune(nil), runes...)
+			swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+			candidates = append(candidates, string(swapped))
+		}
+		for _, l := range letters {
+			if i < len(runes) && runes[i] != l {
+				candidates = append(candidates, string(runes[:i])+string(l)+string(runes[i+1:]))
+			}
+			candidates = append(candidates, string(runes[:i])+string(l)+string(runes[i:]))
+		}
+	}
+	return candidates
+}
+
+func isLatinLetter(r rune) bool {
+	return unicode.Is(unicode.Latin, r)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+// isUpper 語がすべて大文字かどうか（略語の判定）
+func isUpper(word string) bool {
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+	return true
+}
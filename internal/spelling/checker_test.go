@@ -0,0 +1,95 @@
+package spelling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckerCheck(t *testing.T) {
+	checker := NewChecker()
+
+	tests := []struct {
+		name string
+		text string
+		want []Misspelling
+	}{
+		{name: "誤りなし", text: "I went to see a movie with my friends yesterday."},
+		{name: "空文字", text: ""},
+		{
+			name: "先頭の文字が同じ候補を優先する",
+			text: "I go to school evry day.",
+			want: []Misspelling{{Word: "evry", Start: 15, End: 19, Suggestion: "every"}},
+		},
+		{
+			name: "複数の誤りを出現順に返す",
+			text: "I recieve a leter from my freind.",
+			want: []Misspelling{
+				{Word: "recieve", Start: 2, End: 9, Suggestion: "receive"},
+				{Word: "leter", Start: 12, End: 17, Suggestion: "letter"},
+				{Word: "freind", Start: 26, End: 32, Suggestion: "friend"},
+			},
+		},
+		{
+			name: "文頭の語は大文字を保って提案する",
+			text: "Becuase it rained, I stayed home.",
+			want: []Misspelling{{Word: "Becuase", Start: 0, End: 7, Suggestion: "Because"}},
+		},
+		{name: "文中の大文字で始まる語（固有名詞）は確認しない", text: "I met Jhon in Kyoto."},
+		{name: "略語は確認しない", text: "I work at NSAA now."},
+		{name: "短い語は確認しない", text: "I am ok."},
+		{name: "数字を含む語は確認しない", text: "I bought 3rd4 tickets."},
+		{name: "1文字の修正で一覧の語にならない語は確認しない", text: "I like xylophonist music."},
+		{
+			name: "所有格は語幹で確認する",
+			text: "It is my freind's car.",
+			want: []Misspelling{{Word: "freind's", Start: 9, End: 17, Suggestion: "friend's"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checker.Check(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckerKnownWords(t *testing.T) {
+	checker := NewChecker()
+
+	tests := []struct {
+		text      string
+		wantKnown int
+		wantTotal int
+	}{
+		{text: "", wantKnown: 0, wantTotal: 0},
+		{text: "I drink coffee every morning.", wantKnown: 5, wantTotal: 5},
+		{text: "Ich trinke jeden Morgen Kaffee.", wantKnown: 0, wantTotal: 5},
+		{text: "I visited Kyoto on the 2nd day.", wantKnown: 5, wantTotal: 6},
+		{text: "It's Tom's book.", wantKnown: 2, wantTotal: 3},
+	}
+
+	for _, tt := range tests {
+		known, total := checker.KnownWords(tt.text)
+		if known != tt.wantKnown || total != tt.wantTotal {
+			t.Errorf("KnownWords(%q) = %d, %d; want %d, %d", tt.text, known, total, tt.wantKnown, tt.wantTotal)
+		}
+	}
+}
+
+// 単語の一覧の形式（空行・コメント・大文字小文字・重複）と順位
+func TestNewCheckerFromList(t *testing.T) {
+	checker := NewCheckerFromList("# コメント\nvery\n\nEvery\nvery\n")
+
+	if !checker.Known("every") || !checker.Known("VERY") || checker.Known("# コメント") {
+		t.Error("一覧の語の判定が正しくありません")
+	}
+	// 一覧で先に現れる語がよく使われる語だが、先頭の文字が同じ候補を優先する
+	if got := checker.Check("evry"); len(got) != 1 || got[0].Suggestion != "every" {
+		t.Errorf("Check(evry) = %+v, want every", got)
+	}
+	if got := checker.Check("vrey"); len(got) != 1 || got[0].Suggestion != "very" {
+		t.Errorf("Check(vrey) = %+v, want very", got)
+	}
+}
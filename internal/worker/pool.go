@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrQueueFull キューが満杯でジョブを受け付けられない
+var ErrQueueFull = errors.New("ジョブキューが満杯です")
+
+// ErrPoolStopped 停止済みのプールにジョブが投入された
+var ErrPoolStopped = errors.New("ワーカープールは停止しています")
+
+// Job ワーカーで実行する処理
+type Job func(ctx context.Context)
+
+// Pool 同時実行数とキュー長に上限を持つプロセス内ワーカープール
+type Pool struct {
+	name    string
+	workers int
+	jobs    chan Job
+
+	mu      sync.RWMutex
+	stopped bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool ワーカー数とキュー長を指定してワーカープールを作成する
+func NewPool(name string, workers, queueSize int) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pool{
+		name:    name,
+		workers: workers,
+		jobs:    make(chan Job, queueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start ワーカーを起動する
+func (p *Pool) Start() {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.run()
+	}
+	log.Printf("ワーカープール %s を起動しました（ワーカー数: %d）", p.name, p.workers)
+}
+
+// Submit ジョブをキューに投入する（キューが満杯の場合はブロックせずにErrQueueFullを返す）
+func (p *Pool) Submit(job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return ErrPoolStopped
+	}
+
+	select {
+	case p.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// SubmitWait ジョブをキューに投入する（キューが満杯の場合は空きができるまで待つ）
+// 待っている間にプールが停止した場合はErrPoolStopped、ctxが終了した場合はctxのエラーを返す
+func (p *Pool) SubmitWait(ctx context.Context, job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return ErrPoolStopped
+	}
+
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-p.ctx.Done():
+		return ErrPoolStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop 新規ジョブの受付を停止し、実行中のワーカーの終了を待つ
+// キューに残っているジョブはキャンセル済みのコンテキストで実行される
+func (p *Pool) Stop() {
+	// キューの空きを待っているSubmitWaitを先に終了させてから受付を停止する
+	p.cancel()
+
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	close(p.jobs)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+func (p *Pool) run() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		p.execute(job)
+	}
+}
+
+// execute ジョブを実行する（パニックしてもワーカーは停止しない）
+func (p *Pool) execute(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("ワーカープール %s のジョブでパニックが発生しました: %v", p.name, r)
+		}
+	}()
+	job(p.ctx)
+}
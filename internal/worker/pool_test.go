@@ -0,0 +1,207 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitDone チャネルが閉じられるまで待つ（一定時間を過ぎた場合はテストを失敗させる）
+func waitDone(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%sが終了しません", what)
+	}
+}
+
+func TestPoolSubmitQueueFull(t *testing.T) {
+	p := NewPool("test", 1, 2)
+	defer p.Stop()
+
+	// ワーカーを起動する前はキュー長までジョブを受け付ける
+	var ran atomic.Int32
+	job := func(ctx context.Context) { ran.Add(1) }
+	for i := 0; i < 2; i++ {
+		if err := p.Submit(job); err != nil {
+			t.Fatalf("%d件目の Submit = %v", i+1, err)
+		}
+	}
+	if err := p.Submit(job); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("キューが満杯の Submit = %v, want ErrQueueFull", err)
+	}
+
+	// ワーカーを起動するとキューのジョブを実行する
+	done := make(chan struct{})
+	p.Start()
+	if err := p.SubmitWait(context.Background(), func(ctx context.Context) { close(done) }); err != nil {
+		t.Fatalf("SubmitWait = %v", err)
+	}
+	waitDone(t, done, "ジョブ")
+	if ran.Load() != 2 {
+		t.Errorf("実行したジョブの数 = %d, want 2", ran.Load())
+	}
+}
+
+// キューの空きを待っているSubmitWaitは、空きができた場合・停止した場合・ctxが終了した場合に戻る
+func TestPoolSubmitWait(t *testing.T) {
+	p := NewPool("test", 1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Start()
+
+	// ワーカーを実行中のジョブで塞ぎ、キューを満杯にする
+	if err := p.Submit(func(ctx context.Context) { close(started); <-release }); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	waitDone(t, started, "ジョブの開始")
+	if err := p.Submit(func(ctx context.Context) {}); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+
+	// ctxが終了した場合はctxのエラーを返す
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.SubmitWait(ctx, func(ctx context.Context) {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SubmitWait = %v, want context.DeadlineExceeded", err)
+	}
+
+	// 空きができた場合は投入する
+	submitted := make(chan error, 1)
+	go func() { submitted <- p.SubmitWait(context.Background(), func(ctx context.Context) {}) }()
+	close(release)
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Errorf("SubmitWait = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("空きができても SubmitWait が戻りません")
+	}
+	p.Stop()
+
+	// 待っている間に停止した場合はErrPoolStoppedを返す
+	p = NewPool("test", 1, 1)
+	if err := p.Submit(func(ctx context.Context) {}); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	waiting := make(chan error, 1)
+	go func() { waiting <- p.SubmitWait(context.Background(), func(ctx context.Context) {}) }()
+	time.Sleep(10 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() { p.Stop(); close(stopped) }()
+	waitDone(t, stopped, "Stop")
+	select {
+	case err := <-waiting:
+		if !errors.Is(err, ErrPoolStopped) {
+			t.Errorf("停止後の SubmitWait = %v, want ErrPoolStopped", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("停止しても SubmitWait が戻りません")
+	}
+}
+
+// ジョブがパニックしてもワーカーは次のジョブを実行する
+func TestPoolRecoversPanic(t *testing.T) {
+	p := NewPool("test", 1, 2)
+	p.Start()
+	defer p.Stop()
+
+	done := make(chan struct{})
+	if err := p.Submit(func(ctx context.Context) { panic("ジョブの失敗") }); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	if err := p.Submit(func(ctx context.Context) { close(done) }); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	waitDone(t, done, "パニック後のジョブ")
+}
+
+// 停止時は実行中のジョブのコンテキストをキャンセルし、キューに残ったジョブはキャンセル済みのコンテキストで実行して終了を待つ
+func TestPoolStopCancelsJobs(t *testing.T) {
+	p := NewPool("test", 1, 2)
+	p.Start()
+
+	started := make(chan struct{})
+	var (
+		mu      sync.Mutex
+		results []error
+	)
+	record := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, err)
+	}
+	if err := p.Submit(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		record(ctx.Err())
+	}); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	waitDone(t, started, "ジョブの開始")
+	for i := 0; i < 2; i++ {
+		if err := p.Submit(func(ctx context.Context) { record(ctx.Err()) }); err != nil {
+			t.Fatalf("Submit = %v", err)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() { p.Stop(); close(stopped) }()
+	waitDone(t, stopped, "Stop")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results) != 3 {
+		t.Fatalf("停止までに実行したジョブの数 = %d, want 3", len(results))
+	}
+	for i, err := range results {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%d件目のジョブのコンテキスト = %v, want context.Canceled", i+1, err)
+		}
+	}
+
+	// 停止後はジョブを受け付けず、Stopを再度呼び出してもよい
+	if err := p.Submit(func(ctx context.Context) {}); !errors.Is(err, ErrPoolStopped) {
+		t.Errorf("停止後の Submit = %v, want ErrPoolStopped", err)
+	}
+	if err := p.SubmitWait(context.Background(), func(ctx context.Context) {}); !errors.Is(err, ErrPoolStopped) {
+		t.Errorf("停止後の SubmitWait = %v, want ErrPoolStopped", err)
+	}
+	p.Stop()
+}
+
+// ワーカー数を超えてジョブを同時に実行しない
+func TestPoolConcurrency(t *testing.T) {
+	const workers = 2
+	p := NewPool("test", workers, 10)
+	p.Start()
+
+	var inFlight, maxInFlight atomic.Int32
+	for i := 0; i < 6; i++ {
+		if err := p.Submit(func(ctx context.Context) {
+			n := inFlight.Add(1)
+			for {
+				current := maxInFlight.Load()
+				if n <= current || maxInFlight.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			inFlight.Add(-1)
+		}); err != nil {
+			t.Fatalf("Submit = %v", err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	p.Stop()
+
+	if got := maxInFlight.Load(); got != workers {
+		t.Errorf("同時に実行したジョブの最大数 = %d, want %d", got, workers)
+	}
+}
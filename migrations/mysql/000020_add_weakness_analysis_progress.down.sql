@@ -0,0 +1,3 @@
+ALTER TABLE weakness_analyses
+DROP COLUMN progress,
+DROP COLUMN analysis_stage;
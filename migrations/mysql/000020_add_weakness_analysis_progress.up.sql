@@ -0,0 +1,4 @@
+-- 弱点分析の進捗管理カラムを追加
+ALTER TABLE weakness_analyses
+ADD COLUMN analysis_stage VARCHAR(20) NOT NULL DEFAULT 'QUEUED' COMMENT '分析処理の現在のステージ（QUEUED, CATEGORY, DETAILED, ADVICE, SCORING, DONE）' AFTER analysis_status,
+ADD COLUMN progress INT NOT NULL DEFAULT 0 COMMENT '分析処理の進捗率（0-100）' AFTER analysis_stage;
@@ -0,0 +1,5 @@
+ALTER TABLE weakness_analyses
+MODIFY COLUMN analysis_version VARCHAR(10) NOT NULL COMMENT '分析ロジックのバージョン（プロンプトや処理の変更管理用）';
+
+ALTER TABLE correction_results
+DROP COLUMN prompt_version;
@@ -0,0 +1,6 @@
+-- 採点・分析に使用したプロンプトのバージョンを記録する
+ALTER TABLE correction_results
+ADD COLUMN prompt_version VARCHAR(100) NULL COMMENT '採点に使用したプロンプトのバージョン（<プロンプト名>@<バージョン>）' AFTER advice;
+
+ALTER TABLE weakness_analyses
+MODIFY COLUMN analysis_version VARCHAR(255) NOT NULL COMMENT '分析に使用したプロンプトのバージョン（<プロンプト名>@<バージョン>のカンマ区切り）';
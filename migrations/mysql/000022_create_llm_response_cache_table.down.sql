@@ -0,0 +1,2 @@
+-- LLMResponseCache テーブルの削除
+DROP TABLE IF EXISTS llm_response_cache;
@@ -0,0 +1,17 @@
+-- LLMResponseCache テーブルの作成
+-- 同一のリクエストに対するLLMの応答を再利用するためのキャッシュテーブル
+CREATE TABLE llm_response_cache (
+    cache_key CHAR(64) PRIMARY KEY COMMENT 'モデル・プロンプトのバージョン・プロンプト本文などのSHA-256ハッシュ',
+    feature VARCHAR(50) NOT NULL COMMENT '応答を生成した機能（grading, category_analysis など）',
+    llm_model VARCHAR(100) NOT NULL COMMENT '応答を生成したモデル',
+    prompt_version VARCHAR(100) NOT NULL DEFAULT '' COMMENT '使用したプロンプトのバージョン',
+    response_text MEDIUMTEXT NOT NULL COMMENT 'LLMの応答テキスト',
+    input_tokens INT NOT NULL DEFAULT 0 COMMENT '生成時の入力トークン数',
+    output_tokens INT NOT NULL DEFAULT 0 COMMENT '生成時の出力トークン数',
+    expires_at DATETIME NOT NULL COMMENT '有効期限',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+
+    -- インデックス
+    INDEX idx_llm_response_cache_expires_at (expires_at),
+    INDEX idx_llm_response_cache_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='LLM応答キャッシュテーブル';
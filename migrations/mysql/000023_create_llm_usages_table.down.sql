@@ -0,0 +1,2 @@
+-- LLMUsages テーブルの削除
+DROP TABLE IF EXISTS llm_usages;
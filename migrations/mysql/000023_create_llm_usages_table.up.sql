@@ -0,0 +1,16 @@
+-- LLMUsages テーブルの作成
+-- LLM呼び出しごとのトークン数と見積もり料金をユーザー・機能ごとに記録するテーブル
+CREATE TABLE llm_usages (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    user_id CHAR(36) NOT NULL DEFAULT '' COMMENT '呼び出し元のユーザーID',
+    feature VARCHAR(50) NOT NULL COMMENT '呼び出し元の機能（grading, category_analysis など）',
+    llm_model VARCHAR(100) NOT NULL COMMENT '使用したモデル',
+    input_tokens INT NOT NULL DEFAULT 0 COMMENT '入力トークン数',
+    output_tokens INT NOT NULL DEFAULT 0 COMMENT '出力トークン数',
+    cost_usd DECIMAL(12, 6) NOT NULL DEFAULT 0 COMMENT '見積もり料金（USD）',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+
+    -- インデックス
+    INDEX idx_llm_usages_user_id_created_at (user_id, created_at),
+    INDEX idx_llm_usages_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='LLM利用量テーブル';
@@ -0,0 +1,5 @@
+ALTER TABLE weakness_analyses
+MODIFY COLUMN llm_model VARCHAR(50) NOT NULL COMMENT '使用したLLMモデル名（例: claude-3-sonnet-20240229）';
+
+ALTER TABLE correction_results
+DROP COLUMN llm_model;
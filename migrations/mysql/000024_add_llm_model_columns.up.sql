@@ -0,0 +1,6 @@
+-- 採点・分析に実際に使用したモデルを記録する
+ALTER TABLE correction_results
+ADD COLUMN llm_model VARCHAR(100) NULL COMMENT '採点に使用したLLMモデル' AFTER prompt_version;
+
+ALTER TABLE weakness_analyses
+MODIFY COLUMN llm_model VARCHAR(255) NOT NULL COMMENT '分析に使用したLLMモデル（複数の場合はカンマ区切り）';
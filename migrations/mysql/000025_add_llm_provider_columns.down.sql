@@ -0,0 +1,8 @@
+ALTER TABLE llm_response_cache
+DROP COLUMN provider;
+
+ALTER TABLE weakness_analyses
+DROP COLUMN llm_provider;
+
+ALTER TABLE correction_results
+DROP COLUMN llm_provider;
@@ -0,0 +1,9 @@
+-- フォールバックチェーンで実際に応答したLLMプロバイダを記録する
+ALTER TABLE correction_results
+ADD COLUMN llm_provider VARCHAR(50) NULL COMMENT '採点に応答したLLMプロバイダ' AFTER llm_model;
+
+ALTER TABLE weakness_analyses
+ADD COLUMN llm_provider VARCHAR(255) NULL COMMENT '分析に応答したLLMプロバイダ（複数の場合はカンマ区切り）' AFTER llm_model;
+
+ALTER TABLE llm_response_cache
+ADD COLUMN provider VARCHAR(50) NOT NULL DEFAULT '' COMMENT '応答を生成したプロバイダ' AFTER llm_model;
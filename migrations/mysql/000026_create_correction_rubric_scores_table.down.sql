@@ -0,0 +1,2 @@
+-- CorrectionRubricScores テーブルの削除
+DROP TABLE IF EXISTS correction_rubric_scores;
@@ -0,0 +1,20 @@
+-- CorrectionRubricScores テーブルの作成
+-- 添削結果の評価観点（文法・語彙・課題達成度・自然さ・スペル）ごとの得点と根拠を記録するテーブル
+CREATE TABLE correction_rubric_scores (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    correction_result_id CHAR(36) NOT NULL COMMENT '添削結果ID',
+    criterion VARCHAR(50) NOT NULL COMMENT '評価観点（grammar, vocabulary, task_fulfilment, naturalness, spelling）',
+    score INT NOT NULL DEFAULT 0 COMMENT '評価観点の得点（0-100）',
+    weight INT NOT NULL DEFAULT 0 COMMENT '採点時の評価観点の重み（%）',
+    rationale TEXT NOT NULL COMMENT '得点の根拠',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'レコード最終更新日時',
+
+    -- インデックス
+    UNIQUE INDEX uk_correction_rubric_scores_result_criterion (correction_result_id, criterion),
+
+    -- 外部キー制約
+    CONSTRAINT fk_correction_rubric_scores_correction_result_id
+        FOREIGN KEY (correction_result_id) REFERENCES correction_results(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='添削結果の評価観点別得点テーブル';
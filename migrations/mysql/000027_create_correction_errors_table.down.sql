@@ -0,0 +1,2 @@
+-- CorrectionErrors テーブルの削除
+DROP TABLE IF EXISTS correction_errors;
@@ -0,0 +1,22 @@
+-- CorrectionErrors テーブルの作成
+-- 添削結果で指摘した解答中の誤り（文字位置・元の文字列・修正案・種類・説明）を記録するテーブル
+CREATE TABLE correction_errors (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    correction_result_id CHAR(36) NOT NULL COMMENT '添削結果ID',
+    start_offset INT NOT NULL COMMENT '解答中の誤りの開始位置（文字単位、0始まり）',
+    end_offset INT NOT NULL COMMENT '解答中の誤りの終了位置（文字単位、この位置の文字を含まない）',
+    original_text TEXT NOT NULL COMMENT '誤りのある元の文字列',
+    suggestion TEXT NOT NULL COMMENT '修正案（削除すべき場合は空文字）',
+    error_type VARCHAR(30) NOT NULL COMMENT '誤りの種類（article, tense, preposition, word_order, spelling など）',
+    explanation TEXT NOT NULL COMMENT '誤りの説明',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'レコード最終更新日時',
+
+    -- インデックス
+    INDEX idx_correction_errors_correction_result_id (correction_result_id, start_offset),
+
+    -- 外部キー制約
+    CONSTRAINT fk_correction_errors_correction_result_id
+        FOREIGN KEY (correction_result_id) REFERENCES correction_results(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='添削結果の誤り指摘テーブル';
@@ -0,0 +1,2 @@
+-- QuestionReferenceAnswers テーブルの削除
+DROP TABLE IF EXISTS question_reference_answers;
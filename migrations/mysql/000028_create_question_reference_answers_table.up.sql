@@ -0,0 +1,24 @@
+-- QuestionReferenceAnswers テーブルの作成
+-- 質問テンプレートごとに正解として認める解答例を、文体（フォーマル・カジュアル）と英語の種類（米国・英国）とともに管理するテーブル
+CREATE TABLE question_reference_answers (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    question_template_master_id CHAR(36) NOT NULL COMMENT '質問テンプレートID',
+    answer TEXT NOT NULL COMMENT '正解として認める解答例',
+    register VARCHAR(10) NOT NULL COMMENT '文体（formal, casual）',
+    variety VARCHAR(10) NOT NULL COMMENT '英語の種類（US, UK）',
+    created_by CHAR(36) NOT NULL COMMENT '作成者ID',
+    updated_by CHAR(36) NOT NULL COMMENT '更新者ID',
+    deleted_by CHAR(36) NULL COMMENT '削除者ID',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'レコード最終更新日時',
+    deleted_at DATETIME NULL COMMENT 'レコード削除日時（論理削除）',
+
+    -- インデックス
+    INDEX idx_question_reference_answers_question_template_master_id (question_template_master_id),
+    INDEX idx_question_reference_answers_deleted_at (deleted_at),
+
+    -- 外部キー制約
+    CONSTRAINT fk_question_reference_answers_question_template_master_id
+        FOREIGN KEY (question_template_master_id) REFERENCES question_template_masters(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='質問テンプレートの解答例テーブル';
@@ -0,0 +1,2 @@
+ALTER TABLE correction_results
+DROP COLUMN pre_check;
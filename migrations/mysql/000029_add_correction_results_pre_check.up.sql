@@ -0,0 +1,3 @@
+-- LLMを呼ばずに事前チェックで採点した理由を記録する
+ALTER TABLE correction_results
+ADD COLUMN pre_check VARCHAR(30) NULL COMMENT '事前チェックで採点した理由（empty, too_short, non_english, copied_question, reference_match。LLMで採点した場合はNULL）' AFTER llm_provider;
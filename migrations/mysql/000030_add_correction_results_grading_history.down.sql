@@ -0,0 +1,6 @@
+ALTER TABLE correction_results
+DROP INDEX idx_correction_results_answer_version,
+DROP COLUMN regrade_reason,
+DROP COLUMN grading_mode,
+DROP COLUMN is_authoritative,
+DROP COLUMN grading_version;
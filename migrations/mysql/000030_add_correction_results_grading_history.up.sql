@@ -0,0 +1,7 @@
+-- 同じ解答の採点（再採点を含む）をバージョンとして保持し、正式な採点結果を記録する
+ALTER TABLE correction_results
+ADD COLUMN grading_version INT NOT NULL DEFAULT 1 COMMENT '同じ解答の採点のバージョン（再採点のたびに1ずつ増える）' AFTER challenge_count,
+ADD COLUMN is_authoritative BOOLEAN NOT NULL DEFAULT TRUE COMMENT '解答の正式な採点結果かどうか（解答ごとに1件）' AFTER grading_version,
+ADD COLUMN grading_mode VARCHAR(20) NOT NULL DEFAULT 'standard' COMMENT '採点の方法（standard: 通常の採点, second_opinion: 厳格な再評価）' AFTER is_authoritative,
+ADD COLUMN regrade_reason TEXT NULL COMMENT '再採点を依頼した理由（初回の採点はNULL）' AFTER grading_mode,
+ADD INDEX idx_correction_results_answer_version (question_answer_id, grading_version);
@@ -0,0 +1,5 @@
+ALTER TABLE correction_results
+DROP INDEX idx_correction_results_needs_review,
+DROP COLUMN needs_review,
+DROP COLUMN score_variance,
+DROP COLUMN consensus_samples;
@@ -0,0 +1,6 @@
+-- 同じ解答を複数回採点して結果をまとめる合議採点のばらつきと要確認の判定を記録する
+ALTER TABLE correction_results
+ADD COLUMN consensus_samples INT NULL COMMENT '合議採点に使用した採点の回数（合議採点を行っていない場合はNULL）' AFTER pre_check,
+ADD COLUMN score_variance DOUBLE NULL COMMENT '合議採点の採点ごとの正答率の分散' AFTER consensus_samples,
+ADD COLUMN needs_review BOOLEAN NOT NULL DEFAULT FALSE COMMENT '採点のばらつきが大きく、人による確認が必要かどうか' AFTER score_variance,
+ADD INDEX idx_correction_results_needs_review (needs_review, created_at);
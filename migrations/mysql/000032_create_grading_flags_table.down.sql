@@ -0,0 +1,2 @@
+-- GradingFlags テーブルの削除
+DROP TABLE IF EXISTS grading_flags;
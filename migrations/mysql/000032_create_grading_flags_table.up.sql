@@ -0,0 +1,19 @@
+-- GradingFlags テーブルの作成
+-- 採点時に検出した、解答中の指示のような記述（プロンプトインジェクションの疑い）や解答の特徴と矛盾する採点結果を記録するテーブル
+CREATE TABLE grading_flags (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    correction_result_id CHAR(36) NOT NULL COMMENT '添削結果ID',
+    user_id CHAR(36) NOT NULL COMMENT '解答したユーザーID',
+    flag_type VARCHAR(30) NOT NULL COMMENT '検出した内容の種類（injection_suspected, inconsistent_grade）',
+    details TEXT NOT NULL COMMENT '検出した内容の詳細（改行区切り）',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+
+    -- インデックス
+    INDEX idx_grading_flags_created_at (created_at),
+    INDEX idx_grading_flags_correction_result_id (correction_result_id),
+
+    -- 外部キー制約
+    CONSTRAINT fk_grading_flags_correction_result_id
+        FOREIGN KEY (correction_result_id) REFERENCES correction_results(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='採点の要確認記録テーブル';
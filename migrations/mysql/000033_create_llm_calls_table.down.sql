@@ -0,0 +1,2 @@
+-- LLMCalls テーブルの削除
+DROP TABLE IF EXISTS llm_calls;
@@ -0,0 +1,27 @@
+-- LLMCalls テーブルの作成
+-- LLM呼び出しごとのプロンプト（学習者の入力を伏せたもの）・出力・解析結果・トークン数・所要時間を記録する監査用のテーブル（保存期間を過ぎたレコードは定期的に削除する）
+CREATE TABLE llm_calls (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    user_id CHAR(36) NOT NULL DEFAULT '' COMMENT '呼び出し元のユーザーID',
+    feature VARCHAR(50) NOT NULL COMMENT '呼び出し元の機能（grading, category_analysis など）',
+    subject_id CHAR(36) NOT NULL DEFAULT '' COMMENT '呼び出しに関連する添削結果・弱点分析のID',
+    llm_model VARCHAR(100) NOT NULL DEFAULT '' COMMENT '使用したモデル',
+    provider VARCHAR(50) NOT NULL DEFAULT '' COMMENT '応答したプロバイダ',
+    prompt_version VARCHAR(100) NOT NULL DEFAULT '' COMMENT '使用したプロンプトのバージョン（<プロンプト名>@<バージョン>）',
+    attempt INT NOT NULL DEFAULT 1 COMMENT '構造化出力の修正依頼を含めた試行回数',
+    redacted_prompt MEDIUMTEXT NOT NULL COMMENT '学習者の入力を伏せたプロンプト',
+    raw_response MEDIUMTEXT NOT NULL COMMENT 'LLMの出力テキスト',
+    outcome VARCHAR(30) NOT NULL COMMENT '出力の解析結果（parsed, invalid_json, schema_violation, text, error）',
+    error_message TEXT NULL COMMENT '呼び出しが失敗した場合のエラー',
+    input_tokens INT NOT NULL DEFAULT 0 COMMENT '入力トークン数',
+    output_tokens INT NOT NULL DEFAULT 0 COMMENT '出力トークン数',
+    latency_ms INT NOT NULL DEFAULT 0 COMMENT '所要時間（ミリ秒）',
+    cached BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'キャッシュから返した応答かどうか',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+
+    -- インデックス
+    INDEX idx_llm_calls_created_at (created_at),
+    INDEX idx_llm_calls_subject_id (subject_id),
+    INDEX idx_llm_calls_feature_created_at (feature, created_at),
+    INDEX idx_llm_calls_user_id_created_at (user_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='LLM呼び出し記録テーブル';
@@ -0,0 +1,2 @@
+-- TutorThreads テーブルの削除
+DROP TABLE IF EXISTS tutor_threads;
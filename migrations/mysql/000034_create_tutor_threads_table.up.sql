@@ -0,0 +1,18 @@
+-- TutorThreads テーブルの作成
+-- 添削結果ごとに、学習者がチューター（LLM）に質問する対話のスレッドを管理するテーブル
+CREATE TABLE tutor_threads (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    correction_result_id CHAR(36) NOT NULL COMMENT '対話の対象となる添削結果ID',
+    user_id CHAR(36) NOT NULL COMMENT '質問するユーザーID',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'レコード更新日時（最後のメッセージの日時）',
+
+    -- インデックス
+    UNIQUE KEY uk_tutor_threads_correction_result_id (correction_result_id),
+    INDEX idx_tutor_threads_user_id (user_id),
+
+    -- 外部キー制約
+    CONSTRAINT fk_tutor_threads_correction_result_id
+        FOREIGN KEY (correction_result_id) REFERENCES correction_results(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='チューターとの対話スレッドテーブル';
@@ -0,0 +1,2 @@
+-- TutorMessages テーブルの削除
+DROP TABLE IF EXISTS tutor_messages;
@@ -0,0 +1,24 @@
+-- TutorMessages テーブルの作成
+-- チューターとの対話のメッセージ（学習者の質問とチューターの回答）を保存するテーブル
+CREATE TABLE tutor_messages (
+    id CHAR(36) PRIMARY KEY COMMENT 'レコードの一意識別子',
+    thread_id CHAR(36) NOT NULL COMMENT 'スレッドID',
+    user_id CHAR(36) NOT NULL COMMENT 'スレッドのユーザーID（1日あたりの質問数の集計に使用する）',
+    message_index INT NOT NULL COMMENT 'スレッド内のメッセージの順番（1始まり）',
+    role VARCHAR(20) NOT NULL COMMENT '発話者（user: 学習者, assistant: チューター）',
+    content TEXT NOT NULL COMMENT 'メッセージ本文',
+    llm_model VARCHAR(100) NULL COMMENT '回答したモデル（チューターの回答のみ）',
+    prompt_version VARCHAR(100) NULL COMMENT '使用したプロンプトのバージョン（チューターの回答のみ）',
+    input_tokens INT NOT NULL DEFAULT 0 COMMENT '入力トークン数（チューターの回答のみ）',
+    output_tokens INT NOT NULL DEFAULT 0 COMMENT '出力トークン数（チューターの回答のみ）',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'レコード作成日時',
+
+    -- インデックス
+    UNIQUE KEY uk_tutor_messages_thread_id_message_index (thread_id, message_index),
+    INDEX idx_tutor_messages_user_id_role_created_at (user_id, role, created_at),
+
+    -- 外部キー制約
+    CONSTRAINT fk_tutor_messages_thread_id
+        FOREIGN KEY (thread_id) REFERENCES tutor_threads(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='チューターとの対話メッセージテーブル';